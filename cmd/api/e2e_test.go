@@ -72,7 +72,7 @@ func setupTestDB(t *testing.T) *sqlx.DB {
 	db, err := sqlx.Connect("pgx", dsn)
 	require.NoError(t, err, "Failed to connect to test database")
 
-	_, err = db.Exec("DROP TABLE IF EXISTS habit_entries, habits, users CASCADE")
+	_, err = db.Exec("DROP TABLE IF EXISTS habit_pauses, operations, habit_entries, habit_versions, habits, devices, user_sync_state, users CASCADE")
 	require.NoError(t, err, "Failed to drop tables")
 
 	schema := `
@@ -84,9 +84,38 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL
     );
 
-    CREATE TABLE habits (
+    CREATE TABLE devices (
         id TEXT PRIMARY KEY,
         user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        name TEXT NOT NULL DEFAULT '',
+        platform TEXT NOT NULL DEFAULT '',
+        last_cursor BIGINT NOT NULL DEFAULT 0,
+        last_synced_at TIMESTAMP WITH TIME ZONE,
+        last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        revoked_at TIMESTAMP WITH TIME ZONE
+    );
+
+    CREATE TABLE user_sync_state (
+        user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+        last_seq BIGINT NOT NULL DEFAULT 0,
+        min_valid_seq BIGINT NOT NULL DEFAULT 0
+    );
+
+    CREATE OR REPLACE FUNCTION assign_change_seq()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        INSERT INTO user_sync_state (user_id, last_seq)
+        VALUES (NEW.user_id, 1)
+        ON CONFLICT (user_id) DO UPDATE SET last_seq = user_sync_state.last_seq + 1
+        RETURNING last_seq INTO NEW.change_seq;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TABLE habits (
+        id UUID PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
         title TEXT NOT NULL,
         description TEXT,
         color TEXT,
@@ -98,6 +127,9 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         interval INTEGER,
         weekdays TEXT, -- JSON TEXT
         frequency_type TEXT,
+        times_per_period INTEGER NOT NULL DEFAULT 0,
+        rrule TEXT NOT NULL DEFAULT '',
+        exdates TEXT,
         
         start_date TIMESTAMP WITH TIME ZONE,
         end_date TIMESTAMP WITH TIME ZONE,
@@ -107,14 +139,16 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
         deleted_at TIMESTAMP WITH TIME ZONE,
         version INTEGER DEFAULT 1,
+        change_seq BIGINT NOT NULL DEFAULT 0,
+        hlc TEXT NOT NULL DEFAULT '',
         sort_order INTEGER DEFAULT 0,
         current_streak INTEGER DEFAULT 0,
         longest_streak INTEGER DEFAULT 0
     );
 
     CREATE TABLE habit_entries (
-        id TEXT PRIMARY KEY,
-        habit_id TEXT NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+        id UUID PRIMARY KEY,
+        habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
         user_id TEXT NOT NULL,
         value INTEGER NOT NULL,
         notes TEXT,
@@ -122,7 +156,80 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        version INTEGER DEFAULT 1
+        version INTEGER DEFAULT 1,
+        change_seq BIGINT NOT NULL DEFAULT 0,
+        hlc TEXT NOT NULL DEFAULT ''
+    );
+
+    CREATE TRIGGER assign_habits_change_seq BEFORE INSERT OR UPDATE ON habits
+    FOR EACH ROW EXECUTE PROCEDURE assign_change_seq();
+
+    CREATE TABLE habit_versions (
+        habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+        version INTEGER NOT NULL,
+        snapshot JSONB NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (habit_id, version)
+    );
+
+    CREATE OR REPLACE FUNCTION record_habit_version()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        INSERT INTO habit_versions (habit_id, version, snapshot)
+        VALUES (NEW.id, NEW.version, to_jsonb(NEW))
+        ON CONFLICT (habit_id, version) DO UPDATE SET snapshot = EXCLUDED.snapshot;
+
+        DELETE FROM habit_versions WHERE habit_id = NEW.id AND version <= NEW.version - 50;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TRIGGER record_habits_version AFTER INSERT OR UPDATE ON habits
+    FOR EACH ROW EXECUTE PROCEDURE record_habit_version();
+
+    CREATE TRIGGER assign_habit_entries_change_seq BEFORE INSERT OR UPDATE ON habit_entries
+    FOR EACH ROW EXECUTE PROCEDURE assign_change_seq();
+
+    CREATE OR REPLACE FUNCTION cascade_habit_soft_delete()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        IF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+            UPDATE habit_entries
+            SET deleted_at = NEW.deleted_at, updated_at = NOW(), version = version + 1
+            WHERE habit_id = NEW.id AND deleted_at IS NULL;
+        ELSIF NEW.deleted_at IS NULL AND OLD.deleted_at IS NOT NULL THEN
+            UPDATE habit_entries
+            SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+            WHERE habit_id = NEW.id AND deleted_at >= OLD.deleted_at;
+        END IF;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TRIGGER cascade_habits_soft_delete AFTER UPDATE OF deleted_at ON habits
+    FOR EACH ROW EXECUTE PROCEDURE cascade_habit_soft_delete();
+    CREATE TABLE operations (
+        id BIGSERIAL PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        device_id TEXT NOT NULL DEFAULT '',
+        entity_type TEXT NOT NULL,
+        entity_id TEXT NOT NULL,
+        habit_id TEXT NOT NULL,
+        op TEXT NOT NULL,
+        old_value JSONB,
+        new_value JSONB,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+    CREATE TABLE habit_pauses (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        habit_id UUID REFERENCES habits(id) ON DELETE CASCADE,
+        start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+        end_date TIMESTAMP WITH TIME ZONE,
+        reason TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
     );
     `
 	_, err = db.Exec(schema)
@@ -181,17 +288,17 @@ func TestEndToEnd_FullSystemLifecycle(t *testing.T) {
 	entryRepo := repository.NewPostgresEntryRepository(db)
 	userRepo := repository.NewPostgresUserRepository(db.DB)
 
-	streakWorker := workers.NewStreakWorker(habitRepoCached, entryRepo)
+	streakWorker := workers.NewStreakWorker(habitRepoCached, entryRepo, workers.StreakOptions{})
 
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	defer workerCancel()
 	streakWorker.Start(workerCtx)
 
-	tokenService := services.NewTokenService("test-secret-e2e", "kanso-e2e", 24*time.Hour, userRepo)
+	tokenService := services.NewTokenService("test-secret-e2e", "kanso-e2e", 24*time.Hour, userRepo, nil)
 
-	habitSvc := services.NewHabitService(habitRepoCached)
-	entrySvc := services.NewEntryService(entryRepo, habitRepoCached, streakWorker)
-	authSvc := services.NewAuthService(userRepo, tokenService)
+	habitSvc := services.NewHabitService(habitRepoCached, nil, services.WriteOptions{})
+	entrySvc := services.NewEntryService(entryRepo, habitRepoCached, streakWorker, nil, services.WriteOptions{})
+	authSvc := services.NewAuthService(userRepo, tokenService, nil)
 
 	habitHandler := adapterHTTP.NewHabitHandler(habitSvc)
 	entryHandler := adapterHTTP.NewEntryHandler(entrySvc)
@@ -350,16 +457,27 @@ func TestEndToEnd_FullSystemLifecycle(t *testing.T) {
 	})
 
 	t.Run("6. Sync Logic", func(t *testing.T) {
-		since := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
-		safeSince := url.QueryEscape(since)
-
-		req, _ := http.NewRequest("GET", "/api/v1/entries/sync?since="+safeSince, nil)
+		req, _ := http.NewRequest("GET", "/api/v1/entries/sync", nil)
 		req.Header.Set("Authorization", "Bearer "+authToken)
 
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), entryID)
+
+		var syncResp struct {
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &syncResp))
+		require.NotEmpty(t, syncResp.Cursor)
+
+		req, _ = http.NewRequest("GET", "/api/v1/entries/sync?since="+url.QueryEscape(syncResp.Cursor), nil)
+		req.Header.Set("Authorization", "Bearer "+authToken)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), entryID, "Nothing changed after the returned cursor")
 	})
 
 	t.Run("7. Security: IDOR Check (Attacker)", func(t *testing.T) {
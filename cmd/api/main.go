@@ -56,6 +56,14 @@ func main() {
 	jwtIssuer := getEnv("JWT_ISSUER", "kanso-api")
 	jwtExpStr := getEnv("JWT_EXPIRATION", "24h")
 
+	tombstoneRetentionStr := getEnv("TOMBSTONE_RETENTION", "720h")
+	tombstoneIntervalStr := getEnv("TOMBSTONE_GC_INTERVAL", "1h")
+	finalizeIntervalStr := getEnv("HABIT_FINALIZE_INTERVAL", "1h")
+	streamHeartbeatStr := getEnv("STREAM_HEARTBEAT", "25s")
+
+	conflictPolicyStr := getEnv("CONFLICT_POLICY", "version")
+	maxClockDriftStr := getEnv("MAX_CLOCK_DRIFT", "5m")
+
 	sslMode := getEnv("DB_SSLMODE", "disable")
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		dbUser, dbPass, dbHost, dbPort, dbName, sslMode)
@@ -85,38 +93,112 @@ func main() {
 		log.Fatalf("Invalid JWT duration: %v", err)
 	}
 
+	tombstoneRetention, err := time.ParseDuration(tombstoneRetentionStr)
+	if err != nil {
+		log.Fatalf("Invalid tombstone retention: %v", err)
+	}
+	tombstoneInterval, err := time.ParseDuration(tombstoneIntervalStr)
+	if err != nil {
+		log.Fatalf("Invalid tombstone GC interval: %v", err)
+	}
+	finalizeInterval, err := time.ParseDuration(finalizeIntervalStr)
+	if err != nil {
+		log.Fatalf("Invalid habit finalize interval: %v", err)
+	}
+	streamHeartbeat, err := time.ParseDuration(streamHeartbeatStr)
+	if err != nil {
+		log.Fatalf("Invalid stream heartbeat: %v", err)
+	}
+	conflictPolicy, err := services.ParseConflictPolicy(conflictPolicyStr)
+	if err != nil {
+		log.Fatalf("Invalid conflict policy: %v", err)
+	}
+	maxClockDrift, err := time.ParseDuration(maxClockDriftStr)
+	if err != nil {
+		log.Fatalf("Invalid max clock drift: %v", err)
+	}
+	clockPolicy := services.ClockPolicy{Conflicts: conflictPolicy, MaxDrift: maxClockDrift}
+
 	habitRepoPostgres := repository.NewPostgresHabitRepository(db)
 	entryRepo := repository.NewPostgresEntryRepository(db)
 	userRepo := repository.NewPostgresUserRepository(db.DB)
+	tombstoneRepo := repository.NewPostgresTombstoneRepository(db)
+	deviceRepo := repository.NewPostgresDeviceRepository(db)
+	snapshotRepo := repository.NewPostgresSnapshotRepository(db)
+	checksumRepo := repository.NewPostgresChecksumRepository(db)
+	operationRepo := repository.NewPostgresOperationRepository(db)
+	pauseRepo := repository.NewPostgresPauseRepository(db)
 
 	habitRepoCached := repository.NewCachedHabitRepository(habitRepoPostgres, rdb)
+	transactor := repository.NewPostgresTransactor(db)
+	changeNotifier := cache.NewRedisChangeNotifier(rdb)
 
-	streakWorker := workers.NewStreakWorker(habitRepoCached, entryRepo)
+	streakWorker := workers.NewStreakWorker(habitRepoCached, entryRepo, workers.StreakOptions{
+		Pauses:     pauseRepo,
+		Operations: operationRepo,
+		Tx:         transactor,
+	})
+	tombstoneWorker := workers.NewTombstoneWorker(tombstoneRepo, tombstoneRetention, tombstoneInterval)
 
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	streakWorker.Start(workerCtx)
+	tombstoneWorker.Start(workerCtx)
 
-	tokenService := services.NewTokenService(jwtSecret, jwtIssuer, tokenDuration, userRepo)
+	tokenService := services.NewTokenService(jwtSecret, jwtIssuer, tokenDuration, userRepo, deviceRepo)
+
+	writeOptions := services.WriteOptions{
+		Clock:      clockPolicy,
+		Tx:         transactor,
+		Operations: operationRepo,
+	}
 
-	habitService := services.NewHabitService(habitRepoCached)
-	authService := services.NewAuthService(userRepo, tokenService)
-	entryService := services.NewEntryService(entryRepo, habitRepoCached, streakWorker)
-	statsService := services.NewStatsService(habitRepoCached, entryRepo)
+	habitService := services.NewHabitService(habitRepoCached, changeNotifier, writeOptions)
+	finalizeWorker := workers.NewFinalizeWorker(habitRepoCached, habitService, finalizeInterval)
+	finalizeWorker.Start(workerCtx)
+	deviceService := services.NewDeviceService(deviceRepo)
+	authService := services.NewAuthService(userRepo, tokenService, deviceService)
+	entryService := services.NewEntryService(entryRepo, habitRepoCached, streakWorker, changeNotifier, writeOptions)
+	statsService := services.NewStatsService(habitRepoCached, entryRepo, pauseRepo)
+	syncService := services.NewSyncService(habitService, entryService, deviceService, transactor)
+	snapshotService := services.NewSnapshotService(snapshotRepo)
+	checksumService := services.NewChecksumService(checksumRepo)
+	operationService := services.NewOperationService(operationRepo)
+	trashService := services.NewTrashService(habitRepoCached, transactor, operationRepo, streakWorker, changeNotifier, tombstoneRetention)
+	scheduleService := services.NewScheduleService(habitRepoCached, entryRepo, pauseRepo)
+	pauseService := services.NewPauseService(pauseRepo, habitRepoCached, streakWorker)
 
 	habitHandler := adapterHTTP.NewHabitHandler(habitService)
 	entryHandler := adapterHTTP.NewEntryHandler(entryService)
 	authHandler := adapterHTTP.NewAuthHandler(authService)
 	statsHandler := adapterHTTP.NewStatsHandler(statsService)
+	syncHandler := adapterHTTP.NewSyncHandler(syncService)
+	streamHandler := adapterHTTP.NewStreamHandler(syncService, changeNotifier, streamHeartbeat)
+	deviceHandler := adapterHTTP.NewDeviceHandler(deviceService)
+	snapshotHandler := adapterHTTP.NewSnapshotHandler(snapshotService)
+	checksumHandler := adapterHTTP.NewChecksumHandler(checksumService)
+	operationHandler := adapterHTTP.NewOperationHandler(operationService)
+	trashHandler := adapterHTTP.NewTrashHandler(trashService)
+	scheduleHandler := adapterHTTP.NewScheduleHandler(scheduleService)
+	pauseHandler := adapterHTTP.NewPauseHandler(pauseService)
 
 	router := adapterHTTP.NewRouter(adapterHTTP.RouterDependencies{
-		AuthHandler:  authHandler,
-		HabitHandler: habitHandler,
-		EntryHandler: entryHandler,
-		StatsHandler: statsHandler,
-		TokenService: tokenService,
-		DB:           db,
-		Redis:        rdb,
-		StartTime:    startTime,
+		AuthHandler:      authHandler,
+		HabitHandler:     habitHandler,
+		EntryHandler:     entryHandler,
+		StatsHandler:     statsHandler,
+		SyncHandler:      syncHandler,
+		StreamHandler:    streamHandler,
+		DeviceHandler:    deviceHandler,
+		SnapshotHandler:  snapshotHandler,
+		ChecksumHandler:  checksumHandler,
+		OperationHandler: operationHandler,
+		TrashHandler:     trashHandler,
+		ScheduleHandler:  scheduleHandler,
+		PauseHandler:     pauseHandler,
+		TokenService:     tokenService,
+		DB:               db,
+		Redis:            rdb,
+		StartTime:        startTime,
 	})
 
 	srv := &http.Server{
@@ -151,6 +233,8 @@ func main() {
 	log.Println("Stopping workers...")
 	workerCancel()
 	streakWorker.Stop()
+	tombstoneWorker.Stop()
+	finalizeWorker.Stop()
 	log.Println("Workers stopped.")
 
 	log.Println("Server exited properly.")
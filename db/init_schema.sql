@@ -24,6 +24,49 @@ CREATE TABLE IF NOT EXISTS users (
 
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 
+-- DEVICES table
+
+-- One row per app installation. last_cursor is the newest sync cursor the
+-- device has acknowledged; the tombstone GC keeps expired deletions until
+-- every active device has moved past them.
+CREATE TABLE IF NOT EXISTS devices (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    platform VARCHAR(50) NOT NULL DEFAULT '',
+    last_cursor BIGINT NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices(user_id);
+
+-- USER SYNC STATE table
+
+-- Holds the last change sequence handed out per user. Every write to a habit
+-- or an entry takes the next value while holding this row lock, so sequences
+-- become visible in commit order and a cursor can never skip a change.
+-- min_valid_seq is raised by the tombstone GC: cursors below it may have
+-- missed a purged deletion and must resync from scratch.
+CREATE TABLE IF NOT EXISTS user_sync_state (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    min_valid_seq BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE OR REPLACE FUNCTION assign_change_seq()
+RETURNS TRIGGER AS $$
+BEGIN
+   INSERT INTO user_sync_state (user_id, last_seq)
+   VALUES (NEW.user_id, 1)
+   ON CONFLICT (user_id) DO UPDATE SET last_seq = user_sync_state.last_seq + 1
+   RETURNING last_seq INTO NEW.change_seq;
+   RETURN NEW;
+END;
+$$ language 'plpgsql';
+
 DROP TRIGGER IF EXISTS update_users_updated_at ON users;
 CREATE TRIGGER update_users_updated_at
 BEFORE UPDATE ON users
@@ -43,7 +86,10 @@ CREATE TABLE IF NOT EXISTS habits (
     sort_order INTEGER DEFAULT 0,
     
     type VARCHAR(50) NOT NULL CHECK (type IN ('boolean', 'timer', 'numeric')),
-    frequency_type VARCHAR(50) NOT NULL CHECK (frequency_type IN ('daily', 'weekly', 'specific_days', 'interval')),
+    frequency_type VARCHAR(50) NOT NULL CHECK (frequency_type IN ('daily', 'weekly', 'monthly', 'specific_days', 'interval', 'rrule')),
+    times_per_period INTEGER NOT NULL DEFAULT 0 CHECK (times_per_period BETWEEN 0 AND 31),
+    rrule VARCHAR(500) NOT NULL DEFAULT '',
+    exdates JSONB,
     weekdays JSONB,
     reminder_time VARCHAR(10),
     
@@ -55,10 +101,13 @@ CREATE TABLE IF NOT EXISTS habits (
     longest_streak INTEGER DEFAULT 0 CHECK (longest_streak >= 0),
     
     start_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    start_date_set BOOLEAN NOT NULL DEFAULT FALSE,
     end_date TIMESTAMP WITH TIME ZONE,
     archived_at TIMESTAMP WITH TIME ZONE,
     
     version INTEGER DEFAULT 1 NOT NULL,
+    change_seq BIGINT NOT NULL DEFAULT 0,
+    hlc VARCHAR(100) NOT NULL DEFAULT '',
     deleted_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
@@ -66,6 +115,9 @@ CREATE TABLE IF NOT EXISTS habits (
 
 CREATE INDEX IF NOT EXISTS idx_habits_user_id ON habits(user_id);
 CREATE INDEX IF NOT EXISTS idx_habits_updated_at ON habits(updated_at);
+CREATE INDEX IF NOT EXISTS idx_habits_user_change_seq ON habits(user_id, change_seq);
+CREATE INDEX IF NOT EXISTS idx_habits_tombstones ON habits(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_habits_ending ON habits(end_date) WHERE end_date IS NOT NULL AND archived_at IS NULL AND deleted_at IS NULL;
 
 DROP TRIGGER IF EXISTS update_habits_updated_at ON habits;
 CREATE TRIGGER update_habits_updated_at
@@ -73,6 +125,43 @@ BEFORE UPDATE ON habits
 FOR EACH ROW
 EXECUTE PROCEDURE update_updated_at_column();
 
+DROP TRIGGER IF EXISTS assign_habits_change_seq ON habits;
+CREATE TRIGGER assign_habits_change_seq
+BEFORE INSERT OR UPDATE ON habits
+FOR EACH ROW
+EXECUTE PROCEDURE assign_change_seq();
+
+-- HABIT VERSIONS table
+
+-- Snapshot of every habit version, used as the common ancestor when two
+-- devices edit the same habit concurrently. Only the last 50 versions of a
+-- habit are kept.
+CREATE TABLE IF NOT EXISTS habit_versions (
+    habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (habit_id, version)
+);
+
+CREATE OR REPLACE FUNCTION record_habit_version()
+RETURNS TRIGGER AS $$
+BEGIN
+   INSERT INTO habit_versions (habit_id, version, snapshot)
+   VALUES (NEW.id, NEW.version, to_jsonb(NEW))
+   ON CONFLICT (habit_id, version) DO UPDATE SET snapshot = EXCLUDED.snapshot;
+
+   DELETE FROM habit_versions WHERE habit_id = NEW.id AND version <= NEW.version - 50;
+   RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS record_habits_version ON habits;
+CREATE TRIGGER record_habits_version
+AFTER INSERT OR UPDATE ON habits
+FOR EACH ROW
+EXECUTE PROCEDURE record_habit_version();
+
 -- Tabella HABIT ENTRIES TABLE
 
 CREATE TABLE IF NOT EXISTS habit_entries (
@@ -85,6 +174,8 @@ CREATE TABLE IF NOT EXISTS habit_entries (
     notes TEXT,
     
     version INTEGER DEFAULT 1,
+    change_seq BIGINT NOT NULL DEFAULT 0,
+    hlc VARCHAR(100) NOT NULL DEFAULT '',
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     deleted_at TIMESTAMP WITH TIME ZONE,
@@ -94,9 +185,100 @@ CREATE TABLE IF NOT EXISTS habit_entries (
 
 CREATE INDEX IF NOT EXISTS idx_habit_entries_user_updated ON habit_entries(user_id, updated_at);
 CREATE INDEX IF NOT EXISTS idx_habit_entries_habit_date ON habit_entries(habit_id, completion_date);
+CREATE INDEX IF NOT EXISTS idx_habit_entries_user_change_seq ON habit_entries(user_id, change_seq);
+CREATE INDEX IF NOT EXISTS idx_habit_entries_tombstones ON habit_entries(deleted_at) WHERE deleted_at IS NOT NULL;
 
 DROP TRIGGER IF EXISTS update_habit_entries_updated_at ON habit_entries;
 CREATE TRIGGER update_habit_entries_updated_at
 BEFORE UPDATE ON habit_entries
 FOR EACH ROW
-EXECUTE PROCEDURE update_updated_at_column();
\ No newline at end of file
+EXECUTE PROCEDURE update_updated_at_column();
+
+DROP TRIGGER IF EXISTS assign_habit_entries_change_seq ON habit_entries;
+CREATE TRIGGER assign_habit_entries_change_seq
+BEFORE INSERT OR UPDATE ON habit_entries
+FOR EACH ROW
+EXECUTE PROCEDURE assign_change_seq();
+
+-- Soft-deleting a habit soft-deletes its live entries with the same
+-- deleted_at, in the same statement, so that delta sync emits their
+-- tombstones and stats stop counting them. Undeleting the habit brings back
+-- the entries deleted with or after it; entries deleted earlier stay deleted.
+
+CREATE OR REPLACE FUNCTION cascade_habit_soft_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+   IF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+      UPDATE habit_entries
+      SET deleted_at = NEW.deleted_at, updated_at = NOW(), version = version + 1
+      WHERE habit_id = NEW.id AND deleted_at IS NULL;
+   ELSIF NEW.deleted_at IS NULL AND OLD.deleted_at IS NOT NULL THEN
+      UPDATE habit_entries
+      SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+      WHERE habit_id = NEW.id AND deleted_at >= OLD.deleted_at;
+   END IF;
+   RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS cascade_habits_soft_delete ON habits;
+CREATE TRIGGER cascade_habits_soft_delete
+AFTER UPDATE OF deleted_at ON habits
+FOR EACH ROW
+EXECUTE PROCEDURE cascade_habit_soft_delete();
+
+-- OPERATIONS table
+--
+-- Append-only history of every write to a habit or an entry, with the device
+-- that made it and the row before and after. Written in the same transaction
+-- as the change. Rows have no foreign key to habits or entries, so history
+-- outlives purged tombstones.
+
+CREATE TABLE IF NOT EXISTS operations (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) NOT NULL DEFAULT '',
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    habit_id VARCHAR(255) NOT NULL,
+    op VARCHAR(20) NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_operations_user ON operations(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_operations_habit ON operations(user_id, habit_id, id DESC);
+
+CREATE OR REPLACE FUNCTION reject_operation_update()
+RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'operations is append-only';
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS operations_append_only ON operations;
+CREATE TRIGGER operations_append_only
+BEFORE UPDATE ON operations
+FOR EACH ROW
+EXECUTE PROCEDURE reject_operation_update();
+
+
+-- HABIT PAUSES table
+
+-- Vacation or sick days: a pause suspends one habit, or every habit of the
+-- user when habit_id is NULL, from start_date to end_date included (open-ended
+-- without an end). Paused days are not due, are left out of the stats and
+-- neither break nor extend a streak.
+CREATE TABLE IF NOT EXISTS habit_pauses (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    habit_id UUID REFERENCES habits(id) ON DELETE CASCADE,
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE,
+    reason VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_habit_pauses_user ON habit_pauses(user_id, start_date DESC);
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const changeChannelPrefix = "changes:user:"
+
+// RedisChangeNotifier fans change signals out through Redis pub/sub, so a
+// write handled by one API instance reaches streams held by any other.
+type RedisChangeNotifier struct {
+	rdb *redis.Client
+}
+
+func NewRedisChangeNotifier(rdb *redis.Client) *RedisChangeNotifier {
+	return &RedisChangeNotifier{rdb: rdb}
+}
+
+func (n *RedisChangeNotifier) Publish(ctx context.Context, userID string) error {
+	return n.rdb.Publish(ctx, changeChannelPrefix+userID, "").Err()
+}
+
+func (n *RedisChangeNotifier) Subscribe(ctx context.Context, userID string) (<-chan struct{}, error) {
+	pubsub := n.rdb.Subscribe(ctx, changeChannelPrefix+userID)
+
+	// Wait for the subscription to be confirmed, so that no change published
+	// after Subscribe returns can be missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	signals := make(chan struct{}, 1)
+	go func() {
+		defer close(signals)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case signals <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return signals, nil
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisChangeNotifier_Integration(t *testing.T) {
+	_ = godotenv.Load("../../../.env")
+
+	rdb, err := NewRedisClient(
+		getEnv("REDIS_HOST", "localhost"),
+		getEnv("REDIS_PORT", "6379"),
+		getEnv("REDIS_PASSWORD", "secret_redis_pass_local"),
+		1,
+	)
+	if err != nil {
+		t.Skipf("Skipping Redis integration test: %v", err)
+	}
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notifier := NewRedisChangeNotifier(rdb)
+
+	signals, err := notifier.Subscribe(ctx, "user-a")
+	require.NoError(t, err)
+
+	other, err := notifier.Subscribe(ctx, "user-b")
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Publish(ctx, "user-a"))
+
+	select {
+	case <-signals:
+	case <-ctx.Done():
+		t.Fatal("Subscriber did not receive the change signal")
+	}
+
+	select {
+	case <-other:
+		t.Fatal("Signals must not leak to other users")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	for range signals {
+	}
+}
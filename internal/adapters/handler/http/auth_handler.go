@@ -30,8 +30,15 @@ type userResponse struct {
 }
 
 type loginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email    string              `json:"email" binding:"required,email"`
+	Password string              `json:"password" binding:"required"`
+	Device   *loginDeviceRequest `json:"device"`
+}
+
+type loginDeviceRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Platform string `json:"platform"`
 }
 
 type loginResponse struct {
@@ -40,6 +47,7 @@ type loginResponse struct {
 		ID    string `json:"id"`
 		Email string `json:"email"`
 	} `json:"user"`
+	Device *domain.Device `json:"device,omitempty"`
 }
 
 // Register godoc
@@ -91,7 +99,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary      User Login
-// @Description  Authenticates a user and returns a JWT token
+// @Description  Authenticates a user and returns a JWT token.
+// @Description  When 'device' is sent, the device is registered (or refreshed) and the token is bound to it, so revoking the device revokes the token.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -99,6 +108,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Success      200  {object}  loginResponse
 // @Failure      400  {object}  map[string]string "Invalid Input"
 // @Failure      401  {object}  map[string]string "Invalid Credentials"
+// @Failure      403  {object}  map[string]string "Device Revoked"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -113,12 +123,23 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Email:    req.Email,
 		Password: req.Password,
 	}
+	if req.Device != nil {
+		input.Device = &services.RegisterDeviceInput{
+			ID:       req.Device.ID,
+			Name:     req.Device.Name,
+			Platform: req.Device.Platform,
+		}
+	}
 
 	output, err := h.service.Login(c.Request.Context(), input)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrUserNotFound):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		case errors.Is(err, domain.ErrDeviceRevoked):
+			c.JSON(http.StatusForbidden, gin.H{"error": "device revoked"})
+		case errors.Is(err, domain.ErrDeviceNameTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			_ = c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
@@ -135,6 +156,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			ID:    output.User.ID,
 			Email: output.User.Email,
 		},
+		Device: output.Device,
 	})
 }
 
@@ -52,9 +52,9 @@ func setupHandler(middleware gin.HandlerFunc) (*gin.Engine, *MockUserRepository)
 
 	mockRepo := new(MockUserRepository)
 
-	tokenService := services.NewTokenService("test-secret-key", "test-issuer", 1*time.Hour, mockRepo)
+	tokenService := services.NewTokenService("test-secret-key", "test-issuer", 1*time.Hour, mockRepo, nil)
 
-	authService := services.NewAuthService(mockRepo, tokenService)
+	authService := services.NewAuthService(mockRepo, tokenService, nil)
 	authHandler := NewAuthHandler(authService)
 
 	router := gin.New()
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type ChecksumHandler struct {
+	svc *services.ChecksumService
+}
+
+func NewChecksumHandler(svc *services.ChecksumService) *ChecksumHandler {
+	return &ChecksumHandler{
+		svc: svc,
+	}
+}
+
+func (h *ChecksumHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/sync/checksum", h.Checksum)
+}
+
+// Checksum godoc
+// @Summary      Sync integrity checksum
+// @Description  Returns a Merkle-style digest of every habit and entry of the user, deleted ones included, bucketed by month
+// @Description  (habits by creation time, entries by completion date, in UTC). Each item is hashed as "<kind>|<id>|<version>|<deleted_at in unix microseconds, 0 if live>";
+// @Description  a bucket hash is the SHA-256 of its item lines sorted by kind and id, and the root is the SHA-256 of the "<month>:<bucket hash>" lines.
+// @Description  The digest is current as of the returned cursor: sync up to it, compare the root, then the buckets, and pass month to list the items of a mismatching bucket.
+// @Tags         Sync
+// @Produce      json
+// @Security     BearerAuth
+// @Param        month query string false "Bucket to list the items of (YYYY-MM)"
+// @Success      200  {object}  domain.SyncChecksum
+// @Failure      400  {object}  map[string]string "Invalid Month"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /sync/checksum [get]
+func (h *ChecksumHandler) Checksum(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	sum, err := h.svc.Checksum(c.Request.Context(), userID, c.Query("month"))
+	if errors.Is(err, domain.ErrInvalidChecksumMonth) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Checksum failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute checksum"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, sum)
+}
@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakeChecksumRepo struct {
+	cursor int64
+	items  []domain.ChecksumItem
+	err    error
+}
+
+func (r *fakeChecksumRepo) ChecksumItems(ctx context.Context, userID string) (int64, []domain.ChecksumItem, error) {
+	return r.cursor, r.items, r.err
+}
+
+func setupChecksumRouter(repo domain.ChecksumRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := adapterHTTP.NewChecksumHandler(services.NewChecksumService(repo))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+	return r
+}
+
+func TestChecksum(t *testing.T) {
+	repo := &fakeChecksumRepo{
+		cursor: 7,
+		items: []domain.ChecksumItem{
+			{Kind: domain.ChecksumKindHabit, ID: "h-1", Version: 2, Date: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+			{Kind: domain.ChecksumKindEntry, ID: "e-1", Version: 1, Date: time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	t.Run("Success: Root and month buckets with the cursor", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/sync/checksum", nil)
+		setupChecksumRouter(repo).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var sum domain.SyncChecksum
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sum))
+		assert.Equal(t, int64(7), sum.Cursor)
+		assert.Equal(t, domain.BuildChecksum(7, repo.items, "").Root, sum.Root)
+		require.Len(t, sum.Buckets, 2)
+		assert.Empty(t, sum.Buckets[0].Items)
+		assert.Contains(t, w.Body.String(), `"cursor":"7"`)
+	})
+
+	t.Run("Success: Lists the items of the requested month", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/sync/checksum?month=2026-02", nil)
+		setupChecksumRouter(repo).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var sum domain.SyncChecksum
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sum))
+		assert.Empty(t, sum.Buckets[0].Items)
+		require.Len(t, sum.Buckets[1].Items, 1)
+		assert.Equal(t, "e-1", sum.Buckets[1].Items[0].ID)
+	})
+
+	t.Run("Fail: 400 on an invalid month", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/sync/checksum?month=february", nil)
+		setupChecksumRouter(repo).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: 500 when the data cannot be read", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/sync/checksum", nil)
+		setupChecksumRouter(&fakeChecksumRepo{err: errors.New("db down")}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidCursor    = errors.New("invalid sync cursor")
+	errInvalidPageToken = errors.New("invalid page token")
+	errInvalidPageLimit = errors.New("invalid limit, must be a positive integer")
+)
+
+// parseSyncCursor decodes the opaque cursor handed out by the sync endpoints.
+// Cursors issued before change sequences existed were RFC3339 timestamps:
+// they cannot be mapped onto a sequence, so they restart from zero and the
+// client receives a full resync instead of missing changes.
+func parseSyncCursor(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err == nil {
+		if seq < 0 {
+			return 0, errInvalidCursor
+		}
+		return seq, nil
+	}
+
+	if _, err := time.Parse(time.RFC3339, raw); err == nil {
+		return 0, nil
+	}
+
+	return 0, errInvalidCursor
+}
+
+func formatSyncCursor(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+func encodePageToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(formatSyncCursor(seq)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errInvalidPageToken
+	}
+
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, errInvalidPageToken
+	}
+	return seq, nil
+}
+
+func parseSyncLimit(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return 0, errInvalidPageLimit
+	}
+	return limit, nil
+}
+
+// parseSyncPage reads the cursor, page_token and limit query parameters of a
+// delta sync request. A page token continues a previous page and takes
+// precedence over the cursor.
+func parseSyncPage(c *gin.Context, cursorParam string) (int64, int, error) {
+	limit, err := parseSyncLimit(c.Query("limit"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if token := c.Query("page_token"); token != "" {
+		since, err := decodePageToken(token)
+		return since, limit, err
+	}
+
+	since, err := parseSyncCursor(c.Query(cursorParam))
+	return since, limit, err
+}
+
+func syncPageResponse(changes interface{}, cursor int64, hasMore bool) gin.H {
+	resp := gin.H{
+		"changes":  changes,
+		"cursor":   formatSyncCursor(cursor),
+		"has_more": hasMore,
+	}
+	if hasMore {
+		resp["next_page_token"] = encodePageToken(cursor)
+	}
+	return resp
+}
+
+// deltaSyncResponse is the page returned by GET /habits/sync and
+// /entries/sync. It repeats the cursor under timestamp, the key those
+// endpoints used before cursors became opaque, for clients that still read
+// it; timestamp is deprecated.
+func deltaSyncResponse(changes interface{}, cursor int64, hasMore bool) gin.H {
+	resp := syncPageResponse(changes, cursor, hasMore)
+	resp["timestamp"] = resp["cursor"]
+	return resp
+}
+
+// respondCursorExpired tells the client that deletions older than its cursor
+// have been purged and it must drop local state and sync without a cursor.
+func respondCursorExpired(c *gin.Context) {
+	c.JSON(http.StatusGone, gin.H{
+		"error":   "sync cursor expired",
+		"message": "full resync required, sync again without a cursor",
+	})
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type DeviceHandler struct {
+	svc *services.DeviceService
+}
+
+func NewDeviceHandler(svc *services.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		svc: svc,
+	}
+}
+
+type renameDeviceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type deviceResponse struct {
+	*domain.Device
+	Current bool `json:"current"`
+}
+
+func (h *DeviceHandler) RegisterRoutes(router *gin.RouterGroup) {
+	devices := router.Group("/devices")
+	{
+		devices.GET("", h.List)
+		devices.PUT("/:id", h.Rename)
+		devices.DELETE("/:id", h.Revoke)
+	}
+}
+
+// List godoc
+// @Summary      List devices
+// @Description  Get every device registered by the user, including revoked ones, with the last cursor each one acknowledged and when it last synced.
+// @Description  'current' marks the device the request was made from.
+// @Tags         Devices
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   deviceResponse
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /devices [get]
+func (h *DeviceHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	devices, err := h.svc.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[ERROR] Listing devices failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list devices"})
+		return
+	}
+
+	currentID, _ := middleware.GetDeviceID(c)
+
+	resp := make([]deviceResponse, 0, len(devices))
+	for _, d := range devices {
+		resp = append(resp, deviceResponse{Device: d, Current: d.ID == currentID})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Rename godoc
+// @Summary      Rename a device
+// @Tags         Devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path string true "Device ID"
+// @Param        device body renameDeviceRequest true "New name"
+// @Success      200  {object}  domain.Device
+// @Failure      400  {object}  map[string]string "Invalid Input"
+// @Failure      404  {object}  map[string]string "Device Not Found"
+// @Router       /devices/{id} [put]
+func (h *DeviceHandler) Rename(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req renameDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := h.svc.Rename(c.Request.Context(), c.Param("id"), userID, req.Name)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+// Revoke godoc
+// @Summary      Revoke a device
+// @Description  Revoke a lost or retired device: its tokens stop working immediately and it must log in again as a new device.
+// @Tags         Devices
+// @Security     BearerAuth
+// @Param        id   path string true "Device ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]string "Device Not Found"
+// @Router       /devices/{id} [delete]
+func (h *DeviceHandler) Revoke(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	if err := h.svc.Revoke(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *DeviceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDeviceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+	case errors.Is(err, domain.ErrDeviceNameTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[ERROR] Request %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,172 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type MockDeviceRepo struct {
+	store map[string]*domain.Device
+}
+
+func NewMockDeviceRepo() *MockDeviceRepo {
+	return &MockDeviceRepo{store: make(map[string]*domain.Device)}
+}
+
+func (m *MockDeviceRepo) Create(ctx context.Context, d *domain.Device) error {
+	clone := *d
+	m.store[d.ID] = &clone
+	return nil
+}
+
+func (m *MockDeviceRepo) GetByID(ctx context.Context, id string) (*domain.Device, error) {
+	d, ok := m.store[id]
+	if !ok {
+		return nil, domain.ErrDeviceNotFound
+	}
+	clone := *d
+	return &clone, nil
+}
+
+func (m *MockDeviceRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Device, error) {
+	var out []*domain.Device
+	for _, d := range m.store {
+		if d.UserID == userID {
+			clone := *d
+			out = append(out, &clone)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockDeviceRepo) Update(ctx context.Context, d *domain.Device) error {
+	if _, ok := m.store[d.ID]; !ok {
+		return domain.ErrDeviceNotFound
+	}
+	clone := *d
+	m.store[d.ID] = &clone
+	return nil
+}
+
+func (m *MockDeviceRepo) AcknowledgeCursor(ctx context.Context, id string, cursor int64, at time.Time) error {
+	d, ok := m.store[id]
+	if !ok || d.IsRevoked() {
+		return domain.ErrDeviceNotFound
+	}
+	d.LastCursor = max(d.LastCursor, cursor)
+	d.LastSyncedAt = &at
+	return nil
+}
+
+func setupDeviceRouter(t *testing.T) (*gin.Engine, *services.DeviceService, *MockDeviceRepo) {
+	gin.SetMode(gin.TestMode)
+
+	repo := NewMockDeviceRepo()
+	svc := services.NewDeviceService(repo)
+	handler := adapterHTTP.NewDeviceHandler(svc)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		if deviceID := c.GetHeader("X-Device-ID"); deviceID != "" {
+			c.Set(middleware.ContextDeviceIDKey, deviceID)
+		}
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+
+	ctx := context.Background()
+	for _, in := range []services.RegisterDeviceInput{
+		{ID: "phone", UserID: "user-1", Name: "Phone"},
+		{ID: "laptop", UserID: "user-1", Name: "Laptop"},
+		{ID: "other", UserID: "user-2", Name: "Not mine"},
+	} {
+		_, err := svc.Register(ctx, in)
+		require.NoError(t, err)
+	}
+
+	return r, svc, repo
+}
+
+func TestDeviceHandler(t *testing.T) {
+	t.Run("List: Returns the user's devices and marks the current one", func(t *testing.T) {
+		r, _, _ := setupDeviceRouter(t)
+
+		req, _ := http.NewRequest("GET", "/api/v1/devices", nil)
+		req.Header.Set("X-Device-ID", "phone")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp []struct {
+			ID      string `json:"id"`
+			Current bool   `json:"current"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp, 2)
+
+		current := map[string]bool{}
+		for _, d := range resp {
+			current[d.ID] = d.Current
+		}
+		assert.Equal(t, map[string]bool{"phone": true, "laptop": false}, current)
+	})
+
+	t.Run("Rename: Updates the name", func(t *testing.T) {
+		r, _, repo := setupDeviceRouter(t)
+
+		req, _ := http.NewRequest("PUT", "/api/v1/devices/laptop", strings.NewReader(`{"name":"Work laptop"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Work laptop", repo.store["laptop"].Name)
+	})
+
+	t.Run("Rename: 400 without a name", func(t *testing.T) {
+		r, _, _ := setupDeviceRouter(t)
+
+		req, _ := http.NewRequest("PUT", "/api/v1/devices/laptop", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Revoke: 204 and the device is revoked", func(t *testing.T) {
+		r, _, repo := setupDeviceRouter(t)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/devices/laptop", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.True(t, repo.store["laptop"].IsRevoked())
+	})
+
+	t.Run("Revoke: 404 for another user's device", func(t *testing.T) {
+		r, _, repo := setupDeviceRouter(t)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/devices/other", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.False(t, repo.store["other"].IsRevoked())
+	})
+}
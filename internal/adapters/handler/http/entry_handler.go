@@ -24,16 +24,19 @@ func NewEntryHandler(svc *services.EntryService) *EntryHandler {
 }
 
 type createEntryRequest struct {
+	ID             string    `json:"id" binding:"omitempty,uuid"`
 	HabitID        string    `json:"habit_id" binding:"required"`
 	CompletionDate time.Time `json:"completion_date" binding:"required"`
 	Value          int       `json:"value"`
 	Notes          string    `json:"notes"`
+	HLC            string    `json:"hlc"`
 }
 
 type updateEntryRequest struct {
 	Value   int    `json:"value"`
 	Notes   string `json:"notes"`
-	Version int    `json:"version" binding:"required"`
+	Version int    `json:"version"`
+	HLC     string `json:"hlc"`
 }
 
 func (h *EntryHandler) RegisterRoutes(router *gin.RouterGroup) {
@@ -50,6 +53,8 @@ func (h *EntryHandler) RegisterRoutes(router *gin.RouterGroup) {
 // Create godoc
 // @Summary      Log a habit entry
 // @Description  Record a completion or value for a specific habit on a specific date
+// @Description  Offline clients may send their own UUID as id: repeating the request returns the stored entry instead of a duplicate,
+// @Description  and an entry that was deleted on the server is brought back with the submitted data.
 // @Tags         Entries
 // @Accept       json
 // @Produce      json
@@ -57,6 +62,7 @@ func (h *EntryHandler) RegisterRoutes(router *gin.RouterGroup) {
 // @Param        entry body createEntryRequest true "Entry Data"
 // @Success      201  {object}  domain.HabitEntry
 // @Failure      400  {object}  map[string]string "Invalid Input"
+// @Failure      409  {object}  map[string]string "ID already taken"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /entries [post]
 func (h *EntryHandler) Create(c *gin.Context) {
@@ -73,11 +79,13 @@ func (h *EntryHandler) Create(c *gin.Context) {
 	}
 
 	input := services.CreateEntryInput{
+		ID:             req.ID,
 		HabitID:        req.HabitID,
 		UserID:         userID,
 		CompletionDate: req.CompletionDate,
 		Value:          req.Value,
 		Notes:          req.Notes,
+		HLC:            req.HLC,
 	}
 
 	entry, err := h.svc.Create(c.Request.Context(), input)
@@ -86,22 +94,27 @@ func (h *EntryHandler) Create(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(entry.Version))
 	c.JSON(http.StatusCreated, entry)
 }
 
 // Update godoc
 // @Summary      Update an entry value
 // @Description  Change the value or completion status. Requires current version for optimistic locking.
+// @Description  A 409 carries the current server copy, its version and a diff of the submitted fields against it.
+// @Description  The version may be sent as If-Match (the ETag of the copy being edited) instead; conflicts are then reported as 412.
 // @Tags         Entries
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id    path string true "Entry ID"
+// @Param        If-Match header string false "ETag of the current version, instead of the body version"
 // @Param        entry body updateEntryRequest true "Update Data"
 // @Success      200  {object}  domain.HabitEntry
 // @Failure      400  {object}  map[string]string "Invalid Input"
 // @Failure      404  {object}  map[string]string "Entry not found"
 // @Failure      409  {object}  map[string]string "Version Conflict"
+// @Failure      412  {object}  map[string]string "Version Conflict with If-Match"
 // @Router       /entries/{id} [put]
 func (h *EntryHandler) Update(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -117,12 +130,19 @@ func (h *EntryHandler) Update(c *gin.Context) {
 		return
 	}
 
+	version, err := updateVersion(c, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	input := services.UpdateEntryInput{
 		ID:      id,
 		UserID:  userID,
 		Value:   req.Value,
 		Notes:   req.Notes,
-		Version: req.Version,
+		Version: version,
+		HLC:     req.HLC,
 	}
 
 	entry, err := h.svc.Update(c.Request.Context(), input)
@@ -131,6 +151,7 @@ func (h *EntryHandler) Update(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(entry.Version))
 	c.JSON(http.StatusOK, entry)
 }
 
@@ -164,14 +185,16 @@ func (h *EntryHandler) Delete(c *gin.Context) {
 
 // ListByHabit godoc
 // @Summary      List entries for a habit
-// @Description  Get history of entries for a specific habit ID within a date range
+// @Description  Get history of entries for a specific habit ID within a date range. The response carries an ETag; a matching If-None-Match returns 304.
 // @Tags         Entries
-// @Produce      json
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Security     BearerAuth
+// @Param        If-None-Match header string false "ETag of the cached list"
 // @Param        habit_id query string true "Habit ID"
 // @Param        from     query string false "Start Date (RFC3339) - Default: 30 days ago"
 // @Param        to       query string false "End Date (RFC3339) - Default: Now"
 // @Success      200  {array}   domain.HabitEntry
+// @Success      304  "Not Modified"
 // @Failure      400  {object}  map[string]string "Missing habit_id"
 // @Router       /entries [get]
 func (h *EntryHandler) ListByHabit(c *gin.Context) {
@@ -207,18 +230,22 @@ func (h *EntryHandler) ListByHabit(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, list)
+	renderCacheablePayload(c, list)
 }
 
 // Sync godoc
 // @Summary      Sync entries (Offline-First)
 // @Description  Get entries created or modified since the last sync cursor.
+// @Description  The cursor only covers entries, so it is not recorded as the device's acknowledged cursor: devices that never use POST /sync or the change stream hold back the tombstone GC until they count as inactive.
 // @Tags         Entries
-// @Produce      json
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Security     BearerAuth
-// @Param        since query string false "Last Sync Cursor (RFC3339)"
-// @Success      200  {object}  map[string]interface{} "Returns {changes: [], timestamp: NextCursor}"
-// @Failure      400  {object}  map[string]string "Invalid Date Format"
+// @Param        since query string false "Last Sync Cursor (from a previous response)"
+// @Param        page_token query string false "Continuation token (next_page_token of the previous page)"
+// @Param        limit query int false "Page size (default 500, max 1000)"
+// @Success      200  {object}  map[string]interface{} "Returns {changes: [], cursor: NextCursor, has_more: bool, next_page_token: string, timestamp: NextCursor (deprecated, use cursor)}"
+// @Failure      400  {object}  map[string]string "Invalid Cursor, Page Token or Limit"
+// @Failure      410  {object}  map[string]string "Cursor Expired (Full Resync Required)"
 // @Router       /entries/sync [get]
 func (h *EntryHandler) Sync(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -227,19 +254,13 @@ func (h *EntryHandler) Sync(c *gin.Context) {
 		return
 	}
 
-	sinceStr := c.Query("since")
-	var since time.Time
-
-	if sinceStr != "" {
-		var err error
-		since, err = time.Parse(time.RFC3339, sinceStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format"})
-			return
-		}
+	since, limit, err := parseSyncPage(c, "since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	changes, err := h.svc.GetDelta(c.Request.Context(), userID, since)
+	changes, hasMore, err := h.svc.GetDelta(c.Request.Context(), userID, since, limit)
 	if err != nil {
 		handleError(c, err)
 		return
@@ -247,10 +268,7 @@ func (h *EntryHandler) Sync(c *gin.Context) {
 
 	nextCursor := calculateNextCursor(changes, since)
 
-	c.JSON(http.StatusOK, gin.H{
-		"changes":   changes,
-		"timestamp": nextCursor,
-	})
+	renderPayload(c, http.StatusOK, deltaSyncResponse(changes, nextCursor, hasMore))
 }
 
 func handleError(c *gin.Context, err error) {
@@ -258,14 +276,27 @@ func handleError(c *gin.Context, err error) {
 	case errors.Is(err, domain.ErrUnauthorized):
 		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized access"})
 
+	case errors.Is(err, domain.ErrInvalidEntry) || isClockError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
 	case errors.Is(err, domain.ErrEntryNotFound) || errors.Is(err, domain.ErrHabitNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
 
+	case errors.Is(err, domain.ErrSyncCursorExpired):
+		respondCursorExpired(c)
+
 	case errors.Is(err, domain.ErrEntryConflict):
-		c.JSON(http.StatusConflict, gin.H{
+		resp := gin.H{
 			"error":   "version conflict",
 			"message": "data has been modified elsewhere, please sync",
-		})
+		}
+		var conflict *domain.EntryConflictError
+		if errors.As(err, &conflict) && conflict.Server != nil {
+			resp["server"] = conflict.Server
+			resp["server_version"] = conflict.Server.Version
+			resp["diff"] = conflict.Diff
+		}
+		c.JSON(conflictStatus(c), resp)
 
 	default:
 		log.Printf("[ERROR] Request %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
@@ -273,12 +304,17 @@ func handleError(c *gin.Context, err error) {
 	}
 }
 
-func calculateNextCursor(changes []*domain.HabitEntry, fallback time.Time) time.Time {
+// isClockError reports a client HLC that is malformed or too far ahead.
+func isClockError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidHLC) || errors.Is(err, domain.ErrHLCTooFarAhead)
+}
+
+func calculateNextCursor(changes []*domain.HabitEntry, fallback int64) int64 {
 	if len(changes) == 0 {
 		return fallback
 	}
 
-	lastChange := changes[len(changes)-1].UpdatedAt
+	lastChange := changes[len(changes)-1].ChangeSeq
 
 	return lastChange
 }
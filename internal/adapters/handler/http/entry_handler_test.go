@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
+	"sort"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
@@ -22,11 +24,13 @@ import (
 )
 
 func getTestWorker() *workers.StreakWorker {
-	return workers.NewStreakWorker(nil, nil)
+	return workers.NewStreakWorker(nil, nil, workers.StreakOptions{})
 }
 
 type MockEntryRepo struct {
-	store map[string]*domain.HabitEntry
+	store       map[string]*domain.HabitEntry
+	seq         int64
+	minValidSeq int64
 }
 
 func NewMockEntryRepo() *MockEntryRepo {
@@ -34,9 +38,14 @@ func NewMockEntryRepo() *MockEntryRepo {
 }
 
 func (m *MockEntryRepo) Create(ctx context.Context, e *domain.HabitEntry) error {
+	if _, ok := m.store[e.ID]; ok {
+		return domain.ErrEntryConflict
+	}
 	if e.Version == 0 {
 		e.Version = 1
 	}
+	m.seq++
+	e.ChangeSeq = m.seq
 	m.store[e.ID] = e
 	return nil
 }
@@ -50,6 +59,8 @@ func (m *MockEntryRepo) Update(ctx context.Context, e *domain.HabitEntry) error
 		return domain.ErrEntryConflict
 	}
 	e.Version++
+	m.seq++
+	e.ChangeSeq = m.seq
 	m.store[e.ID] = e
 	return nil
 }
@@ -74,6 +85,10 @@ func (m *MockEntryRepo) Delete(ctx context.Context, id string, userID string) er
 	return nil
 }
 
+func (m *MockEntryRepo) Resurrect(ctx context.Context, e *domain.HabitEntry) error {
+	return domain.ErrEntryNotFound
+}
+
 func (m *MockEntryRepo) ListByHabitID(ctx context.Context, habitID string) ([]*domain.HabitEntry, error) {
 	var list []*domain.HabitEntry
 	for _, e := range m.store {
@@ -97,13 +112,20 @@ func (m *MockEntryRepo) ListByHabitIDWithRange(ctx context.Context, habitID stri
 	return list, nil
 }
 
-func (m *MockEntryRepo) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.HabitEntry, error) {
+func (m *MockEntryRepo) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.HabitEntry, error) {
+	if since > 0 && since < m.minValidSeq {
+		return nil, domain.ErrSyncCursorExpired
+	}
 	var changes []*domain.HabitEntry
 	for _, e := range m.store {
-		if e.UserID == userID && e.UpdatedAt.After(since) {
+		if e.UserID == userID && e.ChangeSeq > since {
 			changes = append(changes, e)
 		}
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeSeq < changes[j].ChangeSeq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
 	return changes, nil
 }
 
@@ -140,12 +162,15 @@ func (m *MockHabitRepoForEntry) Create(ctx context.Context, h *domain.Habit) err
 	m.habits[h.ID] = h
 	return nil
 }
+func (m *MockHabitRepoForEntry) ListByUserIDForUpdate(ctx context.Context, u string) ([]*domain.Habit, error) {
+	return nil, nil
+}
 func (m *MockHabitRepoForEntry) ListByUserID(ctx context.Context, u string) ([]*domain.Habit, error) {
 	return nil, nil
 }
 func (m *MockHabitRepoForEntry) Update(ctx context.Context, h *domain.Habit) error { return nil }
 func (m *MockHabitRepoForEntry) Delete(ctx context.Context, id string) error       { return nil }
-func (m *MockHabitRepoForEntry) GetChanges(ctx context.Context, u string, t time.Time) ([]*domain.Habit, error) {
+func (m *MockHabitRepoForEntry) GetChanges(ctx context.Context, u string, since int64, limit int) ([]*domain.Habit, error) {
 	return nil, nil
 }
 
@@ -153,13 +178,17 @@ func (m *MockHabitRepoForEntry) UpdateStreaks(ctx context.Context, id string, cu
 	return nil
 }
 
+func (m *MockHabitRepoForEntry) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	return nil, domain.ErrHabitVersionNotFound
+}
+
 func setupEntryRouter() (*gin.Engine, *MockEntryRepo, *MockHabitRepoForEntry) {
 	gin.SetMode(gin.TestMode)
 	entryRepo := NewMockEntryRepo()
 	habitRepo := NewMockHabitRepo()
 	worker := getTestWorker()
 
-	svc := services.NewEntryService(entryRepo, habitRepo, worker)
+	svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 	handler := adapterHTTP.NewEntryHandler(svc)
 
 	r := gin.New()
@@ -222,6 +251,52 @@ func TestCreateEntry(t *testing.T) {
 		router.ServeHTTP(w, req)
 		assert.Contains(t, []int{http.StatusForbidden, http.StatusUnauthorized}, w.Code)
 	})
+
+	t.Run("Success: Retrying with a client ID returns the same entry", func(t *testing.T) {
+		router, entryRepo, habitRepo := setupEntryRouter()
+
+		h, _ := domain.NewHabit("habit-1", "Gym", "user-1")
+		habitRepo.Create(context.Background(), h)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"id":              "0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90",
+			"habit_id":        h.ID,
+			"completion_date": time.Now().Format(time.RFC3339),
+			"value":           1,
+		})
+
+		var bodies []string
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("POST", "/api/v1/entries", bytes.NewBuffer(jsonBody))
+			req.Header.Set("X-User-ID", "user-1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusCreated, w.Code)
+			bodies = append(bodies, w.Body.String())
+		}
+
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.Contains(t, bodies[0], `"id":"0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90"`)
+		assert.Len(t, entryRepo.store, 1)
+	})
+
+	t.Run("Fail: 400 when the client ID is not a UUID", func(t *testing.T) {
+		router, _, _ := setupEntryRouter()
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"id":              "my-entry",
+			"habit_id":        "habit-1",
+			"completion_date": time.Now().Format(time.RFC3339),
+		})
+
+		req, _ := http.NewRequest("POST", "/api/v1/entries", bytes.NewBuffer(jsonBody))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestUpdateEntry(t *testing.T) {
@@ -268,6 +343,33 @@ func TestUpdateEntry(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var resp struct {
+			Server        domain.HabitEntry  `json:"server"`
+			ServerVersion int                `json:"server_version"`
+			Diff          []domain.FieldDiff `json:"diff"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, e.ID, resp.Server.ID)
+		assert.Equal(t, 2, resp.ServerVersion)
+		assert.Equal(t, []domain.FieldDiff{{Field: "value", Client: float64(10), Server: float64(5)}}, resp.Diff)
+	})
+	t.Run("Fail: 400 on a malformed HLC", func(t *testing.T) {
+		router, entryRepo, _ := setupEntryRouter()
+
+		e := domain.NewHabitEntry("habit-read", "user-1", time.Now(), 5)
+		e.ID = "entry-1"
+		e.Version = 1
+		entryRepo.Create(context.Background(), e)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{"value": 10, "version": 1, "hlc": "not-a-clock"})
+
+		req, _ := http.NewRequest("PUT", "/api/v1/entries/"+e.ID, bytes.NewBuffer(jsonBody))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
@@ -357,16 +459,13 @@ func TestSyncEntries(t *testing.T) {
 
 	eOld := domain.NewHabitEntry("h1", "user-1", time.Now(), 1)
 	eOld.ID = "old-1"
-	eOld.UpdatedAt = time.Now().Add(-24 * time.Hour)
 	entryRepo.Create(context.Background(), eOld)
 
 	eNew := domain.NewHabitEntry("h1", "user-1", time.Now(), 1)
 	eNew.ID = "new-1"
-	eNew.UpdatedAt = time.Now()
 	entryRepo.Create(context.Background(), eNew)
 
-	since := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
-	safeSince := url.QueryEscape(since)
+	safeSince := strconv.FormatInt(eOld.ChangeSeq, 10)
 
 	t.Run("Success: Returns only new entries", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/v1/entries/sync?since="+safeSince, nil)
@@ -378,5 +477,31 @@ func TestSyncEntries(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), eNew.ID)
 		assert.NotContains(t, w.Body.String(), eOld.ID)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, strconv.FormatInt(eNew.ChangeSeq, 10), response["cursor"])
+		assert.Equal(t, response["cursor"], response["timestamp"], "timestamp is kept for older clients")
+	})
+
+	t.Run("Fail: 410 Gone (Cursor older than purged tombstones)", func(t *testing.T) {
+		entryRepo.minValidSeq = eNew.ChangeSeq
+		defer func() { entryRepo.minValidSeq = 0 }()
+
+		req, _ := http.NewRequest("GET", "/api/v1/entries/sync?since="+safeSince, nil)
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/entries/sync", nil)
+		req.Header.Set("X-User-ID", "user-1")
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "A full resync is always allowed")
 	})
 }
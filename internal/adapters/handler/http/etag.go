@@ -0,0 +1,115 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidIfMatch  = errors.New("invalid If-Match header, must be the ETag of the version being updated")
+	errVersionRequired = errors.New("version is required, in the body or as If-Match")
+)
+
+// versionETag is the strong ETag of a single habit or entry. Every write
+// bumps the version, so it changes whenever the representation does.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// bodyETag is the strong ETag of a response that is not a single resource,
+// such as a list: a digest of the exact bytes sent.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag of the response and answers 304 when the
+// client's If-None-Match already covers it.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	if !etagListMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagListMatches compares an If-None-Match list against etag, using the
+// weak comparison the header calls for.
+func etagListMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion reads the version an update is based on from If-Match, as
+// an alternative to the version field of the body. It reports whether the
+// header was sent.
+func ifMatchVersion(c *gin.Context) (int, bool, error) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" {
+		return 0, false, nil
+	}
+
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return 0, true, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(raw[1 : len(raw)-1])
+	if err != nil || version <= 0 {
+		return 0, true, errInvalidIfMatch
+	}
+	return version, true, nil
+}
+
+// updateVersion resolves the base version of an update: If-Match when sent,
+// the body version otherwise.
+func updateVersion(c *gin.Context, bodyVersion int) (int, error) {
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return version, nil
+	}
+	if bodyVersion <= 0 {
+		return 0, errVersionRequired
+	}
+	return bodyVersion, nil
+}
+
+// optionalVersion resolves the base version of a write for which it is
+// optional: If-Match when sent, the body version otherwise, and 0, which
+// skips the check, when neither is.
+func optionalVersion(c *gin.Context, bodyVersion int) (int, error) {
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return version, nil
+	}
+	return bodyVersion, nil
+}
+
+// conflictStatus is 412 for updates whose base version came from If-Match,
+// as HTTP clients expect for a failed precondition, and 409 otherwise.
+func conflictStatus(c *gin.Context) int {
+	if c.GetHeader("If-Match") != "" {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusConflict
+}
@@ -0,0 +1,120 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestHabitETags(t *testing.T) {
+	send := func(router http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success: 304 Not Modified until the habit changes", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Read", "user-1")
+		repo.Create(context.Background(), h)
+
+		path := "/api/v1/habits/" + h.ID
+		w := send(router, "GET", path, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		assert.Equal(t, `"1"`, etag)
+
+		w = send(router, "GET", path, "", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		w = send(router, "PUT", path, `{"title": "Read more"}`, map[string]string{"If-Match": etag})
+		require.Equal(t, http.StatusOK, w.Code, "If-Match replaces the body version")
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+		w = send(router, "GET", path, "", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Success: The list ETag changes with its content", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Read", "user-1")
+		repo.Create(context.Background(), h)
+
+		w := send(router, "GET", "/api/v1/habits", "", nil)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		w = send(router, "GET", "/api/v1/habits", "", map[string]string{"If-None-Match": `"other", ` + etag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		other, _ := domain.NewHabit("", "Run", "user-1")
+		repo.Create(context.Background(), other)
+
+		w = send(router, "GET", "/api/v1/habits", "", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("Fail: 412 Precondition Failed for a stale If-Match", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "V2", "user-1")
+		h.Version = 2
+		repo.Create(context.Background(), h)
+
+		w := send(router, "PUT", "/api/v1/habits/"+h.ID, `{"title": "Overwrite"}`, map[string]string{"If-Match": `"1"`})
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"server_version":2`)
+	})
+
+	t.Run("Fail: 400 without a version or with a malformed If-Match", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Read", "user-1")
+		repo.Create(context.Background(), h)
+
+		w := send(router, "PUT", "/api/v1/habits/"+h.ID, `{"title": "Read more"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = send(router, "PUT", "/api/v1/habits/"+h.ID, `{"title": "Read more"}`, map[string]string{"If-Match": `W/"1"`})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEntryETags(t *testing.T) {
+	router, entryRepo, _ := setupEntryRouter()
+	entryRepo.store["entry-1"] = &domain.HabitEntry{ID: "entry-1", HabitID: "habit-1", UserID: "user-1", Value: 1, Version: 1, CompletionDate: time.Now()}
+
+	update := func(ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/api/v1/entries/entry-1", bytes.NewBufferString(`{"value": 2}`))
+		req.Header.Set("X-User-ID", "user-1")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := update(`"1"`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var updated domain.HabitEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, fmt.Sprintf(`"%d"`, updated.Version), w.Header().Get("ETag"))
+
+	w = update(`"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
@@ -1,9 +1,10 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
-	"time"
 
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
@@ -22,34 +23,54 @@ func NewHabitHandler(svc *services.HabitService) *HabitHandler {
 }
 
 type createHabitRequest struct {
-	ID            string `json:"id"`
-	Title         string `json:"title" binding:"required"`
-	Description   string `json:"description"`
-	Color         string `json:"color"`
-	Icon          string `json:"icon"`
-	Type          string `json:"type"`
-	ReminderTime  string `json:"reminder_time"`
-	Unit          string `json:"unit"`
-	TargetValue   int    `json:"target_value"`
-	Interval      int    `json:"interval"`
-	Weekdays      []int  `json:"weekdays"`
-	FrequencyType string `json:"frequency_type"`
+	ID             string   `json:"id"`
+	Title          string   `json:"title" binding:"required"`
+	Description    string   `json:"description"`
+	Color          string   `json:"color"`
+	Icon           string   `json:"icon"`
+	Type           string   `json:"type"`
+	ReminderTime   string   `json:"reminder_time"`
+	Unit           string   `json:"unit"`
+	TargetValue    int      `json:"target_value"`
+	Interval       int      `json:"interval"`
+	Weekdays       []int    `json:"weekdays"`
+	FrequencyType  string   `json:"frequency_type"`
+	TimesPerPeriod int      `json:"times_per_period"`
+	RRule          string   `json:"rrule"`
+	ExDates        []string `json:"exdates"`
+	StartDate      string   `json:"start_date"`
+	EndDate        string   `json:"end_date"`
+	HLC            string   `json:"hlc"`
 }
 
 type updateHabitRequest struct {
-	Title         *string `json:"title"`
-	Description   *string `json:"description"`
-	Color         *string `json:"color"`
-	Icon          *string `json:"icon"`
-	Type          *string `json:"type"`
-	ReminderTime  *string `json:"reminder_time"`
-	Unit          *string `json:"unit"`
-	TargetValue   *int    `json:"target_value"`
-	Interval      *int    `json:"interval"`
-	Weekdays      []int   `json:"weekdays"`
-	FrequencyType *string `json:"frequency_type"`
-	ArchivedAt    *string `json:"archived_at"`
-	Version       int     `json:"version" binding:"required"`
+	Title          *string  `json:"title"`
+	Description    *string  `json:"description"`
+	Color          *string  `json:"color"`
+	Icon           *string  `json:"icon"`
+	Type           *string  `json:"type"`
+	ReminderTime   *string  `json:"reminder_time"`
+	Unit           *string  `json:"unit"`
+	TargetValue    *int     `json:"target_value"`
+	Interval       *int     `json:"interval"`
+	Weekdays       []int    `json:"weekdays"`
+	FrequencyType  *string  `json:"frequency_type"`
+	TimesPerPeriod *int     `json:"times_per_period"`
+	RRule          *string  `json:"rrule"`
+	ExDates        []string `json:"exdates"`
+	StartDate      *string  `json:"start_date"`
+	EndDate        *string  `json:"end_date"`
+	ArchivedAt     *string  `json:"archived_at"`
+	Version        int      `json:"version"`
+	HLC            string   `json:"hlc"`
+}
+
+type archiveHabitRequest struct {
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+type reorderHabitsRequest struct {
+	IDs []string `json:"ids" binding:"required"`
 }
 
 func (h *HabitHandler) RegisterRoutes(router *gin.RouterGroup) {
@@ -58,20 +79,25 @@ func (h *HabitHandler) RegisterRoutes(router *gin.RouterGroup) {
 		habits.POST("", h.Create)
 		habits.GET("", h.List)
 		habits.GET("/sync", h.Sync)
+		habits.PUT("/order", h.Reorder)
 		habits.GET("/:id", h.Get)
 		habits.PUT("/:id", h.Update)
 		habits.DELETE("/:id", h.Delete)
+		habits.POST("/:id/archive", h.Archive)
+		habits.POST("/:id/unarchive", h.Unarchive)
 	}
 }
 
 // Get godoc
 // @Summary      Get a single habit
-// @Description  Get habit details by ID
+// @Description  Get habit details by ID. The ETag is the habit version; a matching If-None-Match returns 304.
 // @Tags         Habits
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   path string true "Habit ID"
+// @Param        If-None-Match header string false "ETag of the cached copy"
 // @Success      200  {object}  domain.Habit
+// @Success      304  "Not Modified"
 // @Failure      404  {object}  map[string]string "Habit Not Found"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /habits/{id} [get]
@@ -93,16 +119,21 @@ func (h *HabitHandler) Get(c *gin.Context) {
 		return
 	}
 
+	if notModified(c, versionETag(habit.Version)) {
+		return
+	}
 	c.JSON(http.StatusOK, habit)
 }
 
 // Create godoc
 // @Summary      Create a new habit
-// @Description  Create a habit with title, type, color, frequency, and tracking details
+// @Description  Create a habit with title, type, color, frequency, and tracking details.
+// @Description  start_date and end_date are YYYY-MM-DD dates in the user's timezone (or RFC 3339 timestamps); the habit starts today by default, and until start_date is set completions from before it are still accepted.
 // @Tags         Habits
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Param        X-Timezone header string false "User Timezone (e.g. Europe/Rome). Defaults to UTC."
 // @Param        habit body createHabitRequest true "Habit Data"
 // @Success      201  {object}  domain.Habit
 // @Failure      400  {object}  map[string]string "Validation Error (Title empty, Invalid Color)"
@@ -121,25 +152,38 @@ func (h *HabitHandler) Create(c *gin.Context) {
 		return
 	}
 
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	input := services.CreateHabitInput{
-		ID:            req.ID,
-		UserID:        userID,
-		Title:         req.Title,
-		Description:   req.Description,
-		Color:         req.Color,
-		Icon:          req.Icon,
-		Type:          req.Type,
-		ReminderTime:  req.ReminderTime,
-		Unit:          req.Unit,
-		TargetValue:   req.TargetValue,
-		Interval:      req.Interval,
-		Weekdays:      req.Weekdays,
-		FrequencyType: req.FrequencyType,
+		ID:             req.ID,
+		UserID:         userID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Color:          req.Color,
+		Icon:           req.Icon,
+		Type:           req.Type,
+		ReminderTime:   req.ReminderTime,
+		Unit:           req.Unit,
+		TargetValue:    req.TargetValue,
+		Interval:       req.Interval,
+		Weekdays:       req.Weekdays,
+		FrequencyType:  req.FrequencyType,
+		TimesPerPeriod: req.TimesPerPeriod,
+		RRule:          req.RRule,
+		ExDates:        req.ExDates,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+		Location:       location,
+		HLC:            req.HLC,
 	}
 
 	habit, err := h.svc.Create(c.Request.Context(), input)
 	if err != nil {
-		if errors.Is(err, domain.ErrHabitTitleEmpty) || errors.Is(err, domain.ErrInvalidColor) {
+		if errors.Is(err, domain.ErrHabitTitleEmpty) || errors.Is(err, domain.ErrInvalidColor) || isScheduleError(err) || isClockError(err) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -147,16 +191,19 @@ func (h *HabitHandler) Create(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(habit.Version))
 	c.JSON(http.StatusCreated, habit)
 }
 
 // List godoc
 // @Summary      List all habits
-// @Description  Get all active habits for the authenticated user
+// @Description  Get all active habits for the authenticated user. The response carries an ETag; a matching If-None-Match returns 304.
 // @Tags         Habits
-// @Produce      json
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Security     BearerAuth
+// @Param        If-None-Match header string false "ETag of the cached list"
 // @Success      200  {array}   domain.Habit
+// @Success      304  "Not Modified"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /habits [get]
 func (h *HabitHandler) List(c *gin.Context) {
@@ -172,18 +219,22 @@ func (h *HabitHandler) List(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, list)
+	renderCacheablePayload(c, list)
 }
 
 // Sync godoc
 // @Summary      Sync habits (Offline-First)
-// @Description  Get habits created, updated, or deleted since the provided timestamp cursor.
+// @Description  Get habits created, updated, or deleted since the provided change cursor.
+// @Description  The cursor only covers habits, so it is not recorded as the device's acknowledged cursor: devices that never use POST /sync or the change stream hold back the tombstone GC until they count as inactive.
 // @Tags         Habits
-// @Produce      json
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Security     BearerAuth
-// @Param        last_sync query string false "Timestamp Cursor (RFC3339 format)"
-// @Success      200  {object}  map[string]interface{} "Returns {changes: delta, timestamp: NextCursor}"
-// @Failure      400  {object}  map[string]string "Invalid Timestamp Format"
+// @Param        last_sync query string false "Opaque Sync Cursor (from a previous response)"
+// @Param        page_token query string false "Continuation token (next_page_token of the previous page)"
+// @Param        limit query int false "Page size (default 500, max 1000)"
+// @Success      200  {object}  map[string]interface{} "Returns {changes: delta, cursor: NextCursor, has_more: bool, next_page_token: string, timestamp: NextCursor (deprecated, use cursor)}"
+// @Failure      400  {object}  map[string]string "Invalid Cursor, Page Token or Limit"
+// @Failure      410  {object}  map[string]string "Cursor Expired (Full Resync Required)"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /habits/sync [get]
 func (h *HabitHandler) Sync(c *gin.Context) {
@@ -193,45 +244,46 @@ func (h *HabitHandler) Sync(c *gin.Context) {
 		return
 	}
 
-	lastSyncStr := c.Query("last_sync")
-	var lastSync time.Time
-	var err error
-
-	if lastSyncStr != "" {
-		lastSync, err = time.Parse(time.RFC3339, lastSyncStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last_sync format, use RFC3339"})
-			return
-		}
+	lastSync, limit, err := parseSyncPage(c, "last_sync")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	deltas, err := h.svc.GetDelta(c.Request.Context(), userID, lastSync)
+	deltas, hasMore, err := h.svc.GetDelta(c.Request.Context(), userID, lastSync, limit)
 	if err != nil {
+		if errors.Is(err, domain.ErrSyncCursorExpired) {
+			respondCursorExpired(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
 		return
 	}
 
 	nextCursor := calculateNextHabitCursor(deltas, lastSync)
 
-	c.JSON(http.StatusOK, gin.H{
-		"changes":   deltas,
-		"timestamp": nextCursor,
-	})
+	renderPayload(c, http.StatusOK, deltaSyncResponse(deltas, nextCursor, hasMore))
 }
 
 // Update godoc
 // @Summary      Update a habit
-// @Description  Modify an existing habit. Requires 'version' for optimistic locking.
+// @Description  Modify an existing habit. 'version' is the base version the client edited from:
+// @Description  changes made elsewhere since then are merged field by field, and only fields changed on both sides return 409.
+// @Description  A 409 carries the current server copy, its version and a diff of the submitted fields against it.
+// @Description  The base version may be sent as If-Match (the ETag of the copy being edited) instead; conflicts are then reported as 412.
 // @Tags         Habits
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id    path string true "Habit ID"
+// @Param        If-Match header string false "ETag of the base version, instead of the body version"
+// @Param        X-Timezone header string false "User Timezone for start_date and end_date (e.g. Europe/Rome). Defaults to UTC."
 // @Param        habit body updateHabitRequest true "Update Data"
 // @Success      200  {object}  domain.Habit
 // @Failure      400  {object}  map[string]string "Invalid Input"
 // @Failure      404  {object}  map[string]string "Habit Not Found"
-// @Failure      409  {object}  map[string]string "Version Conflict (Data modified elsewhere)"
+// @Failure      409  {object}  map[string]string "Version Conflict (Data modified elsewhere, or an older HLC under last-writer-wins)"
+// @Failure      412  {object}  map[string]string "Version Conflict with If-Match"
 // @Failure      500  {object}  map[string]string "Internal Server Error"
 // @Router       /habits/{id} [put]
 func (h *HabitHandler) Update(c *gin.Context) {
@@ -249,32 +301,48 @@ func (h *HabitHandler) Update(c *gin.Context) {
 		return
 	}
 
+	version, err := updateVersion(c, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	input := services.UpdateHabitInput{
-		ID:            id,
-		UserID:        userID,
-		Title:         req.Title,
-		Description:   req.Description,
-		Color:         req.Color,
-		Icon:          req.Icon,
-		Type:          req.Type,
-		ReminderTime:  req.ReminderTime,
-		Unit:          req.Unit,
-		TargetValue:   req.TargetValue,
-		Interval:      req.Interval,
-		Weekdays:      req.Weekdays,
-		FrequencyType: req.FrequencyType,
-		ArchivedAt:    req.ArchivedAt,
-		Version:       req.Version,
+		ID:             id,
+		UserID:         userID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Color:          req.Color,
+		Icon:           req.Icon,
+		Type:           req.Type,
+		ReminderTime:   req.ReminderTime,
+		Unit:           req.Unit,
+		TargetValue:    req.TargetValue,
+		Interval:       req.Interval,
+		Weekdays:       req.Weekdays,
+		FrequencyType:  req.FrequencyType,
+		TimesPerPeriod: req.TimesPerPeriod,
+		RRule:          req.RRule,
+		ExDates:        req.ExDates,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+		Location:       location,
+		ArchivedAt:     req.ArchivedAt,
+		Version:        version,
+		HLC:            req.HLC,
 	}
 
 	habit, err := h.svc.Update(c.Request.Context(), input)
 
 	if err != nil {
 		if errors.Is(err, domain.ErrHabitConflict) {
-			c.JSON(http.StatusConflict, gin.H{
-				"error":   "version conflict",
-				"message": "Data has been modified elsewhere. Please sync.",
-			})
+			c.JSON(conflictStatus(c), habitConflictResponse(err))
 			return
 		}
 
@@ -282,7 +350,7 @@ func (h *HabitHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "habit not found"})
 			return
 		}
-		if errors.Is(err, domain.ErrInvalidColor) || errors.Is(err, domain.ErrHabitTitleEmpty) {
+		if errors.Is(err, domain.ErrInvalidColor) || errors.Is(err, domain.ErrHabitTitleEmpty) || isScheduleError(err) || isClockError(err) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -293,6 +361,7 @@ func (h *HabitHandler) Update(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(habit.Version))
 	c.JSON(http.StatusOK, habit)
 }
 
@@ -327,9 +396,161 @@ func (h *HabitHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-func calculateNextHabitCursor(changes []*domain.Habit, fallback time.Time) time.Time {
+// Archive godoc
+// @Summary      Archive a habit
+// @Description  Hide a habit from the schedule with a version bump, keeping its history. Archiving an archived habit changes nothing.
+// @Description  The version the client last saw may be sent as If-Match or in the body: if the habit changed since, 409 (412 with If-Match) carries the server copy, as for an update.
+// @Tags         Habits
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string true "Habit ID"
+// @Param        If-Match header string false "ETag of the version last seen, instead of the body version"
+// @Param        habit body archiveHabitRequest false "Version last seen"
+// @Success      200  {object}  domain.Habit
+// @Failure      400  {object}  map[string]string "Invalid Version or If-Match"
+// @Failure      404  {object}  map[string]string "Habit Not Found"
+// @Failure      409  {object}  map[string]string "Version Conflict"
+// @Failure      412  {object}  map[string]string "Version Conflict with If-Match"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/{id}/archive [post]
+func (h *HabitHandler) Archive(c *gin.Context) {
+	h.setArchived(c, h.svc.Archive)
+}
+
+// Unarchive godoc
+// @Summary      Unarchive a habit
+// @Description  Bring an archived habit back with a version bump. Unarchiving an active habit changes nothing.
+// @Description  The version the client last saw may be sent as If-Match or in the body: if the habit changed since, 409 (412 with If-Match) carries the server copy, as for an update.
+// @Tags         Habits
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string true "Habit ID"
+// @Param        If-Match header string false "ETag of the version last seen, instead of the body version"
+// @Param        habit body archiveHabitRequest false "Version last seen"
+// @Success      200  {object}  domain.Habit
+// @Failure      400  {object}  map[string]string "Invalid Version or If-Match"
+// @Failure      404  {object}  map[string]string "Habit Not Found"
+// @Failure      409  {object}  map[string]string "Version Conflict"
+// @Failure      412  {object}  map[string]string "Version Conflict with If-Match"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/{id}/unarchive [post]
+func (h *HabitHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, h.svc.Unarchive)
+}
+
+func (h *HabitHandler) setArchived(c *gin.Context, apply func(ctx context.Context, id, userID string, version int) (*domain.Habit, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	// The body is optional: a bare POST archives whatever the version.
+	var req archiveHabitRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	version, err := optionalVersion(c, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	habit, err := apply(c.Request.Context(), c.Param("id"), userID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrHabitNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "habit not found"})
+		case errors.Is(err, domain.ErrHabitConflict):
+			c.JSON(conflictStatus(c), habitConflictResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(habit.Version))
+	c.JSON(http.StatusOK, habit)
+}
+
+// Reorder godoc
+// @Summary      Reorder habits
+// @Description  Set the order of the user's habits, e.g. after a drag and drop. ids must list every active (not archived) habit exactly once.
+// @Description  The habits that moved get a version bump, all in one transaction. All of the user's habits are returned: the active ones in their new order, then the archived ones.
+// @Description  If a habit changed while it was being moved, 409 carries its server copy, as for an update.
+// @Tags         Habits
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        order body reorderHabitsRequest true "Habit IDs in their new order"
+// @Success      200  {array}   domain.Habit
+// @Failure      400  {object}  map[string]string "Missing, Unknown, Duplicate or Archived Habits"
+// @Failure      409  {object}  map[string]string "Version Conflict"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/order [put]
+func (h *HabitHandler) Reorder(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req reorderHabitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	habits, err := h.svc.Reorder(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidHabitOrder), errors.Is(err, domain.ErrHabitArchived):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrHabitConflict):
+			c.JSON(http.StatusConflict, habitConflictResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, habits)
+}
+
+// habitConflictResponse is the body of a 409 or 412 on a habit write. It
+// carries the server copy and the diff when err is a HabitConflictError.
+func habitConflictResponse(err error) gin.H {
+	resp := gin.H{
+		"error":   "version conflict",
+		"message": "Data has been modified elsewhere. Please sync.",
+	}
+	var conflict *domain.HabitConflictError
+	if errors.As(err, &conflict) {
+		resp["fields"] = conflict.Fields
+		if conflict.Server != nil {
+			resp["server"] = conflict.Server
+			resp["server_version"] = conflict.Server.Version
+			resp["diff"] = conflict.Diff
+		}
+	}
+	return resp
+}
+
+func calculateNextHabitCursor(changes []*domain.Habit, fallback int64) int64 {
 	if len(changes) == 0 {
 		return fallback
 	}
-	return changes[len(changes)-1].UpdatedAt
+	return changes[len(changes)-1].ChangeSeq
+}
+
+func isScheduleError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidFrequency) || errors.Is(err, domain.ErrInvalidTimesPerPeriod) ||
+		errors.Is(err, domain.ErrInvalidRRule) || errors.Is(err, domain.ErrInvalidExDate) ||
+		errors.Is(err, domain.ErrInvalidHabitDate) || errors.Is(err, domain.ErrInvalidDateRange)
 }
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
@@ -19,19 +24,27 @@ import (
 )
 
 type MockRepo struct {
-	store map[string]*domain.Habit
+	store   map[string]*domain.Habit
+	history map[string]map[int]domain.Habit
+	seq     int64
 }
 
 func NewMockRepo() *MockRepo {
-	return &MockRepo{store: make(map[string]*domain.Habit)}
+	return &MockRepo{
+		store:   make(map[string]*domain.Habit),
+		history: make(map[string]map[int]domain.Habit),
+	}
 }
 
 func (m *MockRepo) Create(ctx context.Context, h *domain.Habit) error {
 	if h.Version == 0 {
 		h.Version = 1
 	}
+	m.seq++
+	h.ChangeSeq = m.seq
 	clone := *h
 	m.store[h.ID] = &clone
+	m.record(h)
 	return nil
 }
 
@@ -47,6 +60,10 @@ func (m *MockRepo) GetByID(ctx context.Context, id string) (*domain.Habit, error
 	return &clone, nil
 }
 
+func (m *MockRepo) ListByUserIDForUpdate(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	return m.ListByUserID(ctx, userID)
+}
+
 func (m *MockRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Habit, error) {
 	var list []*domain.Habit
 	for _, h := range m.store {
@@ -64,8 +81,11 @@ func (m *MockRepo) Update(ctx context.Context, h *domain.Habit) error {
 		return domain.ErrHabitNotFound
 	}
 
+	m.seq++
+	h.ChangeSeq = m.seq
 	clone := *h
 	m.store[h.ID] = &clone
+	m.record(h)
 	return nil
 }
 
@@ -78,17 +98,23 @@ func (m *MockRepo) Delete(ctx context.Context, id string) error {
 	h.DeletedAt = &now
 	h.Version++
 	h.UpdatedAt = now
+	m.seq++
+	h.ChangeSeq = m.seq
 	return nil
 }
 
-func (m *MockRepo) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.Habit, error) {
+func (m *MockRepo) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, error) {
 	var changes []*domain.Habit
 	for _, h := range m.store {
-		if h.UserID == userID && h.UpdatedAt.After(since) {
+		if h.UserID == userID && h.ChangeSeq > since {
 			clone := *h
 			changes = append(changes, &clone)
 		}
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeSeq < changes[j].ChangeSeq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
 	return changes, nil
 }
 
@@ -100,6 +126,8 @@ func (m *MockRepo) UpdateStreaks(ctx context.Context, id string, current, longes
 	h.CurrentStreak = current
 	h.LongestStreak = longest
 	h.UpdatedAt = time.Now().UTC()
+	m.seq++
+	h.ChangeSeq = m.seq
 	return nil
 }
 
@@ -108,7 +136,7 @@ func setupRouter() (*gin.Engine, *MockRepo) {
 
 	repo := NewMockRepo()
 
-	svc := services.NewHabitService(repo)
+	svc := services.NewHabitService(repo, nil, services.WriteOptions{})
 	handler := adapterHTTP.NewHabitHandler(svc)
 
 	r := gin.New()
@@ -179,6 +207,62 @@ func TestCreateHabit(t *testing.T) {
 		assert.Contains(t, []int{http.StatusUnauthorized, http.StatusInternalServerError}, w.Code)
 	})
 
+	t.Run("Success: 201 Created with RRule", func(t *testing.T) {
+		router, _ := setupRouter()
+
+		body := `{"title": "Review", "rrule": "FREQ=MONTHLY;BYDAY=1MO", "exdates": ["2026-12-07"]}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"frequency_type":"rrule"`)
+		assert.Contains(t, w.Body.String(), `"rrule":"FREQ=MONTHLY;BYDAY=1MO"`)
+	})
+
+	t.Run("Success: 201 Created with Start and End Dates", func(t *testing.T) {
+		router, _ := setupRouter()
+
+		body := `{"title": "Challenge", "start_date": "2026-10-01", "end_date": "2026-10-30"}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+		req.Header.Set("X-Timezone", "Europe/Rome")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"start_date":"2026-10-01T00:00:00Z"`)
+		assert.Contains(t, w.Body.String(), `"end_date":"2026-10-30T00:00:00Z"`)
+	})
+
+	t.Run("Fail: 400 Bad Request (End Before Start)", func(t *testing.T) {
+		router, _ := setupRouter()
+		body := `{"title": "Backwards", "start_date": "2026-10-30", "end_date": "2026-10-01"}`
+		req, _ := http.NewRequest("POST", "/api/v1/habits", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: 400 Bad Request (Invalid RRule)", func(t *testing.T) {
+		router, _ := setupRouter()
+		body := `{"title": "Review", "rrule": "FREQ=MONTHLY;BYSETPOS=1"}`
+		req, _ := http.NewRequest("POST", "/api/v1/habits", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "BYSETPOS")
+	})
+
 	t.Run("Fail: 400 Bad Request", func(t *testing.T) {
 		router, _ := setupRouter()
 		body := `{"title": ""}`
@@ -286,6 +370,175 @@ func TestUpdateHabit_Conflict(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"fields":["title"]`)
+
+		var resp struct {
+			Server        domain.Habit       `json:"server"`
+			ServerVersion int                `json:"server_version"`
+			Diff          []domain.FieldDiff `json:"diff"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, h.ID, resp.Server.ID)
+		assert.Equal(t, 2, resp.ServerVersion)
+		assert.Equal(t, []domain.FieldDiff{{Field: "title", Client: "Overwrite", Server: "V2"}}, resp.Diff)
+	})
+
+	t.Run("Success: 200 OK when concurrent edits touch different fields", func(t *testing.T) {
+		router, repo := setupRouter()
+
+		h, _ := domain.NewHabit("", "Read", "user-1")
+		repo.Create(context.Background(), h)
+
+		for _, body := range []string{`{"color": "#222222", "version": 1}`, `{"title": "Read more", "version": 1}`} {
+			req, _ := http.NewRequest("PUT", "/api/v1/habits/"+h.ID, bytes.NewBufferString(body))
+			req.Header.Set("X-User-ID", "user-1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, body)
+		}
+
+		stored, _ := repo.GetByID(context.Background(), h.ID)
+		assert.Equal(t, "Read more", stored.Title)
+		assert.Equal(t, "#222222", stored.Color)
+	})
+}
+
+func TestArchiveHabit(t *testing.T) {
+	t.Run("Success: Archive then unarchive", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		repo.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/archive", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		assert.NotNil(t, repo.store[h.ID].ArchivedAt)
+
+		req, _ = http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/unarchive", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, repo.store[h.ID].ArchivedAt)
+		assert.Equal(t, 3, repo.store[h.ID].Version)
+	})
+
+	t.Run("Fail: 409 Conflict on an outdated body version", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		h.Version = 3
+		repo.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/archive", bytes.NewBufferString(`{"version": 2}`))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(3), resp["server_version"])
+		assert.Nil(t, repo.store[h.ID].ArchivedAt)
+	})
+
+	t.Run("Fail: 412 Precondition Failed on an outdated If-Match", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		h.Version = 3
+		h.Archive()
+		repo.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/unarchive", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		req.Header.Set("If-Match", `"2"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.NotNil(t, repo.store[h.ID].ArchivedAt)
+	})
+
+	t.Run("Success: A current If-Match is accepted", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		repo.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/archive", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		req.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("Fail: 404 Not Found (IDOR Protection)", func(t *testing.T) {
+		router, repo := setupRouter()
+		h, _ := domain.NewHabit("", "Secret", "user-1")
+		repo.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/habits/"+h.ID+"/archive", nil)
+		req.Header.Set("X-User-ID", "user-2")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Nil(t, repo.store[h.ID].ArchivedAt)
+	})
+}
+
+func TestReorderHabits(t *testing.T) {
+	setup := func() (*gin.Engine, *MockRepo, *domain.Habit, *domain.Habit) {
+		router, repo := setupRouter()
+		first, _ := domain.NewHabit("", "First", "user-1")
+		second, _ := domain.NewHabit("", "Second", "user-1")
+		second.SortOrder = 1
+		repo.Create(context.Background(), first)
+		repo.Create(context.Background(), second)
+		return router, repo, first, second
+	}
+
+	t.Run("Success: 200 OK with the habits in their new order", func(t *testing.T) {
+		router, repo, first, second := setup()
+		archived, _ := domain.NewHabit("", "Archived", "user-1")
+		archived.Archive()
+		repo.Create(context.Background(), archived)
+
+		body := fmt.Sprintf(`{"ids": [%q, %q]}`, second.ID, first.ID)
+		req, _ := http.NewRequest("PUT", "/api/v1/habits/order", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var habits []domain.Habit
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &habits))
+		assert.Len(t, habits, 3, "Archived habits are returned after the active ones")
+		assert.Equal(t, second.ID, habits[0].ID)
+		assert.Equal(t, archived.ID, habits[2].ID)
+		assert.Equal(t, 0, repo.store[second.ID].SortOrder)
+		assert.Equal(t, 1, repo.store[first.ID].SortOrder)
+		assert.Equal(t, 2, repo.store[first.ID].Version)
+	})
+
+	t.Run("Fail: 400 Bad Request when a habit is missing", func(t *testing.T) {
+		router, repo, first, _ := setup()
+
+		body := fmt.Sprintf(`{"ids": [%q]}`, first.ID)
+		req, _ := http.NewRequest("PUT", "/api/v1/habits/order", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 1, repo.store[first.ID].Version)
 	})
 }
 
@@ -294,16 +547,11 @@ func TestSyncEndpoint(t *testing.T) {
 	ctx := context.Background()
 
 	hOld, _ := domain.NewHabit("", "Old", "user-1")
-	hOld.UpdatedAt = time.Now().UTC().Add(-24 * time.Hour)
 	repo.Create(ctx, hOld)
 
-	lastSyncTime := time.Now().UTC().Add(-1 * time.Hour)
-	lastSyncStr := lastSyncTime.Format(time.RFC3339)
-
-	time.Sleep(1 * time.Millisecond)
+	lastSyncStr := strconv.FormatInt(hOld.ChangeSeq, 10)
 
 	hNew, _ := domain.NewHabit("", "New", "user-1")
-	hNew.UpdatedAt = time.Now().UTC()
 	repo.Create(ctx, hNew)
 
 	t.Run("Sync returns only new items", func(t *testing.T) {
@@ -319,6 +567,84 @@ func TestSyncEndpoint(t *testing.T) {
 
 		assert.Contains(t, w.Body.String(), hNew.ID)
 		assert.NotContains(t, w.Body.String(), hOld.ID)
-		assert.Contains(t, response, "timestamp")
+		assert.Equal(t, strconv.FormatInt(hNew.ChangeSeq, 10), response["cursor"])
+		assert.Equal(t, response["cursor"], response["timestamp"], "timestamp is kept for older clients")
+	})
+
+	t.Run("Legacy timestamp cursor triggers a full resync", func(t *testing.T) {
+		legacy := url.QueryEscape(time.Now().UTC().Format(time.RFC3339))
+
+		req, _ := http.NewRequest("GET", "/api/v1/habits/sync?last_sync="+legacy, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), hOld.ID)
+		assert.Contains(t, w.Body.String(), hNew.ID)
+	})
+
+	t.Run("Pagination: limit and page_token walk through every change", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/habits/sync?limit=1", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var first map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &first)
+		assert.Len(t, first["changes"], 1)
+		assert.Equal(t, true, first["has_more"])
+		token, ok := first["next_page_token"].(string)
+		require.True(t, ok, "A partial page must carry a continuation token")
+
+		req, _ = http.NewRequest("GET", "/api/v1/habits/sync?limit=1&page_token="+url.QueryEscape(token), nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var second map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &second)
+		assert.Contains(t, w.Body.String(), hNew.ID)
+		assert.Equal(t, false, second["has_more"])
+		assert.NotContains(t, second, "next_page_token")
+	})
+
+	t.Run("Fail: 400 Bad Request (Invalid Limit and Page Token)", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=abc", "page_token=%25%25"} {
+			req, _ := http.NewRequest("GET", "/api/v1/habits/sync?"+query, nil)
+			req.Header.Set("X-User-ID", "user-1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Fail: 400 Bad Request (Negative Cursor)", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/habits/sync?last_sync=-1", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+func (m *MockRepo) record(h *domain.Habit) {
+	if m.history[h.ID] == nil {
+		m.history[h.ID] = make(map[int]domain.Habit)
+	}
+	m.history[h.ID][h.Version] = *h
+}
+
+func (m *MockRepo) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	h, ok := m.history[id][version]
+	if !ok {
+		return nil, domain.ErrHabitVersionNotFound
+	}
+	return &h, nil
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +14,7 @@ const (
 	authorizationHeader = "Authorization"
 	authorizationType   = "Bearer"
 	ContextUserIDKey    = "userID"
+	ContextDeviceIDKey  = "deviceID"
 )
 
 func AuthMiddleware(tokenService *services.TokenService) gin.HandlerFunc {
@@ -32,14 +35,23 @@ func AuthMiddleware(tokenService *services.TokenService) gin.HandlerFunc {
 
 		tokenString := fields[1]
 
-		userID, err := tokenService.ValidateToken(tokenString)
+		session, err := tokenService.ValidateSession(tokenString)
+		if errors.Is(err, domain.ErrDeviceRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "device revoked"})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextUserIDKey, session.UserID)
+		if session.DeviceID != "" {
+			c.Set(ContextDeviceIDKey, session.DeviceID)
+			c.Request = c.Request.WithContext(services.WithDevice(c.Request.Context(), session.DeviceID))
+		}
 
 		c.Next()
 	}
@@ -53,3 +65,13 @@ func GetUserID(c *gin.Context) (string, bool) {
 	idStr, ok := id.(string)
 	return idStr, ok
 }
+
+// GetDeviceID returns the device the request's token is bound to, if any.
+func GetDeviceID(c *gin.Context) (string, bool) {
+	id, exists := c.Get(ContextDeviceIDKey)
+	if !exists {
+		return "", false
+	}
+	idStr, ok := id.(string)
+	return idStr, ok
+}
@@ -39,6 +39,31 @@ func (m *MockUserRepo) Delete(ctx context.Context, id string) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+type MockDeviceRepo struct {
+	mock.Mock
+}
+
+func (m *MockDeviceRepo) Create(ctx context.Context, d *domain.Device) error {
+	return m.Called(ctx, d).Error(0)
+}
+func (m *MockDeviceRepo) GetByID(ctx context.Context, id string) (*domain.Device, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Device), args.Error(1)
+}
+func (m *MockDeviceRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Device, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*domain.Device), args.Error(1)
+}
+func (m *MockDeviceRepo) Update(ctx context.Context, d *domain.Device) error {
+	return m.Called(ctx, d).Error(0)
+}
+func (m *MockDeviceRepo) AcknowledgeCursor(ctx context.Context, id string, cursor int64, at time.Time) error {
+	return m.Called(ctx, id, cursor, at).Error(0)
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	t.Parallel()
@@ -63,7 +88,7 @@ func TestAuthMiddleware(t *testing.T) {
 	t.Run("Success: Valid Token", func(t *testing.T) {
 		t.Parallel()
 		mockRepo := new(MockUserRepo)
-		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo)
+		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo, nil)
 		router := setupRouter(tokenService)
 
 		userID := "user-123"
@@ -84,7 +109,7 @@ func TestAuthMiddleware(t *testing.T) {
 	t.Run("Fail: Missing Authorization Header", func(t *testing.T) {
 		t.Parallel()
 		mockRepo := new(MockUserRepo)
-		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo)
+		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo, nil)
 		router := setupRouter(tokenService)
 
 		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
@@ -99,7 +124,7 @@ func TestAuthMiddleware(t *testing.T) {
 	t.Run("Fail: Invalid Header Format", func(t *testing.T) {
 		t.Parallel()
 		mockRepo := new(MockUserRepo)
-		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo)
+		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo, nil)
 		router := setupRouter(tokenService)
 
 		formats := []string{
@@ -123,8 +148,8 @@ func TestAuthMiddleware(t *testing.T) {
 	t.Run("Fail: Token with Wrong Signature (Tampered)", func(t *testing.T) {
 		t.Parallel()
 		mockRepo := new(MockUserRepo)
-		serviceMiddleware := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo)
-		serviceAttacker := services.NewTokenService("wrong-secret", issuer, 1*time.Hour, mockRepo)
+		serviceMiddleware := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo, nil)
+		serviceAttacker := services.NewTokenService("wrong-secret", issuer, 1*time.Hour, mockRepo, nil)
 
 		router := setupRouter(serviceMiddleware)
 		badToken, _ := serviceAttacker.GenerateToken("attacker")
@@ -142,7 +167,7 @@ func TestAuthMiddleware(t *testing.T) {
 	t.Run("Fail: Expired Token", func(t *testing.T) {
 		t.Parallel()
 		mockRepo := new(MockUserRepo)
-		expiredService := services.NewTokenService(secret, issuer, -1*time.Second, mockRepo)
+		expiredService := services.NewTokenService(secret, issuer, -1*time.Second, mockRepo, nil)
 
 		router := setupRouter(expiredService)
 
@@ -157,4 +182,28 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.Contains(t, w.Body.String(), "invalid or expired token")
 	})
+
+	t.Run("Fail: Revoked Device", func(t *testing.T) {
+		t.Parallel()
+		mockRepo := new(MockUserRepo)
+		deviceRepo := new(MockDeviceRepo)
+		tokenService := services.NewTokenService(secret, issuer, 1*time.Hour, mockRepo, deviceRepo)
+		router := setupRouter(tokenService)
+
+		userID := "user-lost-phone"
+		revokedAt := time.Now()
+		mockRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID}, nil)
+		deviceRepo.On("GetByID", mock.Anything, "device-1").Return(&domain.Device{ID: "device-1", UserID: userID, RevokedAt: &revokedAt}, nil)
+
+		token, _ := tokenService.GenerateDeviceToken(userID, "device-1")
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "device revoked")
+	})
 }
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLockTTL bounds how long an unfinished request holds its key,
+	// so that a crashed instance does not block retries for the full TTL.
+	idempotencyLockTTL = time.Minute
+)
+
+// replayedHeaders are the response headers stored with a completed request
+// and sent again on replay, since clients rely on them as much as on the
+// body.
+var replayedHeaders = []string{"Content-Type", "Content-Encoding", "ETag", "Location", "Vary"}
+
+// IdempotencyRecord is what is stored for a key: the fingerprint of the first
+// request and, once it has completed, its response.
+type IdempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Completed   bool              `json:"completed"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+type IdempotencyStore interface {
+	// Reserve stores rec under key unless the key is already taken, in which
+	// case the existing record is returned instead.
+	Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	Save(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware makes mutating requests carrying an Idempotency-Key
+// header safe to retry: the first response is stored per user and key for
+// ttl and replayed to retries, while reusing a key for a different request is
+// rejected. It must run after AuthMiddleware.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "idempotency key too long"})
+			return
+		}
+
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		storeKey := fmt.Sprintf("idempotency:%s:%s", userID, key)
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+
+		existing, err := store.Reserve(ctx, storeKey, IdempotencyRecord{Fingerprint: fingerprint}, idempotencyLockTTL)
+		if err != nil {
+			log.Printf("Redis error (Idempotency skipped): %v", err)
+			c.Next()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "idempotency key already used for a different request",
+				})
+			case !existing.Completed:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "a request with this idempotency key is still being processed",
+				})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				for name, value := range existing.Headers {
+					c.Header(name, value)
+				}
+				c.Data(existing.Status, existing.Headers["Content-Type"], existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// Server errors are not the final answer for this request: free the
+		// key so that a retry runs it again.
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(context.WithoutCancel(ctx), storeKey); err != nil {
+				log.Printf("Redis error (Idempotency release): %v", err)
+			}
+			return
+		}
+
+		rec := IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			Headers:     make(map[string]string, len(replayedHeaders)),
+			Body:        recorder.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				rec.Headers[name] = value
+			}
+		}
+		if err := store.Save(context.WithoutCancel(ctx), storeKey, rec, ttl); err != nil {
+			log.Printf("Redis error (Idempotency save): %v", err)
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func requestFingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(uri))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+type RedisIdempotencyStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisIdempotencyStore(rdb *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{rdb: rdb}
+}
+
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.rdb.SetNX(ctx, key, data, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	raw, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The previous holder expired in between: try once more.
+		reserved, err = s.rdb.SetNX(ctx, key, data, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+		raw, err = s.rdb.Get(ctx, key).Bytes()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var existing IdempotencyRecord
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		return &existing, nil
+	}
+	s.records[key] = rec
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = rec
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func setupIdempotencyRouter(store IdempotencyStore, status int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			c.Set(ContextUserIDKey, userID)
+		}
+		c.Next()
+	})
+	r.Use(IdempotencyMiddleware(store, time.Hour))
+
+	handler := func(c *gin.Context) {
+		calls++
+		c.Header("ETag", fmt.Sprintf(`"%d"`, calls))
+		c.Header("Location", fmt.Sprintf("/entries/%d", calls))
+		c.JSON(status, gin.H{"call": calls})
+	}
+	r.POST("/entries", handler)
+	r.GET("/entries", handler)
+
+	return r, &calls
+}
+
+func doIdempotent(r *gin.Engine, method, userID, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/entries", strings.NewReader(body))
+	req.Header.Set("X-User-ID", userID)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	body := `{"habit_id":"h1","value":1}`
+
+	t.Run("Retries replay the first response", func(t *testing.T) {
+		r, calls := setupIdempotencyRouter(newMemoryIdempotencyStore(), http.StatusCreated)
+
+		first := doIdempotent(r, "POST", "user-1", "key-1", body)
+		retry := doIdempotent(r, "POST", "user-1", "key-1", body)
+
+		assert.Equal(t, 1, *calls, "The handler must run only once")
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+		for _, name := range []string{"Content-Type", "ETag", "Location"} {
+			assert.Equal(t, first.Header().Get(name), retry.Header().Get(name), name)
+		}
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("Reusing a key with a different payload is rejected", func(t *testing.T) {
+		r, calls := setupIdempotencyRouter(newMemoryIdempotencyStore(), http.StatusCreated)
+
+		doIdempotent(r, "POST", "user-1", "key-1", body)
+		w := doIdempotent(r, "POST", "user-1", "key-1", `{"habit_id":"h1","value":2}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("Keys are scoped per user", func(t *testing.T) {
+		r, calls := setupIdempotencyRouter(newMemoryIdempotencyStore(), http.StatusCreated)
+
+		doIdempotent(r, "POST", "user-1", "key-1", body)
+		w := doIdempotent(r, "POST", "user-2", "key-1", body)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("A request still in progress is not run twice", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		r, calls := setupIdempotencyRouter(store, http.StatusCreated)
+
+		first := doIdempotent(r, "POST", "user-1", "key-1", body)
+		require.Equal(t, http.StatusCreated, first.Code)
+
+		rec := store.records["idempotency:user-1:key-1"]
+		rec.Completed = false
+		store.records["idempotency:user-1:key-1"] = rec
+
+		w := doIdempotent(r, "POST", "user-1", "key-1", body)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("Server errors release the key", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		r, calls := setupIdempotencyRouter(store, http.StatusInternalServerError)
+
+		doIdempotent(r, "POST", "user-1", "key-1", body)
+		doIdempotent(r, "POST", "user-1", "key-1", body)
+
+		assert.Equal(t, 2, *calls)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("Requests without a key or read-only are untouched", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		r, calls := setupIdempotencyRouter(store, http.StatusCreated)
+
+		doIdempotent(r, "POST", "user-1", "", body)
+		doIdempotent(r, "POST", "user-1", "", body)
+		doIdempotent(r, "GET", "user-1", "key-1", "")
+		doIdempotent(r, "GET", "user-1", "key-1", "")
+
+		assert.Equal(t, 4, *calls)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("Overlong keys are rejected", func(t *testing.T) {
+		r, calls := setupIdempotencyRouter(newMemoryIdempotencyStore(), http.StatusCreated)
+
+		w := doIdempotent(r, "POST", "user-1", strings.Repeat("k", maxIdempotencyKeyLength+1), body)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, *calls)
+	})
+}
+
+func TestRedisIdempotencyStore_Integration(t *testing.T) {
+	rdb := setupTestRedis(t)
+	defer rdb.Close()
+
+	ctx := context.Background()
+	store := NewRedisIdempotencyStore(rdb)
+
+	existing, err := store.Reserve(ctx, "idempotency:u:k", IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "The first caller reserves the key")
+
+	existing, err = store.Reserve(ctx, "idempotency:u:k", IdempotencyRecord{Fingerprint: "b"}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "a", existing.Fingerprint)
+
+	require.NoError(t, store.Save(ctx, "idempotency:u:k", IdempotencyRecord{Fingerprint: "a", Completed: true, Status: 201, Body: []byte(`{}`)}, time.Minute))
+	existing, err = store.Reserve(ctx, "idempotency:u:k", IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, []byte(`{}`), existing.Body)
+
+	require.NoError(t, store.Release(ctx, "idempotency:u:k"))
+	existing, err = store.Reserve(ctx, "idempotency:u:k", IdempotencyRecord{Fingerprint: "c"}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
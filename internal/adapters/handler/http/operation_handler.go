@@ -0,0 +1,84 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type OperationHandler struct {
+	svc *services.OperationService
+}
+
+func NewOperationHandler(svc *services.OperationService) *OperationHandler {
+	return &OperationHandler{
+		svc: svc,
+	}
+}
+
+func (h *OperationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/operations", h.List)
+}
+
+// List godoc
+// @Summary      Operation history
+// @Description  Pages through the append-only log of writes to the user's habits and entries, newest first:
+// @Description  create, update, delete, archive, unarchive and streak changes, with the device that made them and the row before and after.
+// @Description  habit_id narrows the log to one habit and its entries. While has_more is true, pass next_page_token to get older operations.
+// @Tags         Operations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        habit_id   query string false "Only operations on this habit and its entries"
+// @Param        limit      query int    false "Page size (default 50, max 200)"
+// @Param        page_token query string false "Token from the previous page"
+// @Success      200  {object}  map[string]interface{} "operations, has_more, next_page_token"
+// @Failure      400  {object}  map[string]string "Invalid Page Token or Limit"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /operations [get]
+func (h *OperationHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	limit, err := parseSyncLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before int64
+	if token := c.Query("page_token"); token != "" {
+		if before, err = decodePageToken(token); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ops, hasMore, err := h.svc.List(c.Request.Context(), domain.OperationQuery{
+		UserID:  userID,
+		HabitID: c.Query("habit_id"),
+		Before:  before,
+		Limit:   limit,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Listing operations failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list operations"})
+		return
+	}
+
+	resp := gin.H{
+		"operations": ops,
+		"has_more":   hasMore,
+	}
+	if hasMore {
+		resp["next_page_token"] = encodePageToken(ops[len(ops)-1].ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
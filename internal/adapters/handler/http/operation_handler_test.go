@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type memoryOperationRepo struct {
+	ops []*domain.Operation
+}
+
+func (r *memoryOperationRepo) Append(ctx context.Context, op *domain.Operation) error {
+	op.ID = int64(len(r.ops) + 1)
+	r.ops = append(r.ops, op)
+	return nil
+}
+
+func (r *memoryOperationRepo) List(ctx context.Context, q domain.OperationQuery) ([]*domain.Operation, error) {
+	var result []*domain.Operation
+	for i := len(r.ops) - 1; i >= 0 && len(result) < q.Limit; i-- {
+		op := r.ops[i]
+		if op.UserID != q.UserID || (q.HabitID != "" && op.HabitID != q.HabitID) || (q.Before > 0 && op.ID >= q.Before) {
+			continue
+		}
+		result = append(result, op)
+	}
+	return result, nil
+}
+
+func setupOperationRouter(repo domain.OperationRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := adapterHTTP.NewOperationHandler(services.NewOperationService(repo))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+	return r
+}
+
+type operationPage struct {
+	Operations    []domain.Operation `json:"operations"`
+	HasMore       bool               `json:"has_more"`
+	NextPageToken string             `json:"next_page_token"`
+}
+
+func getOperations(t *testing.T, r *gin.Engine, query string) (int, operationPage) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/operations"+query, nil)
+	r.ServeHTTP(w, req)
+
+	var page operationPage
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	}
+	return w.Code, page
+}
+
+func TestListOperations(t *testing.T) {
+	repo := &memoryOperationRepo{}
+	for _, habitID := range []string{"habit-a", "habit-b", "habit-a"} {
+		repo.Append(context.Background(), &domain.Operation{UserID: "user-1", EntityType: domain.OperationEntityHabit, EntityID: habitID, HabitID: habitID, Op: domain.OperationUpdate, NewValue: json.RawMessage(`{"title":"Read"}`)})
+	}
+	repo.Append(context.Background(), &domain.Operation{UserID: "user-2", HabitID: "habit-a", Op: domain.OperationCreate})
+
+	r := setupOperationRouter(repo)
+
+	t.Run("Success: Pages through the history with a page token", func(t *testing.T) {
+		code, page := getOperations(t, r, "?limit=2")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, page.Operations, 2)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, int64(3), page.Operations[0].ID)
+		assert.JSONEq(t, `{"title":"Read"}`, string(page.Operations[0].NewValue))
+
+		code, page = getOperations(t, r, "?limit=2&page_token="+page.NextPageToken)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, page.Operations, 1)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, int64(1), page.Operations[0].ID)
+	})
+
+	t.Run("Success: Filters by habit", func(t *testing.T) {
+		code, page := getOperations(t, r, "?habit_id=habit-a")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, page.Operations, 2, "Other users' operations are never listed")
+	})
+
+	t.Run("Fail: 400 on invalid limit or page token", func(t *testing.T) {
+		code, _ := getOperations(t, r, "?limit=0")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = getOperations(t, r, "?page_token=not*base64")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
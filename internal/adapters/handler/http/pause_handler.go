@@ -0,0 +1,211 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type PauseHandler struct {
+	svc *services.PauseService
+}
+
+func NewPauseHandler(svc *services.PauseService) *PauseHandler {
+	return &PauseHandler{
+		svc: svc,
+	}
+}
+
+type createPauseRequest struct {
+	HabitID   string `json:"habit_id"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Reason    string `json:"reason"`
+}
+
+type updatePauseRequest struct {
+	StartDate *string `json:"start_date"`
+	EndDate   *string `json:"end_date"`
+	Reason    *string `json:"reason"`
+}
+
+func (h *PauseHandler) RegisterRoutes(router *gin.RouterGroup) {
+	pauses := router.Group("/pauses")
+	{
+		pauses.GET("", h.List)
+		pauses.POST("", h.Create)
+		pauses.PUT("/:id", h.Update)
+		pauses.DELETE("/:id", h.Delete)
+	}
+}
+
+// List godoc
+// @Summary      List pauses
+// @Description  Get the user's pauses, per habit and account-wide, most recent start first.
+// @Tags         Pauses
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   domain.Pause
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /pauses [get]
+func (h *PauseHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	pauses, err := h.svc.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[ERROR] Listing pauses failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list pauses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pauses)
+}
+
+// Create godoc
+// @Summary      Pause habits
+// @Description  Pause one habit (habit_id) or every habit of the user, e.g. for a vacation or sick days. Paused days are not due,
+// @Description  are left out of the stats and neither break nor extend a streak. start_date and end_date are YYYY-MM-DD dates in the
+// @Description  user's timezone (or RFC 3339 timestamps), both included; the pause starts today by default and lasts until ended without an end date.
+// @Tags         Pauses
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        X-Timezone header string false "User Timezone (e.g. Europe/Rome). Defaults to UTC."
+// @Param        pause body createPauseRequest true "Pause Data"
+// @Success      201  {object}  domain.Pause
+// @Failure      400  {object}  map[string]string "Invalid Dates or Reason"
+// @Failure      404  {object}  map[string]string "Habit Not Found"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /pauses [post]
+func (h *PauseHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req createPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pause, err := h.svc.Create(c.Request.Context(), services.CreatePauseInput{
+		UserID:    userID,
+		HabitID:   req.HabitID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Reason:    req.Reason,
+		Location:  location,
+	})
+	if err != nil {
+		handlePauseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, pause)
+}
+
+// Update godoc
+// @Summary      Update a pause
+// @Description  Move or end a pause, or change its reason. An empty end_date makes the pause open-ended again.
+// @Tags         Pauses
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string true "Pause ID"
+// @Param        X-Timezone header string false "User Timezone (e.g. Europe/Rome). Defaults to UTC."
+// @Param        pause body updatePauseRequest true "Update Data"
+// @Success      200  {object}  domain.Pause
+// @Failure      400  {object}  map[string]string "Invalid Dates or Reason"
+// @Failure      404  {object}  map[string]string "Pause Not Found"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /pauses/{id} [put]
+func (h *PauseHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req updatePauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pause, err := h.svc.Update(c.Request.Context(), services.UpdatePauseInput{
+		ID:        c.Param("id"),
+		UserID:    userID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Reason:    req.Reason,
+		Location:  location,
+	})
+	if err != nil {
+		handlePauseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pause)
+}
+
+// Delete godoc
+// @Summary      Delete a pause
+// @Description  Remove a pause: its days count again, as if it never happened.
+// @Tags         Pauses
+// @Security     BearerAuth
+// @Param        id   path string true "Pause ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]string "Pause Not Found"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /pauses/{id} [delete]
+func (h *PauseHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
+		handlePauseError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func handlePauseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrPauseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "pause not found"})
+	case errors.Is(err, domain.ErrHabitNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "habit not found"})
+	case errors.Is(err, domain.ErrInvalidHabitDate), errors.Is(err, domain.ErrInvalidDateRange), errors.Is(err, domain.ErrPauseReasonTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[ERROR] Pause request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,154 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakePauseRepo struct {
+	pauses map[string]*domain.Pause
+}
+
+func (r *fakePauseRepo) Create(ctx context.Context, pause *domain.Pause) error {
+	r.pauses[pause.ID] = pause
+	return nil
+}
+
+func (r *fakePauseRepo) GetByID(ctx context.Context, id string) (*domain.Pause, error) {
+	p, ok := r.pauses[id]
+	if !ok {
+		return nil, domain.ErrPauseNotFound
+	}
+	clone := *p
+	return &clone, nil
+}
+
+func (r *fakePauseRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Pause, error) {
+	pauses := []*domain.Pause{}
+	for _, p := range r.pauses {
+		if p.UserID == userID {
+			pauses = append(pauses, p)
+		}
+	}
+	return pauses, nil
+}
+
+func (r *fakePauseRepo) Update(ctx context.Context, pause *domain.Pause) error {
+	r.pauses[pause.ID] = pause
+	return nil
+}
+
+func (r *fakePauseRepo) Delete(ctx context.Context, id string) error {
+	delete(r.pauses, id)
+	return nil
+}
+
+func setupPauseRouter() (*gin.Engine, *fakePauseRepo, *MockRepo) {
+	gin.SetMode(gin.TestMode)
+
+	pauses := &fakePauseRepo{pauses: make(map[string]*domain.Pause)}
+	habits := NewMockRepo()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			c.Set(middleware.ContextUserIDKey, userID)
+		}
+		c.Next()
+	})
+	api := r.Group("/api/v1")
+	adapterHTTP.NewPauseHandler(services.NewPauseService(pauses, habits, getTestWorker())).RegisterRoutes(api)
+	return r, pauses, habits
+}
+
+func TestPauseHandler(t *testing.T) {
+	t.Run("Success: 201 Created, listed, ended and deleted", func(t *testing.T) {
+		router, pauses, _ := setupPauseRouter()
+
+		body := `{"start_date": "2026-08-01", "end_date": "2026-08-14", "reason": "Holiday"}`
+		req, _ := http.NewRequest("POST", "/api/v1/pauses", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		req.Header.Set("X-Timezone", "Europe/Rome")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created domain.Pause
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "Holiday", created.Reason)
+		assert.Equal(t, "2026-08-01T00:00:00Z", created.StartDate.Format("2006-01-02T15:04:05Z07:00"))
+
+		req, _ = http.NewRequest("GET", "/api/v1/pauses", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), created.ID)
+
+		req, _ = http.NewRequest("PUT", "/api/v1/pauses/"+created.ID, bytes.NewBufferString(`{"end_date": "2026-08-07"}`))
+		req.Header.Set("X-User-ID", "user-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2026-08-07", pauses.pauses[created.ID].EndDate.Format("2006-01-02"))
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/pauses/"+created.ID, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, pauses.pauses)
+	})
+
+	t.Run("Fail: 400 Bad Request (End Before Start)", func(t *testing.T) {
+		router, _, _ := setupPauseRouter()
+
+		body := `{"start_date": "2026-08-14", "end_date": "2026-08-01"}`
+		req, _ := http.NewRequest("POST", "/api/v1/pauses", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: 404 Habit Not Found (IDOR)", func(t *testing.T) {
+		router, _, habits := setupPauseRouter()
+		h, _ := domain.NewHabit("", "Not Yours", "user-2")
+		habits.Create(context.Background(), h)
+
+		req, _ := http.NewRequest("POST", "/api/v1/pauses", bytes.NewBufferString(`{"habit_id": "`+h.ID+`"}`))
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Fail: 404 Pause Not Found", func(t *testing.T) {
+		router, _, _ := setupPauseRouter()
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/pauses/missing", nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
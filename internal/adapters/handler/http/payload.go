@@ -0,0 +1,241 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	MIMEMsgPack  = binding.MIMEMSGPACK2
+	MIMEProtobuf = binding.MIMEPROTOBUF
+
+	// gzipMinSize is the smallest body worth compressing: below it the gzip
+	// header and trailer outweigh the savings.
+	gzipMinSize = 1024
+)
+
+// payloadFormats are the response formats of the sync, list and snapshot
+// endpoints, in order of preference when the client accepts anything.
+var payloadFormats = []string{binding.MIMEJSON, MIMEMsgPack, binding.MIMEMSGPACK, MIMEProtobuf}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.Canonical = true
+	return h
+}()
+
+// negotiatePayload picks the response format from the Accept header,
+// falling back to JSON.
+func negotiatePayload(c *gin.Context) string {
+	format := c.NegotiateFormat(payloadFormats...)
+	if format == "" {
+		return binding.MIMEJSON
+	}
+	return format
+}
+
+// encodePayload encodes v in format. The binary formats are transcoded from
+// the JSON encoding of v, so their payloads have the same field names and
+// values as the JSON API: msgpack carries the document as a map, protobuf as
+// a google.protobuf.Value.
+func encodePayload(format string, v interface{}) ([]byte, error) {
+	if format == binding.MIMEJSON {
+		return json.Marshal(v)
+	}
+
+	doc, err := payloadDocument(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == MIMEProtobuf {
+		value, err := structpb.NewValue(doc)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(value)
+	}
+
+	var out []byte
+	err = codec.NewEncoderBytes(&out, msgpackHandle).Encode(msgpackNumbers(doc))
+	return out, err
+}
+
+// payloadDocument is the JSON encoding of v decoded into maps, slices and
+// json.Numbers.
+func payloadDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// msgpackNumbers replaces the JSON numbers of doc with integers where they
+// fit, so that msgpack can use its compact integer encodings.
+func msgpackNumbers(doc interface{}) interface{} {
+	switch v := doc.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = msgpackNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = msgpackNumbers(item)
+		}
+	}
+	return doc
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of the request
+// allows a gzip response body. Only gzip is offered: zstd is not negotiated.
+func acceptsGzip(c *gin.Context) bool {
+	return acceptsEncoding(c.GetHeader("Accept-Encoding"), "gzip")
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows coding:
+// it is listed, or matched by "*", with a non-zero quality value. An
+// explicit entry for coding takes precedence over the wildcard.
+func acceptsEncoding(header, coding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		accepted := qualityAllows(params)
+		switch {
+		case name == coding || name == "x-"+coding:
+			return accepted
+		case name == "*":
+			wildcard = accepted
+		}
+	}
+	return wildcard
+}
+
+// qualityAllows reports whether the parameters of an Accept-Encoding entry
+// leave it acceptable, i.e. carry no q-value or one above zero.
+func qualityAllows(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return err == nil && q > 0
+	}
+	return true
+}
+
+// renderPayload writes v in the format the client accepts, gzip-compressed
+// when the client supports it and the body is large enough to benefit.
+func renderPayload(c *gin.Context, status int, v interface{}) {
+	writePayload(c, status, v, false)
+}
+
+// renderCacheablePayload is renderPayload for GET responses: the body is
+// tagged with a strong ETag and If-None-Match is answered with 304.
+func renderCacheablePayload(c *gin.Context, v interface{}) {
+	writePayload(c, http.StatusOK, v, true)
+}
+
+func writePayload(c *gin.Context, status int, v interface{}, cacheable bool) {
+	format := negotiatePayload(c)
+
+	body, err := encodePayload(format, v)
+	if err != nil {
+		log.Printf("[ERROR] Encoding %s response for %s failed: %v", format, c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Header("Vary", "Accept, Accept-Encoding")
+
+	if len(body) >= gzipMinSize && acceptsGzip(c) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err == nil && gz.Close() == nil {
+			c.Header("Content-Encoding", "gzip")
+			body = buf.Bytes()
+		}
+	}
+
+	if cacheable && notModified(c, bodyETag(body)) {
+		c.Header("Content-Encoding", "")
+		return
+	}
+
+	if format == binding.MIMEJSON {
+		format = "application/json; charset=utf-8"
+	}
+	c.Data(status, format, body)
+}
+
+// payloadStream writes a sequence of documents in a streamable format:
+// newline-delimited JSON, concatenated msgpack values (which are
+// self-delimiting) or varint length-delimited protobuf Values.
+type payloadStream struct {
+	format string
+	out    io.Writer
+}
+
+// streamContentType is the Content-Type of a stream in format.
+func streamContentType(format string) string {
+	if format == binding.MIMEJSON {
+		return "application/x-ndjson"
+	}
+	return format
+}
+
+func (s *payloadStream) write(v interface{}) error {
+	switch s.format {
+	case binding.MIMEJSON:
+		return json.NewEncoder(s.out).Encode(v)
+
+	case MIMEProtobuf:
+		doc, err := payloadDocument(v)
+		if err != nil {
+			return err
+		}
+		value, err := structpb.NewValue(doc)
+		if err != nil {
+			return err
+		}
+		_, err = protodelim.MarshalTo(s.out, value)
+		return err
+
+	default:
+		body, err := encodePayload(s.format, v)
+		if err != nil {
+			return err
+		}
+		_, err = s.out.Write(body)
+		return err
+	}
+}
@@ -0,0 +1,122 @@
+package http_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func msgpackTestHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.RawToString = true
+	return h
+}
+
+func TestPayloadNegotiation(t *testing.T) {
+	router, repo := setupRouter()
+	for i := 0; i < 10; i++ {
+		h, _ := domain.NewHabit(fmt.Sprintf("habit-%d", i), "Read a book before going to sleep", "user-1")
+		repo.Create(context.Background(), h)
+	}
+
+	get := func(path, accept, encoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var expected []map[string]interface{}
+	jsonResp := get("/api/v1/habits", "", "")
+	require.Equal(t, http.StatusOK, jsonResp.Code)
+	assert.Contains(t, jsonResp.Header().Get("Content-Type"), "application/json")
+	require.NoError(t, json.Unmarshal(jsonResp.Body.Bytes(), &expected))
+	require.Len(t, expected, 10)
+
+	t.Run("Success: msgpack keeps the JSON field names", func(t *testing.T) {
+		w := get("/api/v1/habits", "application/msgpack", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+		assert.Less(t, w.Body.Len(), jsonResp.Body.Len())
+
+		var habits []map[string]interface{}
+		require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), msgpackTestHandle()).Decode(&habits))
+		require.Len(t, habits, 10)
+
+		byID := make(map[interface{}]map[string]interface{})
+		for _, h := range expected {
+			byID[h["id"]] = h
+		}
+		for _, h := range habits {
+			want := byID[h["id"]]
+			require.NotNil(t, want)
+			assert.Equal(t, want["title"], h["title"])
+			assert.EqualValues(t, want["version"], h["version"])
+		}
+	})
+
+	t.Run("Success: protobuf carries the document as a Value", func(t *testing.T) {
+		w := get("/api/v1/habits/sync", "application/x-protobuf", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+
+		value := &structpb.Value{}
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), value))
+
+		page := value.GetStructValue().AsMap()
+		assert.Contains(t, page, "cursor")
+		assert.Len(t, page["changes"], 10)
+	})
+
+	t.Run("Success: Large bodies are gzip-compressed when accepted", func(t *testing.T) {
+		w := get("/api/v1/habits", "", "gzip, deflate")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		var habits []map[string]interface{}
+		require.NoError(t, json.NewDecoder(gz).Decode(&habits))
+		assert.Len(t, habits, 10)
+	})
+
+	t.Run("Success: Encodings refused with q=0 are not used", func(t *testing.T) {
+		for _, encoding := range []string{"gzip;q=0, deflate", "*;q=1, gzip; q=0.0", "zstd", "*;q=0"} {
+			w := get("/api/v1/habits", "", encoding)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Encoding"), encoding)
+
+			var habits []map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &habits), encoding)
+		}
+
+		w := get("/api/v1/habits", "", "zstd, *;q=0.5")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), "The wildcard admits gzip")
+	})
+
+	t.Run("Success: Unsupported formats fall back to JSON", func(t *testing.T) {
+		w := get("/api/v1/habits", "text/html", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+}
@@ -18,14 +18,23 @@ import (
 )
 
 type RouterDependencies struct {
-	AuthHandler  *AuthHandler
-	HabitHandler *HabitHandler
-	EntryHandler *EntryHandler
-	StatsHandler *StatsHandler
-	TokenService *services.TokenService
-	DB           *sqlx.DB
-	Redis        *redis.Client
-	StartTime    time.Time
+	AuthHandler      *AuthHandler
+	HabitHandler     *HabitHandler
+	EntryHandler     *EntryHandler
+	StatsHandler     *StatsHandler
+	SyncHandler      *SyncHandler
+	StreamHandler    *StreamHandler
+	DeviceHandler    *DeviceHandler
+	SnapshotHandler  *SnapshotHandler
+	ChecksumHandler  *ChecksumHandler
+	OperationHandler *OperationHandler
+	TrashHandler     *TrashHandler
+	ScheduleHandler  *ScheduleHandler
+	PauseHandler     *PauseHandler
+	TokenService     *services.TokenService
+	DB               *sqlx.DB
+	Redis            *redis.Client
+	StartTime        time.Time
 }
 
 func NewRouter(deps RouterDependencies) *gin.Engine {
@@ -42,8 +51,12 @@ func NewRouter(deps RouterDependencies) *gin.Engine {
 			"Accept-Encoding",
 			"X-CSRF-Token",
 			"X-Timezone",
+			"Last-Event-ID",
+			"If-Match",
+			"If-None-Match",
+			middleware.IdempotencyKeyHeader,
 		},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", middleware.IdempotentReplayedHeader, SyncCursorHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -87,10 +100,40 @@ func NewRouter(deps RouterDependencies) *gin.Engine {
 
 	protected := apiV1.Group("")
 	protected.Use(authMiddleware)
+	if deps.Redis != nil {
+		protected.Use(middleware.IdempotencyMiddleware(middleware.NewRedisIdempotencyStore(deps.Redis), 24*time.Hour))
+	}
 	{
 		deps.HabitHandler.RegisterRoutes(protected)
 		deps.EntryHandler.RegisterRoutes(protected)
 		deps.StatsHandler.RegisterRoutes(protected)
+		if deps.SyncHandler != nil {
+			deps.SyncHandler.RegisterRoutes(protected)
+		}
+		if deps.StreamHandler != nil {
+			deps.StreamHandler.RegisterRoutes(protected)
+		}
+		if deps.DeviceHandler != nil {
+			deps.DeviceHandler.RegisterRoutes(protected)
+		}
+		if deps.SnapshotHandler != nil {
+			deps.SnapshotHandler.RegisterRoutes(protected)
+		}
+		if deps.ChecksumHandler != nil {
+			deps.ChecksumHandler.RegisterRoutes(protected)
+		}
+		if deps.OperationHandler != nil {
+			deps.OperationHandler.RegisterRoutes(protected)
+		}
+		if deps.TrashHandler != nil {
+			deps.TrashHandler.RegisterRoutes(protected)
+		}
+		if deps.ScheduleHandler != nil {
+			deps.ScheduleHandler.RegisterRoutes(protected)
+		}
+		if deps.PauseHandler != nil {
+			deps.PauseHandler.RegisterRoutes(protected)
+		}
 	}
 
 	return router
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+var errInvalidTimezone = errors.New("invalid timezone format (use IANA name like 'Europe/Rome')")
+
+type ScheduleHandler struct {
+	svc *services.ScheduleService
+}
+
+func NewScheduleHandler(svc *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		svc: svc,
+	}
+}
+
+func (h *ScheduleHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/habits/today", h.Today)
+}
+
+// requestLocation is the user's timezone from the X-Timezone header, UTC
+// when it is not sent.
+func requestLocation(c *gin.Context) (*time.Location, error) {
+	tzHeader := c.GetHeader("X-Timezone")
+	if tzHeader == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tzHeader)
+	if err != nil {
+		return nil, errInvalidTimezone
+	}
+	return loc, nil
+}
+
+// Today godoc
+// @Summary      List the habits due today
+// @Description  Get the habits scheduled on a calendar date in the user's timezone (today by default), with the progress toward
+// @Description  their target on that day. Weekly and monthly habits also report their progress in the current week or month.
+// @Tags         Habits
+// @Produce      json
+// @Produce      application/msgpack
+// @Produce      application/x-protobuf
+// @Security     BearerAuth
+// @Param        date       query  string false "Date (YYYY-MM-DD), defaults to today"
+// @Param        X-Timezone header string false "User Timezone (e.g. Europe/Rome). Defaults to UTC."
+// @Success      200  {object}  domain.DueHabits
+// @Failure      400  {object}  map[string]string "Invalid Date/Timezone"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/today [get]
+func (h *ScheduleHandler) Today(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	day := time.Now().In(location)
+	if dateStr := c.Query("date"); dateStr != "" {
+		day, err = time.ParseInLocation("2006-01-02", dateStr, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	due, err := h.svc.DueOn(c.Request.Context(), userID, day)
+	if err != nil {
+		log.Printf("[ERROR] Listing due habits failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list due habits"})
+		return
+	}
+
+	renderCacheablePayload(c, due)
+}
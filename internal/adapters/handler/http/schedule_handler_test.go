@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+func setupScheduleRouter() (*gin.Engine, *MockHabitRepoForStats, *MockEntryRepo) {
+	gin.SetMode(gin.TestMode)
+
+	habitRepo := new(MockHabitRepoForStats)
+	entryRepo := NewMockEntryRepo()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		c.Next()
+	})
+	api := r.Group("/api/v1")
+	adapterHTTP.NewHabitHandler(services.NewHabitService(NewMockRepo(), nil, services.WriteOptions{})).RegisterRoutes(api)
+	adapterHTTP.NewScheduleHandler(services.NewScheduleService(habitRepo, entryRepo, nil)).RegisterRoutes(api)
+
+	return r, habitRepo, entryRepo
+}
+
+func TestHabitsToday(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	habits := []*domain.Habit{
+		{ID: "daily", UserID: "user-1", Title: "Water", TargetValue: 2, FrequencyType: domain.HabitFreqDaily, StartDate: start},
+		{ID: "monday", UserID: "user-1", Title: "Gym", TargetValue: 1, FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{1}, StartDate: start},
+	}
+
+	t.Run("Success: Lists the habits due on the date with progress", func(t *testing.T) {
+		r, habitRepo, entryRepo := setupScheduleRouter()
+		habitRepo.On("ListByUserID", mock.Anything, "user-1").Return(habits, nil)
+		entryRepo.store["e1"] = &domain.HabitEntry{
+			ID: "e1", HabitID: "daily", UserID: "user-1", Value: 2,
+			CompletionDate: time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC),
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/habits/today?date=2026-10-14", nil)
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+
+		var due domain.DueHabits
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &due))
+		assert.Equal(t, "2026-10-14", due.Date)
+		require.Len(t, due.Habits, 1)
+		assert.Equal(t, "daily", due.Habits[0].Habit.ID)
+		assert.Equal(t, 2, due.Habits[0].Progress)
+		assert.True(t, due.Habits[0].Completed)
+	})
+
+	t.Run("Success: Date is read in the user's timezone", func(t *testing.T) {
+		if _, err := time.LoadLocation("America/New_York"); err != nil {
+			t.Skip("timezone data not available")
+		}
+		r, habitRepo, _ := setupScheduleRouter()
+		habitRepo.On("ListByUserID", mock.Anything, "user-1").Return(habits, nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/habits/today?date=2026-10-12", nil)
+		req.Header.Set("X-Timezone", "America/New_York")
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var due domain.DueHabits
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &due))
+		assert.Equal(t, "America/New_York", due.Timezone)
+		assert.Len(t, due.Habits, 2)
+	})
+
+	t.Run("Fail: Invalid date", func(t *testing.T) {
+		r, _, _ := setupScheduleRouter()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/habits/today?date=14-10-2026", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: Invalid timezone", func(t *testing.T) {
+		r, _, _ := setupScheduleRouter()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/habits/today", nil)
+		req.Header.Set("X-Timezone", "Mars/Olympus")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
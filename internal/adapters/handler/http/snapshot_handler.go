@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+const SyncCursorHeader = "X-Sync-Cursor"
+
+type SnapshotHandler struct {
+	svc *services.SnapshotService
+}
+
+func NewSnapshotHandler(svc *services.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{
+		svc: svc,
+	}
+}
+
+func (h *SnapshotHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/snapshot", h.Snapshot)
+}
+
+// Snapshot godoc
+// @Summary      Full snapshot for new devices
+// @Description  Streams every live habit and entry of the user as NDJSON, gzip-compressed when the client accepts it.
+// @Description  With Accept: application/msgpack the lines are concatenated msgpack maps instead; with application/x-protobuf,
+// @Description  varint length-delimited google.protobuf.Value messages. Field names are the same in every format.
+// @Description  The first line carries the sync cursor the snapshot is current as of (also sent in the X-Sync-Cursor header):
+// @Description  the device continues with delta sync from it without gaps or duplicates. The last line has type "end";
+// @Description  a stream without it was interrupted and must be discarded.
+// @Tags         Sync
+// @Produce      application/x-ndjson,application/msgpack,application/x-protobuf
+// @Security     BearerAuth
+// @Success      200  {string}  string "application/x-ndjson"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /snapshot [get]
+func (h *SnapshotHandler) Snapshot(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	w := &snapshotStreamWriter{c: c, format: negotiatePayload(c)}
+
+	err := h.svc.Export(c.Request.Context(), userID, w)
+	if err == nil {
+		err = w.finish()
+	}
+	if err != nil {
+		if !w.started {
+			log.Printf("[ERROR] Snapshot failed for user %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build snapshot"})
+			return
+		}
+		// The status is already sent: the missing end line tells the client
+		// that the snapshot is incomplete.
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("[ERROR] Snapshot interrupted for user %s: %v", userID, err)
+		}
+	}
+}
+
+type snapshotLine struct {
+	Type    string             `json:"type"`
+	Cursor  string             `json:"cursor,omitempty"`
+	Habit   *domain.Habit      `json:"habit,omitempty"`
+	Entry   *domain.HabitEntry `json:"entry,omitempty"`
+	Habits  int                `json:"habits,omitempty"`
+	Entries int                `json:"entries,omitempty"`
+}
+
+// snapshotStreamWriter writes one document per line straight to the
+// response, in the negotiated format. Headers are only sent once the
+// snapshot cursor is known, so failures before that still get a regular
+// error response.
+type snapshotStreamWriter struct {
+	c       *gin.Context
+	format  string
+	started bool
+	flush   func() error
+	stream  *payloadStream
+	habits  int
+	entries int
+}
+
+func (w *snapshotStreamWriter) Begin(cursor int64) error {
+	c := w.c
+
+	// Large snapshots can take longer than the server write timeout, which
+	// is sized for regular requests.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", streamContentType(w.format))
+	c.Header("Cache-Control", "no-store")
+	c.Header("Vary", "Accept, Accept-Encoding")
+	c.Header(SyncCursorHeader, formatSyncCursor(cursor))
+
+	var out io.Writer
+	if acceptsGzip(c) {
+		c.Header("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(c.Writer)
+		out, w.flush = gz, gz.Close
+	} else {
+		buf := bufio.NewWriter(c.Writer)
+		out, w.flush = buf, buf.Flush
+	}
+
+	c.Status(http.StatusOK)
+	w.started = true
+	w.stream = &payloadStream{format: w.format, out: out}
+
+	return w.stream.write(snapshotLine{Type: "cursor", Cursor: formatSyncCursor(cursor)})
+}
+
+func (w *snapshotStreamWriter) Habit(h *domain.Habit) error {
+	w.habits++
+	return w.stream.write(snapshotLine{Type: "habit", Habit: h})
+}
+
+func (w *snapshotStreamWriter) Entry(e *domain.HabitEntry) error {
+	w.entries++
+	return w.stream.write(snapshotLine{Type: "entry", Entry: e})
+}
+
+func (w *snapshotStreamWriter) finish() error {
+	if err := w.stream.write(snapshotLine{Type: "end", Habits: w.habits, Entries: w.entries}); err != nil {
+		return err
+	}
+	return w.flush()
+}
@@ -0,0 +1,206 @@
+package http_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakeSnapshotRepo struct {
+	cursor  int64
+	habits  []*domain.Habit
+	entries []*domain.HabitEntry
+	// failAt makes the snapshot fail before Begin (0) or after the habits (1).
+	failAt int
+	err    error
+}
+
+func (r *fakeSnapshotRepo) Snapshot(ctx context.Context, userID string, w domain.SnapshotWriter) error {
+	if r.err != nil && r.failAt == 0 {
+		return r.err
+	}
+	if err := w.Begin(r.cursor); err != nil {
+		return err
+	}
+	for _, h := range r.habits {
+		if err := w.Habit(h); err != nil {
+			return err
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	for _, e := range r.entries {
+		if err := w.Entry(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type snapshotTestLine struct {
+	Type    string             `json:"type"`
+	Cursor  string             `json:"cursor"`
+	Habit   *domain.Habit      `json:"habit"`
+	Entry   *domain.HabitEntry `json:"entry"`
+	Habits  int                `json:"habits"`
+	Entries int                `json:"entries"`
+}
+
+func setupSnapshotRouter(repo domain.SnapshotRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := adapterHTTP.NewSnapshotHandler(services.NewSnapshotService(repo))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+	return r
+}
+
+func readSnapshotLines(t *testing.T, body io.Reader) []snapshotTestLine {
+	t.Helper()
+
+	var lines []snapshotTestLine
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var line snapshotTestLine
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestSnapshotHandler(t *testing.T) {
+	habit, _ := domain.NewHabit("habit-1", "Read", "user-1")
+	entry := &domain.HabitEntry{ID: "entry-1", HabitID: "habit-1", UserID: "user-1", Value: 1}
+
+	t.Run("Success: Streams gzip NDJSON framed by cursor and end lines", func(t *testing.T) {
+		r := setupSnapshotRouter(&fakeSnapshotRepo{cursor: 42, habits: []*domain.Habit{habit}, entries: []*domain.HabitEntry{entry}})
+
+		req, _ := http.NewRequest("GET", "/api/v1/snapshot", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Equal(t, "42", w.Header().Get(adapterHTTP.SyncCursorHeader))
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		lines := readSnapshotLines(t, gz)
+
+		require.Len(t, lines, 4)
+		assert.Equal(t, "cursor", lines[0].Type)
+		assert.Equal(t, "42", lines[0].Cursor)
+		assert.Equal(t, "habit-1", lines[1].Habit.ID)
+		assert.Equal(t, "entry-1", lines[2].Entry.ID)
+		assert.Equal(t, snapshotTestLine{Type: "end", Habits: 1, Entries: 1}, lines[3])
+	})
+
+	t.Run("Success: Plain NDJSON when gzip is not accepted", func(t *testing.T) {
+		r := setupSnapshotRouter(&fakeSnapshotRepo{cursor: 7})
+
+		req, _ := http.NewRequest("GET", "/api/v1/snapshot", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+		lines := readSnapshotLines(t, w.Body)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "7", lines[0].Cursor)
+		assert.Equal(t, "end", lines[1].Type)
+	})
+
+	t.Run("Success: msgpack and protobuf streams carry the same lines", func(t *testing.T) {
+		r := setupSnapshotRouter(&fakeSnapshotRepo{cursor: 42, habits: []*domain.Habit{habit}})
+
+		req, _ := http.NewRequest("GET", "/api/v1/snapshot", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+		dec := codec.NewDecoder(w.Body, msgpackTestHandle())
+		var types []interface{}
+		for {
+			var line map[string]interface{}
+			if err := dec.Decode(&line); errors.Is(err, io.EOF) {
+				break
+			} else {
+				require.NoError(t, err)
+			}
+			types = append(types, line["type"])
+		}
+		assert.Equal(t, []interface{}{"cursor", "habit", "end"}, types)
+
+		req, _ = http.NewRequest("GET", "/api/v1/snapshot", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		reader := bufio.NewReader(w.Body)
+		types = nil
+		for {
+			value := &structpb.Value{}
+			if err := protodelim.UnmarshalFrom(reader, value); err == io.EOF {
+				break
+			} else {
+				require.NoError(t, err)
+			}
+			types = append(types, value.GetStructValue().AsMap()["type"])
+		}
+		assert.Equal(t, []interface{}{"cursor", "habit", "end"}, types)
+	})
+
+	t.Run("Fail: 500 when the snapshot cannot start", func(t *testing.T) {
+		r := setupSnapshotRouter(&fakeSnapshotRepo{err: errors.New("db down")})
+
+		req, _ := http.NewRequest("GET", "/api/v1/snapshot", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get(adapterHTTP.SyncCursorHeader))
+	})
+
+	t.Run("Fail: An interrupted snapshot has no end line", func(t *testing.T) {
+		r := setupSnapshotRouter(&fakeSnapshotRepo{cursor: 3, habits: []*domain.Habit{habit}, failAt: 1, err: errors.New("connection reset")})
+
+		req, _ := http.NewRequest("GET", "/api/v1/snapshot", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		for _, line := range readSnapshotLines(t, w.Body) {
+			assert.NotEqual(t, "end", line.Type)
+		}
+	})
+}
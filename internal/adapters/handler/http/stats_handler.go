@@ -44,22 +44,16 @@ func (h *StatsHandler) GetWeeklyStats(c *gin.Context) {
 		return
 	}
 
-	tzHeader := c.GetHeader("X-Timezone")
-	location := time.UTC
-	if tzHeader != "" {
-		loc, err := time.LoadLocation(tzHeader)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timezone format (use IANA name like 'Europe/Rome')"})
-			return
-		}
-		location = loc
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	endDateStr := c.Query("end_date")
 	startDateStr := c.Query("start_date")
 
 	var endDate, startDate time.Time
-	var err error
 
 	if endDateStr == "" {
 		endDate = time.Now().In(location)
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +21,10 @@ type MockHabitRepoForStats struct {
 	mock.Mock
 }
 
+func (m *MockHabitRepoForStats) ListByUserIDForUpdate(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	return m.ListByUserID(ctx, userID)
+}
+
 func (m *MockHabitRepoForStats) ListByUserID(ctx context.Context, userID string) ([]*domain.Habit, error) {
 	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
@@ -36,7 +39,7 @@ func (m *MockHabitRepoForStats) Delete(ctx context.Context, id string) error
 func (m *MockHabitRepoForStats) GetByID(ctx context.Context, id string) (*domain.Habit, error) {
 	return nil, nil
 }
-func (m *MockHabitRepoForStats) GetChanges(ctx context.Context, u string, t time.Time) ([]*domain.Habit, error) {
+func (m *MockHabitRepoForStats) GetChanges(ctx context.Context, u string, since int64, limit int) ([]*domain.Habit, error) {
 	return nil, nil
 }
 
@@ -44,13 +47,17 @@ func (m *MockHabitRepoForStats) UpdateStreaks(ctx context.Context, id string, cu
 	return nil
 }
 
+func (m *MockHabitRepoForStats) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	return nil, domain.ErrHabitVersionNotFound
+}
+
 func setupStatsRouter() (*gin.Engine, *MockHabitRepoForStats, *MockEntryRepo) {
 	gin.SetMode(gin.TestMode)
 
 	habitRepo := new(MockHabitRepoForStats)
 	entryRepo := NewMockEntryRepo()
 
-	svc := services.NewStatsService(habitRepo, entryRepo)
+	svc := services.NewStatsService(habitRepo, entryRepo, nil)
 	handler := adapterHTTP.NewStatsHandler(svc)
 
 	r := gin.New()
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+const (
+	DefaultStreamHeartbeat = 25 * time.Second
+	streamRetry            = 3 * time.Second
+)
+
+type StreamHandler struct {
+	svc       *services.SyncService
+	notifier  domain.ChangeNotifier
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(svc *services.SyncService, notifier domain.ChangeNotifier, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultStreamHeartbeat
+	}
+	return &StreamHandler{
+		svc:       svc,
+		notifier:  notifier,
+		heartbeat: heartbeat,
+	}
+}
+
+func (h *StreamHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/sync/stream", h.Stream)
+}
+
+// Stream godoc
+// @Summary      Real-time change stream (Server-Sent Events)
+// @Description  Keeps the connection open and pushes a "changes" event with the same page shape as the delta sync endpoints
+// @Description  every time a habit or entry of the user is written from any device. The event id is the sync cursor:
+// @Description  on reconnect the Last-Event-ID header (or the cursor query parameter) resumes from it, replaying missed changes first.
+// @Description  A "heartbeat" event is sent periodically; a "resync" event means the cursor expired and the client must sync from scratch.
+// @Description  With a device-bound token, the cursor the stream resumes from, and each one it moves past, is recorded as the device's acknowledged cursor.
+// @Tags         Sync
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        cursor        query  string false "Sync cursor to resume from (ignored when Last-Event-ID is set)"
+// @Param        Last-Event-ID header string false "Id of the last event received"
+// @Success      200  {string}  string "text/event-stream"
+// @Failure      400  {object}  map[string]string "Invalid Cursor"
+// @Failure      503  {object}  map[string]string "Change stream unavailable"
+// @Router       /sync/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("cursor")
+	}
+	cursor, err := parseSyncCursor(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sync cursor"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Subscribe before replaying, so that changes committed in between are
+	// signalled instead of lost.
+	signals, err := h.notifier.Subscribe(ctx, userID)
+	if err != nil {
+		log.Printf("[ERROR] Change stream subscription failed for user %s: %v", userID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "change stream unavailable"})
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	// The server write timeout is meant for regular requests and would cut
+	// the stream; dead connections are detected by failing heartbeats.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	cursor, err = h.pushChanges(c, rc, userID, cursor)
+	if err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case _, ok := <-signals:
+			if !ok {
+				return
+			}
+			cursor, err = h.pushChanges(c, rc, userID, cursor)
+			if err != nil {
+				return
+			}
+
+		case now := <-heartbeat.C:
+			err := writeStreamEvent(c.Writer, "heartbeat", "", gin.H{"time": now.UTC().Format(time.RFC3339)})
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// pushChanges sends every change after cursor, one page per event, and
+// returns the cursor of the last change sent.
+func (h *StreamHandler) pushChanges(c *gin.Context, rc *http.ResponseController, userID string, cursor int64) (int64, error) {
+	deviceID, _ := middleware.GetDeviceID(c)
+
+	for {
+		page, err := h.svc.Pull(c.Request.Context(), userID, deviceID, cursor, 0)
+		if errors.Is(err, domain.ErrSyncCursorExpired) {
+			_ = writeStreamEvent(c.Writer, "resync", "", gin.H{
+				"error":   "sync cursor expired",
+				"message": "full resync required, sync again without a cursor",
+			})
+			_ = rc.Flush()
+			return cursor, err
+		}
+		if err != nil {
+			log.Printf("[ERROR] Change stream failed for user %s: %v", userID, err)
+			return cursor, err
+		}
+
+		if len(page.Changes.Habits)+len(page.Changes.Entries) > 0 {
+			data := syncPageResponse(page.Changes, page.Cursor, page.HasMore)
+			if err := writeStreamEvent(c.Writer, "changes", formatSyncCursor(page.Cursor), data); err != nil {
+				return cursor, err
+			}
+		}
+		cursor = page.Cursor
+
+		if !page.HasMore {
+			return cursor, rc.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, event, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
@@ -0,0 +1,213 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type chanNotifier struct {
+	mu   sync.Mutex
+	subs map[string][]chan struct{}
+	err  error
+}
+
+func newChanNotifier() *chanNotifier {
+	return &chanNotifier{subs: make(map[string][]chan struct{})}
+}
+
+func (n *chanNotifier) Publish(ctx context.Context, userID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, ch := range n.subs[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (n *chanNotifier) Subscribe(ctx context.Context, userID string) (<-chan struct{}, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	n.subs[userID] = append(n.subs[userID], ch)
+	return ch, nil
+}
+
+type streamEvent struct {
+	Event string
+	ID    string
+	Data  string
+}
+
+func readStreamEvent(t *testing.T, r *bufio.Reader) streamEvent {
+	t.Helper()
+	for {
+		var ev streamEvent
+		for {
+			line, err := r.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+			if line == "" {
+				break
+			}
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "event":
+				ev.Event = value
+			case "id":
+				ev.ID = value
+			case "data":
+				ev.Data = value
+			}
+		}
+		if ev.Event != "" {
+			return ev
+		}
+	}
+}
+
+func setupStreamServer(t *testing.T, notifier domain.ChangeNotifier) (*httptest.Server, *services.HabitService, *MockRepo, *MockEntryRepo) {
+	gin.SetMode(gin.TestMode)
+
+	habitRepo := NewMockRepo()
+	entryRepo := NewMockEntryRepo()
+
+	habitSvc := services.NewHabitService(habitRepo, notifier, services.WriteOptions{})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), notifier, services.WriteOptions{})
+	syncSvc := services.NewSyncService(habitSvc, entrySvc, nil, passthroughTransactor{})
+	handler := adapterHTTP.NewStreamHandler(syncSvc, notifier, 50*time.Millisecond)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			c.Set(middleware.ContextUserIDKey, userID)
+		}
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return srv, habitSvc, habitRepo, entryRepo
+}
+
+func openStream(t *testing.T, srv *httptest.Server, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/sync/stream", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestStreamChanges(t *testing.T) {
+	t.Run("Success: Resumes from Last-Event-ID, then pushes live changes", func(t *testing.T) {
+		notifier := newChanNotifier()
+		srv, habitSvc, habitRepo, _ := setupStreamServer(t, notifier)
+		ctx := context.Background()
+
+		seen, _ := domain.NewHabit("habit-seen", "Read", "user-1")
+		habitRepo.Create(ctx, seen)
+		missed, _ := domain.NewHabit("habit-missed", "Run", "user-1")
+		habitRepo.Create(ctx, missed)
+
+		resp, r := openStream(t, srv, strconv.FormatInt(seen.ChangeSeq, 10))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		replay := readStreamEvent(t, r)
+		assert.Equal(t, "changes", replay.Event)
+		assert.Equal(t, strconv.FormatInt(missed.ChangeSeq, 10), replay.ID, "The event id is the sync cursor")
+		assert.Contains(t, replay.Data, "habit-missed")
+		assert.NotContains(t, replay.Data, "habit-seen")
+
+		live, err := habitSvc.Create(ctx, services.CreateHabitInput{ID: "habit-live", UserID: "user-1", Title: "Swim"})
+		require.NoError(t, err)
+
+		var pushed streamEvent
+		for pushed.Event != "changes" {
+			pushed = readStreamEvent(t, r)
+		}
+		assert.Equal(t, strconv.FormatInt(live.ChangeSeq, 10), pushed.ID)
+
+		var page struct {
+			Changes domain.SyncChanges `json:"changes"`
+			Cursor  string             `json:"cursor"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(pushed.Data), &page))
+		require.Len(t, page.Changes.Habits, 1)
+		assert.Equal(t, "habit-live", page.Changes.Habits[0].ID)
+		assert.Equal(t, pushed.ID, page.Cursor)
+	})
+
+	t.Run("Success: Sends heartbeats while idle", func(t *testing.T) {
+		srv, _, _, _ := setupStreamServer(t, newChanNotifier())
+
+		_, r := openStream(t, srv, "")
+
+		assert.Equal(t, "heartbeat", readStreamEvent(t, r).Event)
+	})
+
+	t.Run("Fail: Expired cursor asks for a full resync", func(t *testing.T) {
+		srv, _, _, entryRepo := setupStreamServer(t, newChanNotifier())
+		entryRepo.minValidSeq = 10
+
+		_, r := openStream(t, srv, "3")
+
+		ev := readStreamEvent(t, r)
+		assert.Equal(t, "resync", ev.Event)
+		assert.Contains(t, ev.Data, "sync cursor expired")
+	})
+
+	t.Run("Fail: 400 on invalid cursor", func(t *testing.T) {
+		srv, _, _, _ := setupStreamServer(t, newChanNotifier())
+
+		resp, _ := openStream(t, srv, "not-a-cursor")
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Fail: 503 when the change feed is unavailable", func(t *testing.T) {
+		notifier := newChanNotifier()
+		notifier.err = errors.New("redis down")
+		srv, _, _, _ := setupStreamServer(t, notifier)
+
+		resp, _ := openStream(t, srv, "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
@@ -0,0 +1,254 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+const maxSyncBatchSize = 500
+
+type SyncHandler struct {
+	svc *services.SyncService
+}
+
+func NewSyncHandler(svc *services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		svc: svc,
+	}
+}
+
+type habitChangeRequest struct {
+	Op             string   `json:"op"`
+	ID             string   `json:"id"`
+	Title          *string  `json:"title"`
+	Description    *string  `json:"description"`
+	Color          *string  `json:"color"`
+	Icon           *string  `json:"icon"`
+	Type           *string  `json:"type"`
+	ReminderTime   *string  `json:"reminder_time"`
+	Unit           *string  `json:"unit"`
+	TargetValue    *int     `json:"target_value"`
+	Interval       *int     `json:"interval"`
+	Weekdays       []int    `json:"weekdays"`
+	FrequencyType  *string  `json:"frequency_type"`
+	TimesPerPeriod *int     `json:"times_per_period"`
+	RRule          *string  `json:"rrule"`
+	ExDates        []string `json:"exdates"`
+	StartDate      *string  `json:"start_date"`
+	EndDate        *string  `json:"end_date"`
+	ArchivedAt     *string  `json:"archived_at"`
+	Version        int      `json:"version"`
+	HLC            string   `json:"hlc"`
+}
+
+type entryChangeRequest struct {
+	Op             string    `json:"op"`
+	ID             string    `json:"id"`
+	HabitID        string    `json:"habit_id"`
+	CompletionDate time.Time `json:"completion_date"`
+	Value          int       `json:"value"`
+	Notes          string    `json:"notes"`
+	Version        int       `json:"version"`
+	HLC            string    `json:"hlc"`
+}
+
+type syncRequest struct {
+	Cursor  string               `json:"cursor"`
+	Limit   int                  `json:"limit"`
+	Habits  []habitChangeRequest `json:"habits"`
+	Entries []entryChangeRequest `json:"entries"`
+}
+
+type entryBatchRequest struct {
+	Entries []entryChangeRequest `json:"entries" binding:"required"`
+}
+
+type entryBatchResponse struct {
+	Entries []domain.EntrySyncResult `json:"entries"`
+}
+
+func (h *SyncHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/sync", h.Sync)
+	router.POST("/entries/batch", h.BatchEntries)
+}
+
+// Sync godoc
+// @Summary      Bidirectional sync (Offline-First)
+// @Description  Apply a batch of local habit and entry changes in a single transaction and receive the server-side deltas since the cursor.
+// @Description  Each change reports its own status: applied, conflict (with the server copy) or rejected.
+// @Description  Deltas are paged (limit, default 500, max 1000): while has_more is true, sync again with the returned cursor.
+// @Description  With a device-bound token, the submitted cursor is recorded as the device's acknowledged cursor.
+// @Description  Changes may carry the hybrid logical clock (hlc) of the device that made them; malformed clocks or clocks too far ahead are rejected.
+// @Tags         Sync
+// @Accept       json
+// @Produce      json,application/msgpack,application/x-protobuf
+// @Security     BearerAuth
+// @Param        X-Timezone header string false "User Timezone for habit start_date and end_date (e.g. Europe/Rome). Defaults to UTC."
+// @Param        changes body syncRequest true "Local changes and last sync cursor"
+// @Success      200  {object}  domain.SyncResult
+// @Failure      400  {object}  map[string]string "Invalid Input"
+// @Failure      410  {object}  map[string]string "Cursor Expired (Full Resync Required, no changes applied)"
+// @Failure      413  {object}  map[string]string "Batch Too Large"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /sync [post]
+func (h *SyncHandler) Sync(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req syncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	if len(req.Habits)+len(req.Entries) > maxSyncBatchSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "too many changes in a single sync, split the batch"})
+		return
+	}
+
+	since, err := parseSyncCursor(req.Cursor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sync cursor"})
+		return
+	}
+
+	if req.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPageLimit.Error()})
+		return
+	}
+
+	location, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceID, _ := middleware.GetDeviceID(c)
+
+	input := services.SyncInput{
+		UserID:   userID,
+		DeviceID: deviceID,
+		Since:    since,
+		Limit:    req.Limit,
+		Habits:   make([]services.HabitChange, 0, len(req.Habits)),
+	}
+
+	for _, ch := range req.Habits {
+		input.Habits = append(input.Habits, services.HabitChange{
+			Op: ch.Op,
+			Habit: services.UpdateHabitInput{
+				ID:             ch.ID,
+				UserID:         userID,
+				Title:          ch.Title,
+				Description:    ch.Description,
+				Color:          ch.Color,
+				Icon:           ch.Icon,
+				Type:           ch.Type,
+				ReminderTime:   ch.ReminderTime,
+				Unit:           ch.Unit,
+				TargetValue:    ch.TargetValue,
+				Interval:       ch.Interval,
+				Weekdays:       ch.Weekdays,
+				FrequencyType:  ch.FrequencyType,
+				TimesPerPeriod: ch.TimesPerPeriod,
+				RRule:          ch.RRule,
+				ExDates:        ch.ExDates,
+				StartDate:      ch.StartDate,
+				EndDate:        ch.EndDate,
+				Location:       location,
+				ArchivedAt:     ch.ArchivedAt,
+				Version:        ch.Version,
+				HLC:            ch.HLC,
+			},
+		})
+	}
+
+	input.Entries = toEntryChanges(req.Entries)
+
+	result, err := h.svc.Sync(c.Request.Context(), input)
+	if errors.Is(err, domain.ErrSyncCursorExpired) {
+		respondCursorExpired(c)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Sync failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+		return
+	}
+
+	renderPayload(c, http.StatusOK, result)
+}
+
+// BatchEntries godoc
+// @Summary      Apply a batch of entry changes
+// @Description  Create, update and delete up to 500 entries in a single transaction, e.g. a day's worth of completions or an offline queue.
+// @Description  Each change reports its own status: applied, conflict (with the server copy) or rejected. Every affected habit gets a single streak recompute.
+// @Tags         Entries
+// @Accept       json
+// @Produce      json,application/msgpack,application/x-protobuf
+// @Security     BearerAuth
+// @Param        changes body entryBatchRequest true "Entry changes"
+// @Success      200  {object}  entryBatchResponse
+// @Failure      400  {object}  map[string]string "Invalid Input"
+// @Failure      413  {object}  map[string]string "Batch Too Large"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /entries/batch [post]
+func (h *SyncHandler) BatchEntries(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	var req entryBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	if len(req.Entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no entry changes in batch"})
+		return
+	}
+	if len(req.Entries) > maxSyncBatchSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "too many changes in a single batch, split the batch"})
+		return
+	}
+
+	results, err := h.svc.ApplyEntries(c.Request.Context(), userID, toEntryChanges(req.Entries))
+	if err != nil {
+		log.Printf("[ERROR] Entry batch failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "batch failed"})
+		return
+	}
+
+	renderPayload(c, http.StatusOK, entryBatchResponse{Entries: results})
+}
+
+func toEntryChanges(reqs []entryChangeRequest) []services.EntryChange {
+	changes := make([]services.EntryChange, 0, len(reqs))
+	for _, ch := range reqs {
+		changes = append(changes, services.EntryChange{
+			Op:             ch.Op,
+			ID:             ch.ID,
+			HabitID:        ch.HabitID,
+			CompletionDate: ch.CompletionDate,
+			Value:          ch.Value,
+			Notes:          ch.Notes,
+			Version:        ch.Version,
+			HLC:            ch.HLC,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,202 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type passthroughTransactor struct{}
+
+func (passthroughTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (passthroughTransactor) AfterCommit(_ context.Context, fn func()) {
+	fn()
+}
+
+func setupSyncRouter() (*gin.Engine, *MockRepo, *MockEntryRepo) {
+	gin.SetMode(gin.TestMode)
+
+	habitRepo := NewMockRepo()
+	entryRepo := NewMockEntryRepo()
+
+	habitSvc := services.NewHabitService(habitRepo, nil, services.WriteOptions{})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+	svc := services.NewSyncService(habitSvc, entrySvc, nil, passthroughTransactor{})
+	handler := adapterHTTP.NewSyncHandler(svc)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			c.Set(middleware.ContextUserIDKey, userID)
+		}
+		c.Next()
+	})
+
+	api := r.Group("/api/v1")
+	handler.RegisterRoutes(api)
+
+	return r, habitRepo, entryRepo
+}
+
+func TestSync(t *testing.T) {
+	t.Run("Success: 200 OK with per-item results", func(t *testing.T) {
+		router, habitRepo, _ := setupSyncRouter()
+
+		existing, _ := domain.NewHabit("habit-1", "Read", "user-1")
+		existing.Version = 2
+		habitRepo.Create(context.Background(), existing)
+
+		body := `{
+			"habits": [
+				{"op": "create", "id": "habit-2", "title": "Run", "start_date": "2024-01-01"},
+				{"op": "update", "id": "habit-1", "title": "Read more", "version": 1}
+			],
+			"entries": [
+				{"op": "create", "habit_id": "habit-2", "completion_date": "2024-01-10T08:00:00Z", "value": 1}
+			]
+		}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/sync", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp domain.SyncResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		require.Len(t, resp.Habits, 2)
+		assert.Equal(t, domain.SyncStatusApplied, resp.Habits[0].Status)
+		assert.Equal(t, domain.SyncStatusConflict, resp.Habits[1].Status)
+		require.NotNil(t, resp.Habits[1].Habit)
+		assert.Equal(t, "Read", resp.Habits[1].Habit.Title)
+
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, domain.SyncStatusApplied, resp.Entries[0].Status)
+
+		assert.Len(t, resp.Changes.Habits, 2)
+	})
+
+	t.Run("Fail: 400 Bad Request (Invalid Cursor)", func(t *testing.T) {
+		router, _, _ := setupSyncRouter()
+
+		req, _ := http.NewRequest("POST", "/api/v1/sync", bytes.NewBufferString(`{"cursor": "yesterday"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: 413 Batch Too Large", func(t *testing.T) {
+		router, _, _ := setupSyncRouter()
+
+		changes := make([]string, 0, 501)
+		for i := 0; i < 501; i++ {
+			changes = append(changes, fmt.Sprintf(`{"op": "delete", "id": "habit-%d"}`, i))
+		}
+		body := `{"habits": [` + strings.Join(changes, ",") + `]}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/sync", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestBatchEntries(t *testing.T) {
+	t.Run("Success: 200 OK with per-item results", func(t *testing.T) {
+		router, habitRepo, entryRepo := setupSyncRouter()
+
+		habit, _ := domain.NewHabit("habit-1", "Read", "user-1")
+		habit.StartDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		habitRepo.Create(context.Background(), habit)
+
+		body := `{"entries": [
+			{"op": "create", "habit_id": "habit-1", "completion_date": "2024-01-10T08:00:00Z", "value": 1},
+			{"op": "create", "habit_id": "habit-1", "completion_date": "2024-01-11T08:00:00Z", "value": 1},
+			{"op": "create", "habit_id": "habit-missing", "completion_date": "2024-01-11T08:00:00Z", "value": 1},
+			{"op": "delete", "id": "entry-gone"}
+		]}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/entries/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Entries []domain.EntrySyncResult `json:"entries"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		require.Len(t, resp.Entries, 4)
+		assert.Equal(t, domain.SyncStatusApplied, resp.Entries[0].Status)
+		assert.Equal(t, domain.SyncStatusApplied, resp.Entries[1].Status)
+		assert.Equal(t, domain.SyncStatusRejected, resp.Entries[2].Status)
+		assert.Equal(t, domain.SyncStatusApplied, resp.Entries[3].Status)
+		assert.Len(t, entryRepo.store, 2)
+	})
+
+	t.Run("Fail: 400 Bad Request (Empty Batch)", func(t *testing.T) {
+		router, _, _ := setupSyncRouter()
+
+		req, _ := http.NewRequest("POST", "/api/v1/entries/batch", bytes.NewBufferString(`{"entries": []}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail: 413 Batch Too Large", func(t *testing.T) {
+		router, _, _ := setupSyncRouter()
+
+		changes := make([]string, 0, 501)
+		for i := 0; i < 501; i++ {
+			changes = append(changes, fmt.Sprintf(`{"op": "delete", "id": "entry-%d"}`, i))
+		}
+		body := `{"entries": [` + strings.Join(changes, ",") + `]}`
+
+		req, _ := http.NewRequest("POST", "/api/v1/entries/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "user-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
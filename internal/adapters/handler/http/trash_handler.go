@@ -0,0 +1,92 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type TrashHandler struct {
+	svc *services.TrashService
+}
+
+func NewTrashHandler(svc *services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		svc: svc,
+	}
+}
+
+func (h *TrashHandler) RegisterRoutes(router *gin.RouterGroup) {
+	habits := router.Group("/habits")
+	{
+		habits.GET("/trash", h.List)
+		habits.POST("/:id/restore", h.Restore)
+	}
+}
+
+// List godoc
+// @Summary      List deleted habits
+// @Description  Get the user's deleted habits that can still be restored, most recently deleted first.
+// @Description  Habits leave the trash once the tombstone retention period has passed.
+// @Tags         Habits
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   domain.Habit
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/trash [get]
+func (h *TrashHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	habits, err := h.svc.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[ERROR] Listing trash failed for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deleted habits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, habits)
+}
+
+// Restore godoc
+// @Summary      Restore a deleted habit
+// @Description  Undelete a habit from the trash with a version bump. Entries deleted together with the habit are restored too,
+// @Description  and its streaks are recomputed in the background.
+// @Tags         Habits
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string true "Habit ID"
+// @Success      200  {object}  domain.Habit
+// @Failure      404  {object}  map[string]string "Habit Not In Trash"
+// @Failure      500  {object}  map[string]string "Internal Server Error"
+// @Router       /habits/{id}/restore [post]
+func (h *TrashHandler) Restore(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user context missing"})
+		return
+	}
+
+	habit, err := h.svc.Restore(c.Request.Context(), c.Param("id"), userID)
+	if errors.Is(err, domain.ErrHabitNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "habit not found in trash"})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Restoring habit %s failed for user %s: %v", c.Param("id"), userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore habit"})
+		return
+	}
+
+	c.Header("ETag", versionETag(habit.Version))
+	c.JSON(http.StatusOK, habit)
+}
@@ -0,0 +1,103 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	adapterHTTP "github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/adapters/handler/http/middleware"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakeTrashRepo struct {
+	deleted map[string]*domain.Habit
+}
+
+func (r *fakeTrashRepo) ListDeleted(ctx context.Context, userID string, deletedSince time.Time) ([]*domain.Habit, error) {
+	habits := []*domain.Habit{}
+	for _, h := range r.deleted {
+		if h.UserID == userID {
+			habits = append(habits, h)
+		}
+	}
+	return habits, nil
+}
+
+func (r *fakeTrashRepo) Restore(ctx context.Context, id, userID string, deletedSince time.Time) (*domain.Habit, error) {
+	h, ok := r.deleted[id]
+	if !ok || h.UserID != userID {
+		return nil, domain.ErrHabitNotFound
+	}
+	delete(r.deleted, id)
+	h.DeletedAt = nil
+	h.Version++
+	return h, nil
+}
+
+func setupTrashRouter(repo *fakeTrashRepo) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	trashSvc := services.NewTrashService(repo, passthroughTransactor{}, nil, getTestWorker(), nil, time.Hour)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextUserIDKey, "user-1")
+		c.Next()
+	})
+	api := r.Group("/api/v1")
+	adapterHTTP.NewHabitHandler(services.NewHabitService(NewMockRepo(), nil, services.WriteOptions{})).RegisterRoutes(api)
+	adapterHTTP.NewTrashHandler(trashSvc).RegisterRoutes(api)
+	return r
+}
+
+func TestTrash(t *testing.T) {
+	newRepo := func() *fakeTrashRepo {
+		deletedAt := time.Now().UTC()
+		return &fakeTrashRepo{deleted: map[string]*domain.Habit{
+			"habit-1": {ID: "habit-1", UserID: "user-1", Title: "Read", Version: 2, DeletedAt: &deletedAt},
+		}}
+	}
+
+	t.Run("Success: Lists deleted habits", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/habits/trash", nil)
+		setupTrashRouter(newRepo()).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var habits []domain.Habit
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &habits))
+		require.Len(t, habits, 1)
+		assert.Equal(t, "habit-1", habits[0].ID)
+	})
+
+	t.Run("Success: Restores a habit", func(t *testing.T) {
+		repo := newRepo()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/habits/habit-1/restore", nil)
+		setupTrashRouter(repo).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var habit domain.Habit
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &habit))
+		assert.Nil(t, habit.DeletedAt)
+		assert.Equal(t, 3, habit.Version)
+		assert.Empty(t, repo.deleted)
+	})
+
+	t.Run("Fail: 404 when the habit is not in the trash", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/habits/habit-missing/restore", nil)
+		setupTrashRouter(newRepo()).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -11,7 +12,18 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-var _ domain.HabitRepository = (*CachedHabitRepository)(nil)
+var (
+	_ domain.HabitRepository      = (*CachedHabitRepository)(nil)
+	_ domain.HabitTrashRepository = (*CachedHabitRepository)(nil)
+	_ domain.HabitTrashRepository = (*PostgresHabitRepository)(nil)
+	_ domain.EndedHabitRepository = (*CachedHabitRepository)(nil)
+	_ domain.EndedHabitRepository = (*PostgresHabitRepository)(nil)
+)
+
+var (
+	errTrashUnsupported = errors.New("repository: underlying habit repository has no trash")
+	errEndedUnsupported = errors.New("repository: underlying habit repository cannot list ended habits")
+)
 
 type CachedHabitRepository struct {
 	next  domain.HabitRepository
@@ -29,10 +41,16 @@ func (r *CachedHabitRepository) cacheKey(userID string) string {
 	return fmt.Sprintf("habits:%s", userID)
 }
 
+// invalidate drops the user's cached list once the write is visible to
+// other readers: after the enclosing transaction commits, or right away
+// outside of one. Dropping it earlier would let a concurrent list cache the
+// rows as they were before the commit.
 func (r *CachedHabitRepository) invalidate(ctx context.Context, userID string) {
-	if err := r.cache.Del(ctx, r.cacheKey(userID)).Err(); err != nil {
-		log.Printf("[CACHE] Failed to invalidate for user %s: %v", userID, err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Del(ctx, r.cacheKey(userID)).Err(); err != nil {
+			log.Printf("[CACHE] Failed to invalidate for user %s: %v", userID, err)
+		}
+	})
 }
 
 func (r *CachedHabitRepository) ListByUserID(ctx context.Context, userID string) ([]*domain.Habit, error) {
@@ -65,12 +83,22 @@ func (r *CachedHabitRepository) ListByUserID(ctx context.Context, userID string)
 	return habits, nil
 }
 
+// ListByUserIDForUpdate is never served from the cache: the rows have to be
+// read, and locked, within the caller's transaction.
+func (r *CachedHabitRepository) ListByUserIDForUpdate(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	return r.next.ListByUserIDForUpdate(ctx, userID)
+}
+
 func (r *CachedHabitRepository) GetByID(ctx context.Context, id string) (*domain.Habit, error) {
 	return r.next.GetByID(ctx, id)
 }
 
-func (r *CachedHabitRepository) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.Habit, error) {
-	return r.next.GetChanges(ctx, userID, since)
+func (r *CachedHabitRepository) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	return r.next.GetVersion(ctx, id, version)
+}
+
+func (r *CachedHabitRepository) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, error) {
+	return r.next.GetChanges(ctx, userID, since, limit)
 }
 
 func (r *CachedHabitRepository) Create(ctx context.Context, habit *domain.Habit) error {
@@ -106,3 +134,33 @@ func (r *CachedHabitRepository) UpdateStreaks(ctx context.Context, id string, cu
 
 	return r.next.UpdateStreaks(ctx, id, current, longest)
 }
+
+func (r *CachedHabitRepository) ListDeleted(ctx context.Context, userID string, deletedSince time.Time) ([]*domain.Habit, error) {
+	trash, ok := r.next.(domain.HabitTrashRepository)
+	if !ok {
+		return nil, errTrashUnsupported
+	}
+	return trash.ListDeleted(ctx, userID, deletedSince)
+}
+
+func (r *CachedHabitRepository) Restore(ctx context.Context, id, userID string, deletedSince time.Time) (*domain.Habit, error) {
+	trash, ok := r.next.(domain.HabitTrashRepository)
+	if !ok {
+		return nil, errTrashUnsupported
+	}
+
+	habit, err := trash.Restore(ctx, id, userID, deletedSince)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, userID)
+	return habit, nil
+}
+
+func (r *CachedHabitRepository) ListEnded(ctx context.Context, endedBefore time.Time, limit int) ([]*domain.Habit, error) {
+	ended, ok := r.next.(domain.EndedHabitRepository)
+	if !ok {
+		return nil, errEndedUnsupported
+	}
+	return ended.ListEnded(ctx, endedBefore, limit)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+var _ domain.ChecksumRepository = (*PostgresChecksumRepository)(nil)
+
+type PostgresChecksumRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresChecksumRepository(db *sqlx.DB) *PostgresChecksumRepository {
+	return &PostgresChecksumRepository{db: db}
+}
+
+// ChecksumItems reads the cursor and the rows from one read-only REPEATABLE
+// READ transaction, like a snapshot, so the digest matches exactly the state
+// a client holds once it has synced up to the cursor.
+func (r *PostgresChecksumRepository) ChecksumItems(ctx context.Context, userID string) (int64, []domain.ChecksumItem, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, nil, fmt.Errorf("repository: begin checksum failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var cursor int64
+	err = tx.GetContext(ctx, &cursor, `
+        SELECT COALESCE((SELECT last_seq FROM user_sync_state WHERE user_id = $1), 0)`, userID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("repository: read checksum cursor failed: %w", err)
+	}
+
+	items := []domain.ChecksumItem{}
+	err = tx.SelectContext(ctx, &items, `
+        SELECT 'habit' AS kind, id, version, deleted_at, created_at AS date
+        FROM habits WHERE user_id = $1
+        UNION ALL
+        SELECT 'entry' AS kind, id, version, deleted_at, completion_date AS date
+        FROM habit_entries WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("repository: read checksum items failed: %w", err)
+	}
+
+	return cursor, items, tx.Commit()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestPostgresChecksumRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	habitRepo := NewPostgresHabitRepository(db)
+	entryRepo := NewPostgresEntryRepository(db)
+	repo := NewPostgresChecksumRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "checksum-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'checksum@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	habit := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Read", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, habitRepo.Create(ctx, habit))
+
+	live := domain.NewHabitEntry(habit.ID, userID, now.AddDate(0, -1, 0), 1)
+	require.NoError(t, entryRepo.Create(ctx, live))
+	gone := domain.NewHabitEntry(habit.ID, userID, now, 1)
+	require.NoError(t, entryRepo.Create(ctx, gone))
+	require.NoError(t, entryRepo.Delete(ctx, gone.ID, userID))
+
+	cursor, items, err := repo.ChecksumItems(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, items, 3, "Tombstones are part of the checksum")
+
+	byID := make(map[string]domain.ChecksumItem)
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	assert.Equal(t, domain.ChecksumKindHabit, byID[habit.ID].Kind)
+	assert.Equal(t, habit.CreatedAt.Format("2006-01"), byID[habit.ID].Month())
+	assert.Equal(t, domain.ChecksumKindEntry, byID[live.ID].Kind)
+	assert.Equal(t, live.CompletionDate.Format("2006-01"), byID[live.ID].Month())
+	assert.Nil(t, byID[live.ID].DeletedAt)
+	assert.NotNil(t, byID[gone.ID].DeletedAt)
+
+	changes, err := entryRepo.GetChanges(ctx, userID, cursor, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "The digest is current as of the cursor")
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type PostgresDeviceRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresDeviceRepository(db *sqlx.DB) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+const deviceColumns = `id, user_id, name, platform, last_cursor, last_synced_at, last_seen_at, created_at, revoked_at`
+
+func (r *PostgresDeviceRepository) Create(ctx context.Context, device *domain.Device) error {
+	query := `
+		INSERT INTO devices (` + deviceColumns + `)
+		VALUES (:id, :user_id, :name, :platform, :last_cursor, :last_synced_at, :last_seen_at, :created_at, :revoked_at)`
+
+	if _, err := executor(ctx, r.db).NamedExecContext(ctx, query, device); err != nil {
+		return fmt.Errorf("repository: create device failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresDeviceRepository) GetByID(ctx context.Context, id string) (*domain.Device, error) {
+	var device domain.Device
+	err := executor(ctx, r.db).GetContext(ctx, &device,
+		`SELECT `+deviceColumns+` FROM devices WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrDeviceNotFound
+		}
+		return nil, fmt.Errorf("repository: get device failed: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *PostgresDeviceRepository) ListByUserID(ctx context.Context, userID string) ([]*domain.Device, error) {
+	devices := []*domain.Device{}
+	err := executor(ctx, r.db).SelectContext(ctx, &devices,
+		`SELECT `+deviceColumns+` FROM devices WHERE user_id = $1 ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list devices failed: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *PostgresDeviceRepository) Update(ctx context.Context, device *domain.Device) error {
+	query := `
+		UPDATE devices
+		SET name = :name, platform = :platform, last_seen_at = :last_seen_at, revoked_at = :revoked_at
+		WHERE id = :id`
+
+	res, err := executor(ctx, r.db).NamedExecContext(ctx, query, device)
+	if err != nil {
+		return fmt.Errorf("repository: update device failed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (r *PostgresDeviceRepository) AcknowledgeCursor(ctx context.Context, id string, cursor int64, at time.Time) error {
+	query := `
+		UPDATE devices
+		SET last_cursor = GREATEST(last_cursor, $2), last_synced_at = $3, last_seen_at = $3
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	res, err := executor(ctx, r.db).ExecContext(ctx, query, id, cursor, at)
+	if err != nil {
+		return fmt.Errorf("repository: acknowledge device cursor failed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrDeviceNotFound
+	}
+	return nil
+}
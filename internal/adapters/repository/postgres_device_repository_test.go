@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestPostgresDeviceRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	repo := NewPostgresDeviceRepository(db)
+	habitRepo := NewPostgresHabitRepository(db)
+	tombstones := NewPostgresTombstoneRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "device-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'device@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	phone, err := domain.NewDevice("phone", userID, "Phone", "ios")
+	require.NoError(t, err)
+	laptop, err := domain.NewDevice("laptop", userID, "Laptop", "web")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, phone))
+	require.NoError(t, repo.Create(ctx, laptop))
+
+	t.Run("Create and Get", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "phone")
+		require.NoError(t, err)
+		assert.Equal(t, "Phone", got.Name)
+		assert.Nil(t, got.LastSyncedAt)
+
+		_, err = repo.GetByID(ctx, "missing")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+
+		list, err := repo.ListByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.Len(t, list, 2)
+	})
+
+	t.Run("Acknowledged cursors only move forward", func(t *testing.T) {
+		require.NoError(t, repo.AcknowledgeCursor(ctx, "phone", 10, now))
+		require.NoError(t, repo.AcknowledgeCursor(ctx, "phone", 4, now))
+
+		got, err := repo.GetByID(ctx, "phone")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), got.LastCursor)
+		require.NotNil(t, got.LastSyncedAt)
+	})
+
+	t.Run("Expired tombstones wait for every active device", func(t *testing.T) {
+		habit := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Gone", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, habitRepo.Create(ctx, habit))
+		require.NoError(t, habitRepo.Delete(ctx, habit.ID))
+
+		var seq int64
+		require.NoError(t, db.Get(&seq, `SELECT change_seq FROM habits WHERE id = $1`, habit.ID))
+
+		cutoff := now.Add(-24 * time.Hour)
+		require.NoError(t, repo.AcknowledgeCursor(ctx, "phone", seq, now))
+
+		purged, err := tombstones.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Zero(t, purged, "Acknowledged tombstones are kept for the retention period")
+
+		_, err = db.Exec(`UPDATE habits SET deleted_at = $1 WHERE id = $2`, now.Add(-48*time.Hour), habit.ID)
+		require.NoError(t, err)
+
+		purged, err = tombstones.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Zero(t, purged, "The laptop has not seen the deletion yet")
+
+		laptop.RevokedAt = &now
+		require.NoError(t, repo.Update(ctx, laptop))
+
+		purged, err = tombstones.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged, "Revoked devices do not hold tombstones back")
+	})
+
+	t.Run("Revoked devices cannot acknowledge", func(t *testing.T) {
+		err := repo.AcknowledgeCursor(ctx, "laptop", 99, now)
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}
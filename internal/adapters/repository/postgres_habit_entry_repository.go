@@ -31,15 +31,27 @@ func (r *PostgresEntryRepository) Create(ctx context.Context, entry *domain.Habi
         INSERT INTO habit_entries (
             id, habit_id, user_id, 
             completion_date, value, notes, 
-            version, created_at, updated_at, deleted_at
+            version, hlc, created_at, updated_at, deleted_at
         ) VALUES (
             :id, :habit_id, :user_id, 
             :completion_date, :value, :notes, 
-            :version, :created_at, :updated_at, :deleted_at
-        )`
+            :version, :hlc, :created_at, :updated_at, :deleted_at
+        )
+        ON CONFLICT (id) DO NOTHING
+        RETURNING change_seq`
 
-	_, err := r.db.NamedExecContext(ctx, query, entry)
+	db := executor(ctx, r.db)
+
+	query, args, err := db.BindNamed(query, entry)
+	if err != nil {
+		return err
+	}
+
+	err = db.QueryRowContext(ctx, query, args...).Scan(&entry.ChangeSeq)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrEntryConflict
+		}
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23503" {
 				return errors.New("referenced habit or user does not exist")
@@ -57,7 +69,7 @@ func (r *PostgresEntryRepository) GetByID(ctx context.Context, id string) (*doma
 	var entry domain.HabitEntry
 	query := `SELECT * FROM habit_entries WHERE id = $1 AND deleted_at IS NULL`
 
-	err := r.db.GetContext(ctx, &entry, query, id)
+	err := executor(ctx, r.db).GetContext(ctx, &entry, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrEntryNotFound
@@ -76,7 +88,7 @@ func (r *PostgresEntryRepository) ListByHabitID(ctx context.Context, habitID str
           AND deleted_at IS NULL
         ORDER BY completion_date DESC`
 
-	err := r.db.SelectContext(ctx, &entries, query, habitID)
+	err := executor(ctx, r.db).SelectContext(ctx, &entries, query, habitID)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +106,7 @@ func (r *PostgresEntryRepository) ListByHabitIDWithRange(ctx context.Context, ha
           AND deleted_at IS NULL
         ORDER BY completion_date DESC`
 
-	err := r.db.SelectContext(ctx, &entries, query, habitID, from, to)
+	err := executor(ctx, r.db).SelectContext(ctx, &entries, query, habitID, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -108,28 +120,31 @@ func (r *PostgresEntryRepository) Update(ctx context.Context, entry *domain.Habi
         SET value = :value,
             notes = :notes,
             completion_date = :completion_date,
+            hlc = :hlc,
             version = :version,        -- Salva la versione NUOVA (calcolata dal service)
             updated_at = :updated_at
         WHERE id = :id 
           AND version = :version - 1  -- Verifica che sul DB ci sia la versione VECCHIA
-          AND deleted_at IS NULL`
+          AND deleted_at IS NULL
+        RETURNING change_seq`
 
-	result, err := r.db.NamedExecContext(ctx, query, entry)
-	if err != nil {
-		return err
-	}
+	db := executor(ctx, r.db)
 
-	rows, err := result.RowsAffected()
+	query, args, err := db.BindNamed(query, entry)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		exists, _ := r.exists(ctx, entry.ID)
-		if !exists {
-			return domain.ErrEntryNotFound
+	err = db.QueryRowContext(ctx, query, args...).Scan(&entry.ChangeSeq)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			exists, _ := r.exists(ctx, entry.ID)
+			if !exists {
+				return domain.ErrEntryNotFound
+			}
+			return domain.ErrEntryConflict
 		}
-		return domain.ErrEntryConflict
+		return err
 	}
 
 	return nil
@@ -147,7 +162,7 @@ func (r *PostgresEntryRepository) Delete(ctx context.Context, id string, userID
           AND user_id = $3 -- Security Check
           AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, now, id, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now, id, userID)
 	if err != nil {
 		return err
 	}
@@ -163,25 +178,65 @@ func (r *PostgresEntryRepository) Delete(ctx context.Context, id string, userID
 	return nil
 }
 
-func (r *PostgresEntryRepository) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.HabitEntry, error) {
+func (r *PostgresEntryRepository) Resurrect(ctx context.Context, entry *domain.HabitEntry) error {
+	query := `
+        UPDATE habit_entries
+        SET habit_id = :habit_id,
+            completion_date = :completion_date,
+            value = :value,
+            notes = :notes,
+            hlc = :hlc,
+            version = version + 1,
+            updated_at = :updated_at,
+            deleted_at = NULL
+        WHERE id = :id
+          AND user_id = :user_id
+          AND deleted_at IS NOT NULL
+        RETURNING version, change_seq, created_at`
+
+	db := executor(ctx, r.db)
+
+	query, args, err := db.BindNamed(query, entry)
+	if err != nil {
+		return err
+	}
+
+	err = db.QueryRowContext(ctx, query, args...).Scan(&entry.Version, &entry.ChangeSeq, &entry.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrEntryNotFound
+		}
+		return err
+	}
+
+	entry.DeletedAt = nil
+	return nil
+}
+
+func (r *PostgresEntryRepository) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.HabitEntry, error) {
 	entries := []*domain.HabitEntry{}
 
 	query := `
         SELECT * FROM habit_entries 
         WHERE user_id = $1 
-          AND updated_at > $2
-        ORDER BY updated_at ASC`
+          AND change_seq > $2
+        ORDER BY change_seq ASC
+        LIMIT $3`
 
-	err := r.db.SelectContext(ctx, &entries, query, userID, since)
+	err := executor(ctx, r.db).SelectContext(ctx, &entries, query, userID, since, limit)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := checkSyncCursor(ctx, r.db, userID, since); err != nil {
+		return nil, err
+	}
 	return entries, nil
 }
 
 func (r *PostgresEntryRepository) exists(ctx context.Context, id string) (bool, error) {
 	var count int
-	err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM habit_entries WHERE id = $1", id)
+	err := executor(ctx, r.db).GetContext(ctx, &count, "SELECT count(*) FROM habit_entries WHERE id = $1", id)
 	return count > 0, err
 }
 
@@ -190,7 +245,7 @@ func (r *PostgresEntryRepository) ListByUserIDAndDateRange(ctx context.Context,
 		SELECT 
 			id, habit_id, user_id, value, notes, 
 			completion_date, created_at, updated_at, 
-			deleted_at, version
+			deleted_at, version, change_seq, hlc
 		FROM habit_entries
 		WHERE user_id = $1 
 		AND completion_date >= $2 
@@ -200,7 +255,7 @@ func (r *PostgresEntryRepository) ListByUserIDAndDateRange(ctx context.Context,
 	`
 
 	var entries []domain.HabitEntry
-	err := r.db.SelectContext(ctx, &entries, query, userID, startDate, endDate)
+	err := executor(ctx, r.db).SelectContext(ctx, &entries, query, userID, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("repository: list entries by date range failed: %w", err)
 	}
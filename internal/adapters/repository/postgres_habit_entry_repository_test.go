@@ -31,7 +31,7 @@ func setupTest(t *testing.T) (*PostgresEntryRepository, *sqlx.DB, func()) {
 		t.Skipf("Database connection failed (skipping integration tests): %v", err)
 	}
 
-	db.MustExec("TRUNCATE TABLE habit_entries, habits, users CASCADE")
+	db.MustExec("TRUNCATE TABLE habit_entries, habits, user_sync_state, users CASCADE")
 
 	repo := NewPostgresEntryRepository(db)
 
@@ -105,6 +105,30 @@ func TestPostgresEntryRepository_Integration(t *testing.T) {
 		assert.True(t, exists, "Record must remain physically in DB with deleted_at for sync purposes")
 	})
 
+	t.Run("Resurrect: Deleted entries come back with the new data", func(t *testing.T) {
+		entry := domain.NewHabitEntry(hid, uid, now, 1)
+		entry.ID = uuid.NewString()
+		require.NoError(t, repo.Create(ctx, entry))
+		require.NoError(t, repo.Delete(ctx, entry.ID, uid))
+
+		assert.ErrorIs(t, repo.Create(ctx, entry), domain.ErrEntryConflict, "The ID is still taken by the tombstone")
+
+		revived := domain.NewHabitEntry(hid, "someone-else", now, 7)
+		revived.ID = entry.ID
+		assert.ErrorIs(t, repo.Resurrect(ctx, revived), domain.ErrEntryNotFound, "Only the owner can resurrect")
+
+		revived.UserID = uid
+		require.NoError(t, repo.Resurrect(ctx, revived))
+		assert.Equal(t, 3, revived.Version)
+		assert.Greater(t, revived.ChangeSeq, entry.ChangeSeq)
+
+		fetched, err := repo.GetByID(ctx, entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 7, fetched.Value)
+
+		assert.ErrorIs(t, repo.Resurrect(ctx, revived), domain.ErrEntryNotFound, "Live entries are not resurrected")
+	})
+
 	t.Run("Optimistic Locking: Version Conflict", func(t *testing.T) {
 		entryID := uuid.NewString()
 		e := domain.NewHabitEntry(hid, uid, now, 10)
@@ -158,15 +182,16 @@ func TestPostgresEntryRepository_Integration(t *testing.T) {
 	})
 
 	t.Run("Sync Engine: GetChanges Delta", func(t *testing.T) {
-		checkpoint := time.Now().UTC()
-		time.Sleep(10 * time.Millisecond)
+		var checkpoint int64
+		err := db.Get(&checkpoint, "SELECT last_seq FROM user_sync_state WHERE user_id = $1", uid)
+		require.NoError(t, err)
 
 		e := domain.NewHabitEntry(hid, uid, now, 888)
 		e.ID = uuid.NewString()
-		e.UpdatedAt = time.Now().UTC()
-		repo.Create(ctx, e)
+		require.NoError(t, repo.Create(ctx, e))
+		assert.Greater(t, e.ChangeSeq, checkpoint)
 
-		changes, err := repo.GetChanges(ctx, uid, checkpoint)
+		changes, err := repo.GetChanges(ctx, uid, checkpoint, 100)
 		assert.NoError(t, err)
 
 		require.GreaterOrEqual(t, len(changes), 1)
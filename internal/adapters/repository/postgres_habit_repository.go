@@ -28,7 +28,7 @@ type scannable interface {
 
 func (r *PostgresHabitRepository) scanRow(row scannable) (*domain.Habit, error) {
 	var h domain.Habit
-	var weekdaysJSON []byte
+	var weekdaysJSON, exdatesJSON []byte
 
 	err := row.Scan(
 		&h.ID,
@@ -41,6 +41,9 @@ func (r *PostgresHabitRepository) scanRow(row scannable) (*domain.Habit, error)
 		&h.Type,
 		&h.FrequencyType,
 		&weekdaysJSON,
+		&h.TimesPerPeriod,
+		&h.RRule,
+		&exdatesJSON,
 		&h.ReminderTime,
 		&h.Interval,
 		&h.TargetValue,
@@ -48,9 +51,12 @@ func (r *PostgresHabitRepository) scanRow(row scannable) (*domain.Habit, error)
 		&h.CurrentStreak,
 		&h.LongestStreak,
 		&h.StartDate,
+		&h.StartDateSet,
 		&h.EndDate,
 		&h.ArchivedAt,
 		&h.Version,
+		&h.ChangeSeq,
+		&h.HLC,
 		&h.DeletedAt,
 		&h.CreatedAt,
 		&h.UpdatedAt,
@@ -64,17 +70,22 @@ func (r *PostgresHabitRepository) scanRow(row scannable) (*domain.Habit, error)
 			return nil, fmt.Errorf("failed to unmarshal weekdays: %w", err)
 		}
 	}
+	if len(exdatesJSON) > 0 {
+		if err := json.Unmarshal(exdatesJSON, &h.ExDates); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal exdates: %w", err)
+		}
+	}
 
 	return &h, nil
 }
 
 const selectColumns = `
 	id, user_id, title, description, color, icon, sort_order,
-	type, frequency_type, weekdays, reminder_time,
+	type, frequency_type, weekdays, times_per_period, COALESCE(rrule, '') AS rrule, exdates, reminder_time,
 	interval, target_value, unit,
 	current_streak, longest_streak,
-	start_date, end_date, archived_at,
-	version, deleted_at, created_at, updated_at
+	start_date, start_date_set, end_date, archived_at,
+	version, change_seq, COALESCE(hlc, '') AS hlc, deleted_at, created_at, updated_at
 `
 
 func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) error {
@@ -82,6 +93,10 @@ func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) e
 	if err != nil {
 		return fmt.Errorf("failed to marshal weekdays: %w", err)
 	}
+	exdatesJSON, err := json.Marshal(h.ExDates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exdates: %w", err)
+	}
 
 	query := `
         INSERT INTO habits (
@@ -92,7 +107,8 @@ func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) e
             current_streak, longest_streak,
 
             start_date, end_date, archived_at,
-            version, deleted_at, created_at, updated_at
+            version, hlc, deleted_at, created_at, updated_at,
+            times_per_period, rrule, exdates, start_date_set
         ) VALUES (
             $1, $2, $3, $4, $5, $6, $7,
             $8, $9, $10, $11,
@@ -101,10 +117,13 @@ func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) e
             $15, $16,
 
             $17, $18, $19,
-            1, NULL, $20, $21
-        )`
+            1, $22, NULL, $20, $21,
+            $23, $24, $25, $26
+        )
+        ON CONFLICT (id) DO NOTHING
+        RETURNING change_seq`
 
-	_, err = r.db.ExecContext(ctx, query,
+	row := executor(ctx, r.db).QueryRowContext(ctx, query,
 		h.ID, h.UserID, h.Title, h.Description, h.Color, h.Icon, h.SortOrder,
 		h.Type, h.FrequencyType, weekdaysJSON, h.ReminderTime,
 		h.Interval, h.TargetValue, h.Unit,
@@ -113,9 +132,16 @@ func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) e
 
 		h.StartDate, h.EndDate, h.ArchivedAt,
 		h.CreatedAt, h.UpdatedAt,
+		h.HLC,
+		h.TimesPerPeriod, h.RRule, exdatesJSON, h.StartDateSet,
 	)
 
-	if err != nil {
+	// A duplicate ID returns no row instead of raising a SQL error, so that
+	// an enclosing transaction is not aborted by the failed insert.
+	if err := row.Scan(&h.ChangeSeq); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrHabitConflict
+		}
 		return fmt.Errorf("failed to insert habit: %w", err)
 	}
 
@@ -126,7 +152,7 @@ func (r *PostgresHabitRepository) Create(ctx context.Context, h *domain.Habit) e
 func (r *PostgresHabitRepository) GetByID(ctx context.Context, id string) (*domain.Habit, error) {
 	query := fmt.Sprintf(`SELECT %s FROM habits WHERE id = $1 AND deleted_at IS NULL`, selectColumns)
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := executor(ctx, r.db).QueryRowContext(ctx, query, id)
 
 	h, err := r.scanRow(row)
 	if err != nil {
@@ -140,12 +166,23 @@ func (r *PostgresHabitRepository) GetByID(ctx context.Context, id string) (*doma
 }
 
 func (r *PostgresHabitRepository) ListByUserID(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	return r.listByUserID(ctx, userID, "")
+}
+
+// ListByUserIDForUpdate locks the rows it returns with FOR UPDATE, so that
+// they cannot change under a transaction that rewrites several of them.
+func (r *PostgresHabitRepository) ListByUserIDForUpdate(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	return r.listByUserID(ctx, userID, "FOR UPDATE")
+}
+
+func (r *PostgresHabitRepository) listByUserID(ctx context.Context, userID, lock string) ([]*domain.Habit, error) {
 	query := fmt.Sprintf(`
         SELECT %s FROM habits 
         WHERE user_id = $1 AND deleted_at IS NULL 
-        ORDER BY sort_order ASC, created_at DESC`, selectColumns)
+        ORDER BY sort_order ASC, created_at DESC
+        %s`, selectColumns, lock)
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
@@ -169,6 +206,10 @@ func (r *PostgresHabitRepository) Update(ctx context.Context, h *domain.Habit) e
 	if err != nil {
 		return err
 	}
+	exdatesJSON, err := json.Marshal(h.ExDates)
+	if err != nil {
+		return err
+	}
 
 	query := `
         UPDATE habits SET 
@@ -180,12 +221,15 @@ func (r *PostgresHabitRepository) Update(ctx context.Context, h *domain.Habit) e
 
             end_date=$15, archived_at=$16,
             deleted_at=$19,
+            hlc=$20,
+            times_per_period=$21, rrule=$22, exdates=$23,
+            start_date=$24, start_date_set=$25,
             updated_at=NOW(), 
             version = $18
         WHERE id=$17 AND version = $18 - 1
-        RETURNING version, updated_at`
+        RETURNING version, change_seq, updated_at`
 
-	row := r.db.QueryRowContext(ctx, query,
+	row := executor(ctx, r.db).QueryRowContext(ctx, query,
 		h.Title, h.Description, h.Color, h.Icon, h.SortOrder,
 		h.Type, h.FrequencyType, weekdaysJSON, h.ReminderTime,
 		h.Interval, h.TargetValue, h.Unit,
@@ -195,17 +239,22 @@ func (r *PostgresHabitRepository) Update(ctx context.Context, h *domain.Habit) e
 		h.EndDate, h.ArchivedAt,
 		h.ID, h.Version,
 		h.DeletedAt,
+		h.HLC,
+		h.TimesPerPeriod, h.RRule, exdatesJSON,
+		h.StartDate, h.StartDateSet,
 	)
 
 	var newVersion int
 	var newUpdatedAt time.Time
 
-	err = row.Scan(&newVersion, &newUpdatedAt)
+	var newChangeSeq int64
+
+	err = row.Scan(&newVersion, &newChangeSeq, &newUpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			existsQuery := `SELECT count(*) FROM habits WHERE id = $1`
 			var count int
-			_ = r.db.QueryRowContext(ctx, existsQuery, h.ID).Scan(&count)
+			_ = executor(ctx, r.db).QueryRowContext(ctx, existsQuery, h.ID).Scan(&count)
 
 			if count == 0 {
 				return domain.ErrHabitNotFound
@@ -216,6 +265,7 @@ func (r *PostgresHabitRepository) Update(ctx context.Context, h *domain.Habit) e
 	}
 
 	h.Version = newVersion
+	h.ChangeSeq = newChangeSeq
 	h.UpdatedAt = newUpdatedAt
 
 	return nil
@@ -227,7 +277,7 @@ func (r *PostgresHabitRepository) Delete(ctx context.Context, id string) error {
         SET deleted_at = NOW(), updated_at = NOW(), version = version + 1
         WHERE id = $1 AND deleted_at IS NULL`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("delete query failed: %w", err)
 	}
@@ -243,13 +293,93 @@ func (r *PostgresHabitRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *PostgresHabitRepository) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.Habit, error) {
+func (r *PostgresHabitRepository) ListDeleted(ctx context.Context, userID string, deletedSince time.Time) ([]*domain.Habit, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM habits
+        WHERE user_id = $1 AND deleted_at IS NOT NULL AND deleted_at >= $2
+        ORDER BY deleted_at DESC`, selectColumns)
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, deletedSince)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	habits := []*domain.Habit{}
+
+	for rows.Next() {
+		h, err := r.scanRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		habits = append(habits, h)
+	}
+
+	return habits, rows.Err()
+}
+
+func (r *PostgresHabitRepository) ListEnded(ctx context.Context, endedBefore time.Time, limit int) ([]*domain.Habit, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM habits
+        WHERE end_date < $1 AND archived_at IS NULL AND deleted_at IS NULL
+        ORDER BY end_date
+        LIMIT $2`, selectColumns)
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, endedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	habits := []*domain.Habit{}
+
+	for rows.Next() {
+		h, err := r.scanRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		habits = append(habits, h)
+	}
+
+	return habits, rows.Err()
+}
+
+// Restore clears deleted_at with a version bump; the
+// cascade_habits_soft_delete trigger brings back the entries deleted with the
+// habit in the same statement. The new change sequences let devices pick the
+// restore up through delta sync.
+func (r *PostgresHabitRepository) Restore(ctx context.Context, id, userID string, deletedSince time.Time) (*domain.Habit, error) {
+	query := `
+        UPDATE habits
+        SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+        WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL AND deleted_at >= $3`
+
+	res, err := executor(ctx, r.db).ExecContext(ctx, query, id, userID, deletedSince)
+	if err != nil {
+		return nil, fmt.Errorf("restore query failed: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, domain.ErrHabitNotFound
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetChanges pages through the deltas using change_seq as the keyset: it is
+// unique per user, so a page boundary can never split or repeat a row.
+func (r *PostgresHabitRepository) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, error) {
 	query := fmt.Sprintf(`
         SELECT %s FROM habits 
-        WHERE user_id = $1 AND updated_at > $2
-        ORDER BY updated_at ASC`, selectColumns)
+        WHERE user_id = $1 AND change_seq > $2
+        ORDER BY change_seq ASC
+        LIMIT $3`, selectColumns)
 
-	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID, since, limit)
 	if err != nil {
 		return nil, fmt.Errorf("sync query error: %w", err)
 	}
@@ -265,6 +395,10 @@ func (r *PostgresHabitRepository) GetChanges(ctx context.Context, userID string,
 		habits = append(habits, h)
 	}
 
+	if err := checkSyncCursor(ctx, r.db, userID, since); err != nil {
+		return nil, err
+	}
+
 	return habits, nil
 }
 
@@ -278,7 +412,7 @@ func (r *PostgresHabitRepository) UpdateStreaks(ctx context.Context, id string,
         WHERE id = $3
     `
 
-	result, err := r.db.ExecContext(ctx, query, current, longest, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, current, longest, id)
 	if err != nil {
 		return fmt.Errorf("failed to update streaks: %w", err)
 	}
@@ -294,3 +428,26 @@ func (r *PostgresHabitRepository) UpdateStreaks(ctx context.Context, id string,
 
 	return nil
 }
+
+// GetVersion rebuilds a past version from the snapshot recorded by the
+// record_habit_version trigger.
+func (r *PostgresHabitRepository) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM (
+            SELECT (jsonb_populate_record(NULL::habits, snapshot)).*
+            FROM habit_versions
+            WHERE habit_id = $1 AND version = $2
+        ) h`, selectColumns)
+
+	row := executor(ctx, r.db).QueryRowContext(ctx, query, id, version)
+
+	h, err := r.scanRow(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrHabitVersionNotFound
+		}
+		return nil, fmt.Errorf("history scan error: %w", err)
+	}
+
+	return h, nil
+}
@@ -46,7 +46,7 @@ func setupTestDB(t *testing.T) *sqlx.DB {
 		t.Skipf("Skipping integration tests: database connection failed: %v", err)
 	}
 
-	_, err = db.Exec("DROP TABLE IF EXISTS habit_entries, habits, users CASCADE")
+	_, err = db.Exec("DROP TABLE IF EXISTS habit_pauses, operations, habit_entries, habit_versions, habits, devices, user_sync_state, users CASCADE")
 	require.NoError(t, err)
 
 	schema := `
@@ -58,9 +58,38 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL
     );
 
-    CREATE TABLE habits (
+    CREATE TABLE devices (
         id TEXT PRIMARY KEY,
         user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        name TEXT NOT NULL DEFAULT '',
+        platform TEXT NOT NULL DEFAULT '',
+        last_cursor BIGINT NOT NULL DEFAULT 0,
+        last_synced_at TIMESTAMP WITH TIME ZONE,
+        last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        revoked_at TIMESTAMP WITH TIME ZONE
+    );
+
+    CREATE TABLE user_sync_state (
+        user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+        last_seq BIGINT NOT NULL DEFAULT 0,
+        min_valid_seq BIGINT NOT NULL DEFAULT 0
+    );
+
+    CREATE OR REPLACE FUNCTION assign_change_seq()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        INSERT INTO user_sync_state (user_id, last_seq)
+        VALUES (NEW.user_id, 1)
+        ON CONFLICT (user_id) DO UPDATE SET last_seq = user_sync_state.last_seq + 1
+        RETURNING last_seq INTO NEW.change_seq;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TABLE habits (
+        id UUID PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
         title TEXT NOT NULL,
         description TEXT,
         color TEXT,
@@ -75,6 +104,9 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         
         weekdays TEXT, -- JSON
         frequency_type TEXT,
+        times_per_period INTEGER NOT NULL DEFAULT 0,
+        rrule TEXT NOT NULL DEFAULT '',
+        exdates TEXT,
         
         start_date TIMESTAMP WITH TIME ZONE,
         end_date TIMESTAMP WITH TIME ZONE,
@@ -84,6 +116,8 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
         deleted_at TIMESTAMP WITH TIME ZONE,
         version INTEGER DEFAULT 1,
+        change_seq BIGINT NOT NULL DEFAULT 0,
+        hlc TEXT NOT NULL DEFAULT '',
         sort_order INTEGER DEFAULT 0,
         
         -- CONSTRAINTS CRITICI PER I TEST
@@ -92,8 +126,8 @@ func setupTestDB(t *testing.T) *sqlx.DB {
     );
 
     CREATE TABLE habit_entries (
-        id TEXT PRIMARY KEY,
-        habit_id TEXT NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+        id UUID PRIMARY KEY,
+        habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
         user_id TEXT NOT NULL,
         value INTEGER NOT NULL,
         notes TEXT,
@@ -101,7 +135,80 @@ func setupTestDB(t *testing.T) *sqlx.DB {
         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        version INTEGER DEFAULT 1
+        version INTEGER DEFAULT 1,
+        change_seq BIGINT NOT NULL DEFAULT 0,
+        hlc TEXT NOT NULL DEFAULT ''
+    );
+
+    CREATE TRIGGER assign_habits_change_seq BEFORE INSERT OR UPDATE ON habits
+    FOR EACH ROW EXECUTE PROCEDURE assign_change_seq();
+
+    CREATE TABLE habit_versions (
+        habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+        version INTEGER NOT NULL,
+        snapshot JSONB NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (habit_id, version)
+    );
+
+    CREATE OR REPLACE FUNCTION record_habit_version()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        INSERT INTO habit_versions (habit_id, version, snapshot)
+        VALUES (NEW.id, NEW.version, to_jsonb(NEW))
+        ON CONFLICT (habit_id, version) DO UPDATE SET snapshot = EXCLUDED.snapshot;
+
+        DELETE FROM habit_versions WHERE habit_id = NEW.id AND version <= NEW.version - 50;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TRIGGER record_habits_version AFTER INSERT OR UPDATE ON habits
+    FOR EACH ROW EXECUTE PROCEDURE record_habit_version();
+
+    CREATE TRIGGER assign_habit_entries_change_seq BEFORE INSERT OR UPDATE ON habit_entries
+    FOR EACH ROW EXECUTE PROCEDURE assign_change_seq();
+
+    CREATE OR REPLACE FUNCTION cascade_habit_soft_delete()
+    RETURNS TRIGGER AS $$
+    BEGIN
+        IF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+            UPDATE habit_entries
+            SET deleted_at = NEW.deleted_at, updated_at = NOW(), version = version + 1
+            WHERE habit_id = NEW.id AND deleted_at IS NULL;
+        ELSIF NEW.deleted_at IS NULL AND OLD.deleted_at IS NOT NULL THEN
+            UPDATE habit_entries
+            SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+            WHERE habit_id = NEW.id AND deleted_at >= OLD.deleted_at;
+        END IF;
+        RETURN NEW;
+    END;
+    $$ LANGUAGE plpgsql;
+
+    CREATE TRIGGER cascade_habits_soft_delete AFTER UPDATE OF deleted_at ON habits
+    FOR EACH ROW EXECUTE PROCEDURE cascade_habit_soft_delete();
+    CREATE TABLE operations (
+        id BIGSERIAL PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        device_id TEXT NOT NULL DEFAULT '',
+        entity_type TEXT NOT NULL,
+        entity_id TEXT NOT NULL,
+        habit_id TEXT NOT NULL,
+        op TEXT NOT NULL,
+        old_value JSONB,
+        new_value JSONB,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+    CREATE TABLE habit_pauses (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        habit_id UUID REFERENCES habits(id) ON DELETE CASCADE,
+        start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+        end_date TIMESTAMP WITH TIME ZONE,
+        reason TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
     );
     `
 	_, err = db.Exec(schema)
@@ -111,7 +218,7 @@ func setupTestDB(t *testing.T) *sqlx.DB {
 }
 
 func cleanup(t *testing.T, db *sqlx.DB) {
-	_, err := db.Exec("TRUNCATE TABLE habit_entries, habits, users CASCADE")
+	_, err := db.Exec("TRUNCATE TABLE habit_pauses, operations, habit_entries, habits, devices, user_sync_state, users CASCADE")
 	require.NoError(t, err, "Failed to clean up database for Habit Repository tests")
 }
 
@@ -202,6 +309,22 @@ func TestPostgresHabitRepository_Integration(t *testing.T) {
 		assert.Equal(t, 2, updated.Version)
 	})
 
+	t.Run("Update Start and End Dates", func(t *testing.T) {
+		start := now.AddDate(0, 0, -7).Truncate(time.Second)
+		end := now.AddDate(0, 1, 0).Truncate(time.Second)
+		newHabit.StartDate = start
+		newHabit.EndDate = &end
+		newHabit.Version++
+
+		require.NoError(t, repo.Update(ctx, newHabit))
+
+		updated, err := repo.GetByID(ctx, habitID)
+		require.NoError(t, err)
+		assert.True(t, start.Equal(updated.StartDate), "start_date non aggiornata")
+		require.NotNil(t, updated.EndDate)
+		assert.True(t, end.Equal(*updated.EndDate))
+	})
+
 	t.Run("List By UserID", func(t *testing.T) {
 		list, err := repo.ListByUserID(ctx, userID)
 		assert.NoError(t, err)
@@ -209,6 +332,19 @@ func TestPostgresHabitRepository_Integration(t *testing.T) {
 		assert.Equal(t, habitID, list[0].ID)
 	})
 
+	t.Run("List By UserID For Update locks the rows", func(t *testing.T) {
+		err := NewPostgresTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			list, err := repo.ListByUserIDForUpdate(ctx, userID)
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+
+			_, err = db.Exec("SELECT id FROM habits WHERE id=$1 FOR UPDATE NOWAIT", habitID)
+			assert.Error(t, err, "Another transaction cannot lock the row")
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
 	t.Run("Delete Habit (Soft Delete Check)", func(t *testing.T) {
 		err := repo.Delete(ctx, habitID)
 		assert.NoError(t, err)
@@ -302,24 +438,114 @@ func TestPostgresHabitRepository_Integration(t *testing.T) {
 
 		require.NoError(t, repo.Create(ctx, h1))
 		require.NoError(t, repo.Create(ctx, h2))
+		assert.Greater(t, h2.ChangeSeq, h1.ChangeSeq, "Every write must take the next sequence")
 
-		time.Sleep(50 * time.Millisecond)
-
-		var lastSync time.Time
-		err := db.QueryRow("SELECT NOW()").Scan(&lastSync)
-		require.NoError(t, err)
-
-		time.Sleep(50 * time.Millisecond)
+		lastSync := h2.ChangeSeq
 
 		h1.Title = "H1 Changed"
 		h1.Version++
-		repo.Update(ctx, h1)
+		require.NoError(t, repo.Update(ctx, h1))
 
-		repo.Delete(ctx, h2.ID)
+		require.NoError(t, repo.Delete(ctx, h2.ID))
 
-		changes, err := repo.GetChanges(ctx, syncUser, lastSync)
+		changes, err := repo.GetChanges(ctx, syncUser, lastSync, 100)
 		assert.NoError(t, err)
 
-		assert.Len(t, changes, 2)
+		require.Len(t, changes, 2)
+		assert.Equal(t, h1.ID, changes[0].ID, "Changes must be ordered by sequence")
+		assert.Less(t, changes[0].ChangeSeq, changes[1].ChangeSeq)
+	})
+
+	t.Run("GetVersion (History)", func(t *testing.T) {
+		h := &domain.Habit{ID: uuid.New().String(), UserID: userID, Title: "Base", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, Weekdays: []int{1, 3}, StartDate: now}
+		require.NoError(t, repo.Create(ctx, h))
+
+		h.Title = "Edited"
+		h.Version++
+		require.NoError(t, repo.Update(ctx, h))
+
+		base, err := repo.GetVersion(ctx, h.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Base", base.Title)
+		assert.Equal(t, []int{1, 3}, base.Weekdays)
+		assert.Equal(t, 1, base.Version)
+
+		_, err = repo.GetVersion(ctx, h.ID, 99)
+		assert.ErrorIs(t, err, domain.ErrHabitVersionNotFound)
+	})
+
+	t.Run("Delete cascades to entries", func(t *testing.T) {
+		entryRepo := NewPostgresEntryRepository(db)
+
+		h := &domain.Habit{ID: uuid.New().String(), UserID: userID, Title: "Cascade", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now}
+		require.NoError(t, repo.Create(ctx, h))
+
+		entry := domain.NewHabitEntry(h.ID, userID, now, 1)
+		require.NoError(t, entryRepo.Create(ctx, entry))
+
+		var checkpoint int64
+		require.NoError(t, db.Get(&checkpoint, "SELECT last_seq FROM user_sync_state WHERE user_id = $1", userID))
+
+		require.NoError(t, repo.Delete(ctx, h.ID))
+
+		changes, err := entryRepo.GetChanges(ctx, userID, checkpoint, 100)
+		require.NoError(t, err)
+		require.Len(t, changes, 1, "The entry tombstone must reach the entries delta feed")
+		assert.Equal(t, entry.ID, changes[0].ID)
+		assert.NotNil(t, changes[0].DeletedAt)
+		assert.Equal(t, 2, changes[0].Version)
+
+		stats, err := entryRepo.ListByUserIDAndDateRange(ctx, userID, now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		for _, e := range stats {
+			assert.NotEqual(t, entry.ID, e.ID, "Cascaded entries no longer count")
+		}
+	})
+
+	t.Run("Trash: ListDeleted and Restore", func(t *testing.T) {
+		entryRepo := NewPostgresEntryRepository(db)
+		transactor := NewPostgresTransactor(db)
+
+		h := &domain.Habit{ID: uuid.New().String(), UserID: userID, Title: "Trashed", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now}
+		require.NoError(t, repo.Create(ctx, h))
+
+		earlier := domain.NewHabitEntry(h.ID, userID, now.AddDate(0, 0, -1), 1)
+		require.NoError(t, entryRepo.Create(ctx, earlier))
+		require.NoError(t, entryRepo.Delete(ctx, earlier.ID, userID))
+
+		withHabit := domain.NewHabitEntry(h.ID, userID, now, 1)
+		require.NoError(t, entryRepo.Create(ctx, withHabit))
+
+		cutoff := now.Add(-time.Minute)
+		require.NoError(t, repo.Delete(ctx, h.ID))
+
+		_, err := entryRepo.GetByID(ctx, withHabit.ID)
+		assert.ErrorIs(t, err, domain.ErrEntryNotFound, "Deleting the habit deletes its entries")
+
+		trash, err := repo.ListDeleted(ctx, userID, cutoff)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, h.ID, trash[0].ID)
+
+		none, err := repo.ListDeleted(ctx, userID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, none, "Habits deleted before the window are not listed")
+
+		var restored *domain.Habit
+		err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			restored, err = repo.Restore(ctx, h.ID, userID, cutoff)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+		assert.Equal(t, 3, restored.Version, "Create, delete and restore each bump the version")
+
+		_, err = entryRepo.GetByID(ctx, withHabit.ID)
+		assert.NoError(t, err, "Entries deleted with the habit come back")
+		_, err = entryRepo.GetByID(ctx, earlier.ID)
+		assert.ErrorIs(t, err, domain.ErrEntryNotFound, "Entries deleted before the habit stay deleted")
+
+		_, err = repo.Restore(ctx, h.ID, userID, cutoff)
+		assert.ErrorIs(t, err, domain.ErrHabitNotFound, "A live habit is not in the trash")
 	})
 }
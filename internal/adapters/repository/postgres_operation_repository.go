@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+var _ domain.OperationRepository = (*PostgresOperationRepository)(nil)
+
+type PostgresOperationRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresOperationRepository(db *sqlx.DB) *PostgresOperationRepository {
+	return &PostgresOperationRepository{db: db}
+}
+
+type operationRow struct {
+	ID         int64          `db:"id"`
+	UserID     string         `db:"user_id"`
+	DeviceID   string         `db:"device_id"`
+	EntityType string         `db:"entity_type"`
+	EntityID   string         `db:"entity_id"`
+	HabitID    string         `db:"habit_id"`
+	Op         string         `db:"op"`
+	OldValue   sql.NullString `db:"old_value"`
+	NewValue   sql.NullString `db:"new_value"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+func (r operationRow) toDomain() *domain.Operation {
+	op := &domain.Operation{
+		ID:         r.ID,
+		UserID:     r.UserID,
+		DeviceID:   r.DeviceID,
+		EntityType: r.EntityType,
+		EntityID:   r.EntityID,
+		HabitID:    r.HabitID,
+		Op:         r.Op,
+		CreatedAt:  r.CreatedAt,
+	}
+	if r.OldValue.Valid {
+		op.OldValue = json.RawMessage(r.OldValue.String)
+	}
+	if r.NewValue.Valid {
+		op.NewValue = json.RawMessage(r.NewValue.String)
+	}
+	return op
+}
+
+// nullJSON stores an absent value as SQL NULL rather than a JSON null.
+func nullJSON(v json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(v), Valid: len(v) > 0}
+}
+
+func (r *PostgresOperationRepository) Append(ctx context.Context, op *domain.Operation) error {
+	query := `
+        INSERT INTO operations (user_id, device_id, entity_type, entity_id, habit_id, op, old_value, new_value)
+        VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb)
+        RETURNING id, created_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		op.UserID, op.DeviceID, op.EntityType, op.EntityID, op.HabitID, op.Op,
+		nullJSON(op.OldValue), nullJSON(op.NewValue),
+	).Scan(&op.ID, &op.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository: append operation failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresOperationRepository) List(ctx context.Context, q domain.OperationQuery) ([]*domain.Operation, error) {
+	query := `
+        SELECT id, user_id, device_id, entity_type, entity_id, habit_id, op,
+               old_value::text AS old_value, new_value::text AS new_value, created_at
+        FROM operations
+        WHERE user_id = $1
+          AND ($2 = '' OR habit_id = $2)
+          AND ($3 = 0 OR id < $3)
+        ORDER BY id DESC
+        LIMIT $4`
+
+	var rows []operationRow
+	if err := executor(ctx, r.db).SelectContext(ctx, &rows, query, q.UserID, q.HabitID, q.Before, q.Limit); err != nil {
+		return nil, fmt.Errorf("repository: list operations failed: %w", err)
+	}
+
+	ops := make([]*domain.Operation, 0, len(rows))
+	for _, row := range rows {
+		ops = append(ops, row.toDomain())
+	}
+	return ops, nil
+}
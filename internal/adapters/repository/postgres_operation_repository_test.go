@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestPostgresOperationRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	repo := NewPostgresOperationRepository(db)
+	transactor := NewPostgresTransactor(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "ops-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'ops@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	t.Run("Append sets the ID and keeps both values", func(t *testing.T) {
+		op := &domain.Operation{
+			UserID: userID, DeviceID: "device-1",
+			EntityType: domain.OperationEntityHabit, EntityID: "habit-a", HabitID: "habit-a",
+			Op:       domain.OperationUpdate,
+			OldValue: json.RawMessage(`{"title":"Read"}`),
+			NewValue: json.RawMessage(`{"title":"Read more"}`),
+		}
+		require.NoError(t, repo.Append(ctx, op))
+		assert.NotZero(t, op.ID)
+		assert.False(t, op.CreatedAt.IsZero())
+
+		ops, err := repo.List(ctx, domain.OperationQuery{UserID: userID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		assert.Equal(t, "device-1", ops[0].DeviceID)
+		assert.JSONEq(t, `{"title":"Read"}`, string(ops[0].OldValue))
+		assert.JSONEq(t, `{"title":"Read more"}`, string(ops[0].NewValue))
+	})
+
+	t.Run("Absent values stay empty", func(t *testing.T) {
+		op := &domain.Operation{UserID: userID, EntityType: domain.OperationEntityEntry, EntityID: "entry-1", HabitID: "habit-b", Op: domain.OperationCreate, NewValue: json.RawMessage(`{}`)}
+		require.NoError(t, repo.Append(ctx, op))
+
+		ops, err := repo.List(ctx, domain.OperationQuery{UserID: userID, HabitID: "habit-b", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		assert.Nil(t, ops[0].OldValue)
+		assert.Empty(t, ops[0].DeviceID)
+	})
+
+	t.Run("Pages newest first", func(t *testing.T) {
+		first, err := repo.List(ctx, domain.OperationQuery{UserID: userID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+		assert.Equal(t, "entry-1", first[0].EntityID)
+
+		next, err := repo.List(ctx, domain.OperationQuery{UserID: userID, Before: first[0].ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, next, 1)
+		assert.Equal(t, "habit-a", next[0].EntityID)
+	})
+
+	t.Run("Rolls back with the surrounding transaction", func(t *testing.T) {
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			require.NoError(t, repo.Append(ctx, &domain.Operation{UserID: userID, EntityType: domain.OperationEntityHabit, EntityID: "habit-c", HabitID: "habit-c", Op: domain.OperationDelete}))
+			return assert.AnError
+		})
+		require.ErrorIs(t, err, assert.AnError)
+
+		ops, err := repo.List(ctx, domain.OperationQuery{UserID: userID, HabitID: "habit-c", Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type PostgresPauseRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPauseRepository(db *sqlx.DB) *PostgresPauseRepository {
+	return &PostgresPauseRepository{db: db}
+}
+
+// habit_id is NULL for account-wide pauses. Named queries are written with
+// CAST rather than ::, which sqlx reads as an escaped colon.
+const pauseColumns = `id, user_id, COALESCE(habit_id::text, '') AS habit_id, start_date, end_date, reason, created_at, updated_at`
+
+func (r *PostgresPauseRepository) Create(ctx context.Context, pause *domain.Pause) error {
+	query := `
+		INSERT INTO habit_pauses (id, user_id, habit_id, start_date, end_date, reason, created_at, updated_at)
+		VALUES (:id, :user_id, CAST(NULLIF(:habit_id, '') AS uuid), :start_date, :end_date, :reason, :created_at, :updated_at)`
+
+	if _, err := executor(ctx, r.db).NamedExecContext(ctx, query, pause); err != nil {
+		return fmt.Errorf("repository: create pause failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPauseRepository) GetByID(ctx context.Context, id string) (*domain.Pause, error) {
+	var pause domain.Pause
+	err := executor(ctx, r.db).GetContext(ctx, &pause,
+		`SELECT `+pauseColumns+` FROM habit_pauses WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPauseNotFound
+		}
+		return nil, fmt.Errorf("repository: get pause failed: %w", err)
+	}
+	return &pause, nil
+}
+
+func (r *PostgresPauseRepository) ListByUserID(ctx context.Context, userID string) ([]*domain.Pause, error) {
+	pauses := []*domain.Pause{}
+	err := executor(ctx, r.db).SelectContext(ctx, &pauses,
+		`SELECT `+pauseColumns+` FROM habit_pauses WHERE user_id = $1 ORDER BY start_date DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list pauses failed: %w", err)
+	}
+	return pauses, nil
+}
+
+func (r *PostgresPauseRepository) Update(ctx context.Context, pause *domain.Pause) error {
+	query := `
+		UPDATE habit_pauses
+		SET start_date = :start_date, end_date = :end_date, reason = :reason, updated_at = :updated_at
+		WHERE id = :id`
+
+	res, err := executor(ctx, r.db).NamedExecContext(ctx, query, pause)
+	if err != nil {
+		return fmt.Errorf("repository: update pause failed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrPauseNotFound
+	}
+	return nil
+}
+
+func (r *PostgresPauseRepository) Delete(ctx context.Context, id string) error {
+	res, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM habit_pauses WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("repository: delete pause failed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrPauseNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestPostgresPauseRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	repo := NewPostgresPauseRepository(db)
+	habitRepo := NewPostgresHabitRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "pause-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'pause@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	habit := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Run", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, habitRepo.Create(ctx, habit))
+
+	end := now.AddDate(0, 0, 7)
+	vacation, err := domain.NewPause("vacation", userID, "", now, &end, "Holiday")
+	require.NoError(t, err)
+	injury, err := domain.NewPause("injury", userID, habit.ID, now.AddDate(0, 0, 1), nil, "Sprained ankle")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, vacation))
+	require.NoError(t, repo.Create(ctx, injury))
+
+	t.Run("Create and Get", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "vacation")
+		require.NoError(t, err)
+		assert.Empty(t, got.HabitID, "Account-wide pauses have no habit")
+		require.NotNil(t, got.EndDate)
+		assert.True(t, end.Equal(*got.EndDate))
+
+		got, err = repo.GetByID(ctx, "injury")
+		require.NoError(t, err)
+		assert.Equal(t, habit.ID, got.HabitID)
+		assert.Nil(t, got.EndDate)
+
+		_, err = repo.GetByID(ctx, "missing")
+		assert.ErrorIs(t, err, domain.ErrPauseNotFound)
+
+		list, err := repo.ListByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "injury", list[0].ID, "Most recent start first")
+	})
+
+	t.Run("Update and Delete", func(t *testing.T) {
+		resumed := now.AddDate(0, 0, 3)
+		require.NoError(t, injury.Update(injury.StartDate, &resumed, "Healed"))
+		require.NoError(t, repo.Update(ctx, injury))
+
+		got, err := repo.GetByID(ctx, "injury")
+		require.NoError(t, err)
+		assert.Equal(t, "Healed", got.Reason)
+		require.NotNil(t, got.EndDate)
+
+		require.NoError(t, repo.Delete(ctx, "injury"))
+		assert.ErrorIs(t, repo.Delete(ctx, "injury"), domain.ErrPauseNotFound)
+	})
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+var _ domain.SnapshotRepository = (*PostgresSnapshotRepository)(nil)
+
+type PostgresSnapshotRepository struct {
+	db     *sqlx.DB
+	habits *PostgresHabitRepository
+}
+
+func NewPostgresSnapshotRepository(db *sqlx.DB) *PostgresSnapshotRepository {
+	return &PostgresSnapshotRepository{db: db, habits: NewPostgresHabitRepository(db)}
+}
+
+// Snapshot reads the cursor and the rows from one read-only REPEATABLE READ
+// transaction. Change sequences are assigned under the user_sync_state row
+// lock, so every row up to last_seq is visible in the snapshot and none after
+// it. Rows are streamed to w as they are read instead of being buffered.
+func (r *PostgresSnapshotRepository) Snapshot(ctx context.Context, userID string, w domain.SnapshotWriter) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("repository: begin snapshot failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var cursor int64
+	err = tx.GetContext(ctx, &cursor, `
+        SELECT COALESCE((SELECT last_seq FROM user_sync_state WHERE user_id = $1), 0)`, userID)
+	if err != nil {
+		return fmt.Errorf("repository: read snapshot cursor failed: %w", err)
+	}
+
+	if err := w.Begin(cursor); err != nil {
+		return err
+	}
+
+	if err := r.streamHabits(ctx, tx, userID, w); err != nil {
+		return err
+	}
+	if err := r.streamEntries(ctx, tx, userID, w); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresSnapshotRepository) streamHabits(ctx context.Context, tx *sqlx.Tx, userID string, w domain.SnapshotWriter) error {
+	query := fmt.Sprintf(`
+        SELECT %s FROM habits
+        WHERE user_id = $1 AND deleted_at IS NULL
+        ORDER BY change_seq ASC`, selectColumns)
+
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("repository: snapshot habits failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := r.habits.scanRow(rows)
+		if err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		if err := w.Habit(h); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresSnapshotRepository) streamEntries(ctx context.Context, tx *sqlx.Tx, userID string, w domain.SnapshotWriter) error {
+	rows, err := tx.QueryxContext(ctx, `
+        SELECT * FROM habit_entries
+        WHERE user_id = $1 AND deleted_at IS NULL
+        ORDER BY change_seq ASC`, userID)
+	if err != nil {
+		return fmt.Errorf("repository: snapshot entries failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.HabitEntry
+		if err := rows.StructScan(&e); err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		if err := w.Entry(&e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
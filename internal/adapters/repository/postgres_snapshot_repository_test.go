@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type collectingSnapshotWriter struct {
+	cursor  int64
+	habits  []*domain.Habit
+	entries []*domain.HabitEntry
+}
+
+func (w *collectingSnapshotWriter) Begin(cursor int64) error {
+	w.cursor = cursor
+	return nil
+}
+
+func (w *collectingSnapshotWriter) Habit(h *domain.Habit) error {
+	w.habits = append(w.habits, h)
+	return nil
+}
+
+func (w *collectingSnapshotWriter) Entry(e *domain.HabitEntry) error {
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+func TestPostgresSnapshotRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	habitRepo := NewPostgresHabitRepository(db)
+	entryRepo := NewPostgresEntryRepository(db)
+	repo := NewPostgresSnapshotRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "snapshot-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'snapshot@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	t.Run("A user without data starts from cursor zero", func(t *testing.T) {
+		w := &collectingSnapshotWriter{}
+		require.NoError(t, repo.Snapshot(ctx, userID, w))
+
+		assert.Zero(t, w.cursor)
+		assert.Empty(t, w.habits)
+		assert.Empty(t, w.entries)
+	})
+
+	t.Run("Only live rows, with the cursor of the last change", func(t *testing.T) {
+		live := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Read", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+		gone := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Gone", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, habitRepo.Create(ctx, live))
+		require.NoError(t, habitRepo.Create(ctx, gone))
+		require.NoError(t, habitRepo.Delete(ctx, gone.ID))
+
+		entry := domain.NewHabitEntry(live.ID, userID, now, 1)
+		require.NoError(t, entryRepo.Create(ctx, entry))
+
+		w := &collectingSnapshotWriter{}
+		require.NoError(t, repo.Snapshot(ctx, userID, w))
+
+		require.Len(t, w.habits, 1)
+		assert.Equal(t, live.ID, w.habits[0].ID)
+		require.Len(t, w.entries, 1)
+		assert.Equal(t, entry.ID, w.entries[0].ID)
+
+		changes, err := habitRepo.GetChanges(ctx, userID, w.cursor, 100)
+		require.NoError(t, err)
+		assert.Empty(t, changes, "Nothing is left to pull after the snapshot cursor")
+	})
+}
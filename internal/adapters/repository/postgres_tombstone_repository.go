@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type PostgresTombstoneRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresTombstoneRepository(db *sqlx.DB) *PostgresTombstoneRepository {
+	return &PostgresTombstoneRepository{db: db}
+}
+
+// PurgeTombstones hard-deletes up to limit soft-deleted entries and habits
+// whose deleted_at is older than olderThan, and raises each affected user's
+// min_valid_seq past the purged rows in the same statement. Tombstones that
+// an active device of the user has not acknowledged yet are kept until it
+// does, so that it can still receive them without a full resync. A device
+// is active if it is not revoked and was seen after olderThan.
+// A habit is only purged once none of its entries are left, so its cascade
+// can never remove an entry tombstone that was not accounted for.
+func (r *PostgresTombstoneRepository) PurgeTombstones(ctx context.Context, olderThan time.Time, limit int) (int64, error) {
+	query := `
+        WITH acked AS (
+            SELECT user_id, MIN(last_cursor) AS seq
+            FROM devices
+            WHERE revoked_at IS NULL AND last_seen_at >= $1
+            GROUP BY user_id
+        ),
+        purged_entries AS (
+            DELETE FROM habit_entries
+            WHERE deleted_at IS NOT NULL
+              AND id IN (
+                SELECT e.id FROM habit_entries e
+                LEFT JOIN acked a ON a.user_id = e.user_id
+                WHERE e.deleted_at IS NOT NULL
+                  AND e.deleted_at < $1
+                  AND (a.seq IS NULL OR e.change_seq <= a.seq)
+                LIMIT $2
+              )
+            RETURNING user_id, change_seq
+        ),
+        purged_habits AS (
+            DELETE FROM habits
+            WHERE deleted_at IS NOT NULL
+              AND id IN (
+                SELECT h.id FROM habits h
+                LEFT JOIN acked a ON a.user_id = h.user_id
+                WHERE h.deleted_at IS NOT NULL
+                  AND h.deleted_at < $1
+                  AND (a.seq IS NULL OR h.change_seq <= a.seq)
+                  AND NOT EXISTS (SELECT 1 FROM habit_entries e WHERE e.habit_id = h.id)
+                LIMIT $2
+              )
+            RETURNING user_id, change_seq
+        ),
+        purged AS (
+            SELECT user_id, MAX(change_seq) AS seq
+            FROM (
+                SELECT user_id, change_seq FROM purged_entries
+                UNION ALL
+                SELECT user_id, change_seq FROM purged_habits
+            ) p
+            GROUP BY user_id
+        ),
+        raised AS (
+            UPDATE user_sync_state s
+            SET min_valid_seq = GREATEST(s.min_valid_seq, purged.seq)
+            FROM purged
+            WHERE s.user_id = purged.user_id
+        )
+        SELECT (SELECT count(*) FROM purged_entries) + (SELECT count(*) FROM purged_habits)`
+
+	var purged int64
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, olderThan, limit).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("repository: purge tombstones failed: %w", err)
+	}
+
+	return purged, nil
+}
+
+// checkSyncCursor rejects a cursor that is older than the user's oldest
+// valid cursor. Delta queries call it after reading their rows: a purge that
+// committed before the read is then always detected here, and one that
+// commits later cannot have removed rows from the read.
+func checkSyncCursor(ctx context.Context, db *sqlx.DB, userID string, since int64) error {
+	if since == 0 {
+		return nil
+	}
+
+	var minValid int64
+	err := executor(ctx, db).GetContext(ctx, &minValid,
+		`SELECT min_valid_seq FROM user_sync_state WHERE user_id = $1`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("repository: read sync state failed: %w", err)
+	}
+
+	if since < minValid {
+		return domain.ErrSyncCursorExpired
+	}
+	return nil
+}
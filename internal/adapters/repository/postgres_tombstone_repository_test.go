@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestPostgresTombstoneRepository_Integration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cleanup(t, db)
+	defer cleanup(t, db)
+
+	ctx := context.Background()
+	habitRepo := NewPostgresHabitRepository(db)
+	entryRepo := NewPostgresEntryRepository(db)
+	repo := NewPostgresTombstoneRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := "tombstone-user"
+
+	_, err := db.Exec(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+        VALUES ($1, 'tombstone@kanso.app', 'hash', $2, $2)`, userID, now)
+	require.NoError(t, err)
+
+	habit := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Gone", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, habitRepo.Create(ctx, habit))
+
+	entry := domain.NewHabitEntry(habit.ID, userID, now, 1)
+	entry.ID = uuid.NewString()
+	require.NoError(t, entryRepo.Create(ctx, entry))
+
+	staleCursor := entry.ChangeSeq
+
+	require.NoError(t, entryRepo.Delete(ctx, entry.ID, userID))
+	require.NoError(t, habitRepo.Delete(ctx, habit.ID))
+
+	t.Run("Recent tombstones are kept", func(t *testing.T) {
+		purged, err := repo.PurgeTombstones(ctx, now.Add(-time.Hour), 100)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+
+	t.Run("Expired tombstones are purged and old cursors expire", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE habit_entries SET deleted_at = $1 WHERE id = $2`, now.Add(-48*time.Hour), entry.ID)
+		require.NoError(t, err)
+		_, err = db.Exec(`UPDATE habits SET deleted_at = $1 WHERE id = $2`, now.Add(-48*time.Hour), habit.ID)
+		require.NoError(t, err)
+
+		cutoff := now.Add(-24 * time.Hour)
+
+		purged, err := repo.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged, "The habit waits until its entries are gone")
+
+		purged, err = repo.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		var remaining int
+		require.NoError(t, db.Get(&remaining, `SELECT count(*) FROM habits WHERE id = $1`, habit.ID))
+		assert.Zero(t, remaining)
+
+		_, err = habitRepo.GetChanges(ctx, userID, staleCursor, 100)
+		assert.ErrorIs(t, err, domain.ErrSyncCursorExpired)
+
+		_, err = entryRepo.GetChanges(ctx, userID, staleCursor, 100)
+		assert.ErrorIs(t, err, domain.ErrSyncCursorExpired)
+
+		_, err = habitRepo.GetChanges(ctx, userID, 0, 100)
+		assert.NoError(t, err, "A full resync is always allowed")
+	})
+
+	t.Run("Restorable habits survive the GC once acknowledged", func(t *testing.T) {
+		trashed := &domain.Habit{ID: uuid.NewString(), UserID: userID, Title: "Trashed", Type: "boolean", FrequencyType: "daily", Interval: 1, TargetValue: 1, StartDate: now, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, habitRepo.Create(ctx, trashed))
+		require.NoError(t, habitRepo.Delete(ctx, trashed.ID))
+
+		_, err := db.Exec(`INSERT INTO devices (id, user_id, last_cursor, last_seen_at, created_at)
+            VALUES ('tombstone-phone', $1, (SELECT last_seq FROM user_sync_state WHERE user_id = $1), $2, $2)`, userID, now)
+		require.NoError(t, err)
+
+		cutoff := now.Add(-24 * time.Hour)
+		purged, err := repo.PurgeTombstones(ctx, cutoff, 100)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		var restored *domain.Habit
+		err = NewPostgresTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			restored, err = habitRepo.Restore(ctx, trashed.ID, userID, cutoff)
+			return err
+		})
+		require.NoError(t, err, "A habit listed in the trash can still be restored")
+		assert.Nil(t, restored.DeletedAt)
+	})
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+var _ domain.Transactor = (*PostgresTransactor)(nil)
+
+type txContextKey struct{}
+
+type commitHooksKey struct{}
+
+// commitHooks collects the work to do once the outermost transaction has
+// committed, such as dropping cached rows that it changed. Each savepoint
+// has its own, handed over to the enclosing one when it is released.
+type commitHooks struct {
+	fns []func()
+}
+
+func withCommitHooks(ctx context.Context) (context.Context, *commitHooks) {
+	hooks := &commitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), hooks
+}
+
+// afterCommit defers fn until the transaction in ctx commits; it is dropped
+// if the transaction, or the savepoint fn was registered in, rolls back.
+// Outside of a transaction it runs fn immediately.
+func afterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn()
+}
+
+// dbExecutor is the subset of sqlx shared by *sqlx.DB and *sqlx.Tx, so the
+// same repository code runs both standalone and inside a transaction.
+type dbExecutor interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+func executor(ctx context.Context, db *sqlx.DB) dbExecutor {
+	if tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type PostgresTransactor struct {
+	db         *sqlx.DB
+	savepoints atomic.Uint64
+}
+
+func NewPostgresTransactor(db *sqlx.DB) *PostgresTransactor {
+	return &PostgresTransactor{db: db}
+}
+
+func (t *PostgresTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx); ok {
+		return t.withinSavepoint(ctx, tx, fn)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: begin transaction failed: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	txCtx, hooks := withCommitHooks(context.WithValue(ctx, txContextKey{}, tx))
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: commit transaction failed: %w", err)
+	}
+
+	for _, hook := range hooks.fns {
+		hook()
+	}
+	return nil
+}
+
+func (t *PostgresTransactor) AfterCommit(ctx context.Context, fn func()) {
+	afterCommit(ctx, fn)
+}
+
+func (t *PostgresTransactor) withinSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(ctx context.Context) error) error {
+	name := fmt.Sprintf("sp_%d", t.savepoints.Add(1))
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("repository: create savepoint failed: %w", err)
+	}
+
+	spCtx, hooks := withCommitHooks(ctx)
+	if err := fn(spCtx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("repository: rollback to savepoint failed: %v (original error: %w)", rbErr, err)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("repository: release savepoint failed: %w", err)
+	}
+
+	for _, hook := range hooks.fns {
+		afterCommit(ctx, hook)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresTransactor_AfterCommit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	transactor := NewPostgresTransactor(db)
+
+	t.Run("Hooks run once the outermost transaction commits", func(t *testing.T) {
+		var ran []string
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			afterCommit(ctx, func() { ran = append(ran, "outer") })
+
+			err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				afterCommit(ctx, func() { ran = append(ran, "inner") })
+				return nil
+			})
+			require.NoError(t, err)
+
+			assert.Empty(t, ran, "Nothing runs before the commit")
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, ran)
+	})
+
+	t.Run("Hooks of a rolled back savepoint are dropped", func(t *testing.T) {
+		var ran []string
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			transactor.AfterCommit(ctx, func() { ran = append(ran, "outer") })
+
+			err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				transactor.AfterCommit(ctx, func() { ran = append(ran, "failed") })
+				return errors.New("boom")
+			})
+			require.Error(t, err)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer"}, ran)
+	})
+
+	t.Run("Hooks are dropped on rollback", func(t *testing.T) {
+		ran := false
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			afterCommit(ctx, func() { ran = true })
+			return errors.New("boom")
+		})
+
+		require.Error(t, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("Hooks run immediately outside of a transaction", func(t *testing.T) {
+		ran := false
+		afterCommit(ctx, func() { ran = true })
+		assert.True(t, ran)
+	})
+}
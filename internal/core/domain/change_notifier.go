@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+// ChangeNotifier signals, across every API instance, that a user's data has
+// changed so that their connected devices can pull the new deltas.
+type ChangeNotifier interface {
+	Publish(ctx context.Context, userID string) error
+	// Subscribe delivers a signal for every change published for userID
+	// until ctx is cancelled. Bursts may be coalesced into a single signal.
+	Subscribe(ctx context.Context, userID string) (<-chan struct{}, error)
+}
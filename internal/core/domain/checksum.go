@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrInvalidChecksumMonth = errors.New("invalid checksum month (must be YYYY-MM)")
+
+const (
+	ChecksumKindHabit = "habit"
+	ChecksumKindEntry = "entry"
+
+	checksumMonthLayout = "2006-01"
+)
+
+// ChecksumItem is the part of a habit or entry that the sync checksum covers.
+// Soft-deleted rows are included, so that a deletion the client missed also
+// shows up as a mismatch.
+type ChecksumItem struct {
+	Kind      string     `json:"kind" db:"kind"`
+	ID        string     `json:"id" db:"id"`
+	Version   int        `json:"version" db:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// Date places the item in its bucket: the creation time of a habit, the
+	// completion date of an entry.
+	Date time.Time `json:"-" db:"date"`
+}
+
+// Leaf is the canonical form hashed for the item:
+// "<kind>|<id>|<version>|<deleted_at as unix microseconds, 0 if live>".
+func (i ChecksumItem) Leaf() string {
+	var deleted int64
+	if i.DeletedAt != nil {
+		deleted = i.DeletedAt.UnixMicro()
+	}
+	return fmt.Sprintf("%s|%s|%d|%d", i.Kind, i.ID, i.Version, deleted)
+}
+
+// Month is the bucket of the item, as YYYY-MM in UTC.
+func (i ChecksumItem) Month() string {
+	return i.Date.UTC().Format(checksumMonthLayout)
+}
+
+type ChecksumBucket struct {
+	Month   string         `json:"month"`
+	Hash    string         `json:"hash"`
+	Habits  int            `json:"habits"`
+	Entries int            `json:"entries"`
+	Items   []ChecksumItem `json:"items,omitempty"`
+}
+
+// SyncChecksum is a two-level Merkle digest of a user's data as of Cursor.
+// Each bucket hash is the SHA-256 of the leaves of its items sorted by kind
+// and ID, one per line; the root is the SHA-256 of "<month>:<bucket hash>"
+// lines in month order. Hashes are lowercase hex.
+type SyncChecksum struct {
+	Cursor  int64            `json:"cursor,string"`
+	Root    string           `json:"root"`
+	Buckets []ChecksumBucket `json:"buckets"`
+}
+
+// ParseChecksumMonth validates a YYYY-MM bucket name.
+func ParseChecksumMonth(s string) (string, error) {
+	t, err := time.Parse(checksumMonthLayout, s)
+	if err != nil {
+		return "", ErrInvalidChecksumMonth
+	}
+	return t.Format(checksumMonthLayout), nil
+}
+
+// BuildChecksum buckets items by month and hashes them. Items are only
+// listed for the bucket named by expand, if any.
+func BuildChecksum(cursor int64, items []ChecksumItem, expand string) *SyncChecksum {
+	byMonth := make(map[string][]ChecksumItem)
+	for _, item := range items {
+		month := item.Month()
+		byMonth[month] = append(byMonth[month], item)
+	}
+
+	months := make([]string, 0, len(byMonth))
+	for month := range byMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	result := &SyncChecksum{Cursor: cursor, Buckets: make([]ChecksumBucket, 0, len(months))}
+	root := sha256.New()
+
+	for _, month := range months {
+		bucketItems := byMonth[month]
+		sort.Slice(bucketItems, func(a, b int) bool {
+			if bucketItems[a].Kind != bucketItems[b].Kind {
+				return bucketItems[a].Kind < bucketItems[b].Kind
+			}
+			return bucketItems[a].ID < bucketItems[b].ID
+		})
+
+		bucket := ChecksumBucket{Month: month}
+		h := sha256.New()
+		for _, item := range bucketItems {
+			h.Write([]byte(item.Leaf() + "\n"))
+			if item.Kind == ChecksumKindHabit {
+				bucket.Habits++
+			} else {
+				bucket.Entries++
+			}
+		}
+		bucket.Hash = hex.EncodeToString(h.Sum(nil))
+		if month == expand {
+			bucket.Items = bucketItems
+		}
+
+		root.Write([]byte(month + ":" + bucket.Hash + "\n"))
+		result.Buckets = append(result.Buckets, bucket)
+	}
+
+	result.Root = hex.EncodeToString(root.Sum(nil))
+	return result
+}
+
+type ChecksumRepository interface {
+	// ChecksumItems returns every habit and entry of the user, deleted ones
+	// included, together with the sync cursor they are current as of, from a
+	// single consistent read.
+	ChecksumItems(ctx context.Context, userID string) (int64, []ChecksumItem, error)
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChecksum(t *testing.T) {
+	jan := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	deleted := time.Date(2026, 2, 4, 9, 30, 0, 123456000, time.UTC)
+
+	items := []ChecksumItem{
+		{Kind: ChecksumKindEntry, ID: "e-2", Version: 1, Date: feb, DeletedAt: &deleted},
+		{Kind: ChecksumKindHabit, ID: "h-1", Version: 3, Date: jan},
+		{Kind: ChecksumKindEntry, ID: "e-1", Version: 2, Date: jan},
+	}
+
+	sum := BuildChecksum(42, items, "")
+
+	t.Run("Should bucket by month in order", func(t *testing.T) {
+		assert.Equal(t, int64(42), sum.Cursor)
+		require.Len(t, sum.Buckets, 2)
+		assert.Equal(t, "2026-01", sum.Buckets[0].Month)
+		assert.Equal(t, 1, sum.Buckets[0].Habits)
+		assert.Equal(t, 1, sum.Buckets[0].Entries)
+		assert.Equal(t, "2026-02", sum.Buckets[1].Month)
+		assert.Nil(t, sum.Buckets[0].Items)
+	})
+
+	t.Run("Should hash the sorted canonical leaves", func(t *testing.T) {
+		h := sha256.Sum256([]byte("entry|e-1|2|0\nhabit|h-1|3|0\n"))
+		assert.Equal(t, hex.EncodeToString(h[:]), sum.Buckets[0].Hash)
+
+		leaf := items[0].Leaf()
+		assert.Equal(t, "entry|e-2|1|1770197400123456", leaf)
+	})
+
+	t.Run("Should not depend on input order", func(t *testing.T) {
+		reordered := []ChecksumItem{items[2], items[0], items[1]}
+		assert.Equal(t, sum.Root, BuildChecksum(42, reordered, "").Root)
+	})
+
+	t.Run("Should change only the bucket that differs", func(t *testing.T) {
+		changed := append([]ChecksumItem{}, items...)
+		changed[1].Version = 4
+
+		other := BuildChecksum(42, changed, "")
+		assert.NotEqual(t, sum.Root, other.Root)
+		assert.NotEqual(t, sum.Buckets[0].Hash, other.Buckets[0].Hash)
+		assert.Equal(t, sum.Buckets[1].Hash, other.Buckets[1].Hash)
+	})
+
+	t.Run("Should list the items of the expanded bucket", func(t *testing.T) {
+		expanded := BuildChecksum(42, items, "2026-01")
+		require.Len(t, expanded.Buckets[0].Items, 2)
+		assert.Equal(t, "e-1", expanded.Buckets[0].Items[0].ID)
+		assert.Nil(t, expanded.Buckets[1].Items)
+		assert.Equal(t, sum.Root, expanded.Root)
+	})
+
+	t.Run("Should digest an empty account", func(t *testing.T) {
+		empty := BuildChecksum(0, nil, "")
+		h := sha256.Sum256(nil)
+		assert.Equal(t, hex.EncodeToString(h[:]), empty.Root)
+		assert.Empty(t, empty.Buckets)
+	})
+}
+
+func TestParseChecksumMonth(t *testing.T) {
+	month, err := ParseChecksumMonth("2026-03")
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03", month)
+
+	for _, bad := range []string{"2026-3", "2026-13", "March", "2026-03-01"} {
+		_, err := ParseChecksumMonth(bad)
+		assert.ErrorIs(t, err, ErrInvalidChecksumMonth, bad)
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrHabitVersionNotFound = errors.New("habit version not found in history")
+)
+
+// FieldDiff is one field whose value submitted by the client differs from the
+// value currently stored on the server.
+type FieldDiff struct {
+	Field  string      `json:"field"`
+	Client interface{} `json:"client"`
+	Server interface{} `json:"server"`
+}
+
+// HabitConflictError reports the fields that both the client and another
+// writer changed to different values since the client's base version,
+// together with the current server copy so the client can resolve the
+// conflict without fetching it again.
+type HabitConflictError struct {
+	Fields []string
+	Server *Habit
+	Diff   []FieldDiff
+}
+
+func (e *HabitConflictError) Error() string {
+	return fmt.Sprintf("%s on fields: %s", ErrHabitConflict, strings.Join(e.Fields, ", "))
+}
+
+func (e *HabitConflictError) Unwrap() error {
+	return ErrHabitConflict
+}
+
+// EntryConflictError carries the current server copy of an entry whose update
+// was rejected because it was based on an outdated version.
+type EntryConflictError struct {
+	Server *HabitEntry
+	Diff   []FieldDiff
+}
+
+func (e *EntryConflictError) Error() string {
+	return ErrEntryConflict.Error()
+}
+
+func (e *EntryConflictError) Unwrap() error {
+	return ErrEntryConflict
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrDeviceRevoked     = errors.New("device has been revoked")
+	ErrDeviceNameTooLong = errors.New("device name too long (max 100 chars)")
+)
+
+// Device is one installation of the app through which a user syncs.
+// LastCursor is the newest sync cursor the device has acknowledged: every
+// change up to it is known to be stored on the device.
+type Device struct {
+	ID           string     `json:"id" db:"id"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`
+	Platform     string     `json:"platform" db:"platform"`
+	LastCursor   int64      `json:"last_cursor,string" db:"last_cursor"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+func NewDevice(id, userID, name, platform string) (*Device, error) {
+	d := &Device{
+		ID:       id,
+		UserID:   userID,
+		Platform: strings.TrimSpace(platform),
+	}
+	if err := d.Rename(name); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	d.LastSeenAt = now
+	d.CreatedAt = now
+	return d, nil
+}
+
+func (d *Device) Rename(name string) error {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 100 {
+		return ErrDeviceNameTooLong
+	}
+	d.Name = name
+	return nil
+}
+
+func (d *Device) IsRevoked() bool {
+	return d.RevokedAt != nil
+}
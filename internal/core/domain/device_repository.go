@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type DeviceRepository interface {
+	Create(ctx context.Context, device *Device) error
+	GetByID(ctx context.Context, id string) (*Device, error)
+	ListByUserID(ctx context.Context, userID string) ([]*Device, error)
+	Update(ctx context.Context, device *Device) error
+	// AcknowledgeCursor records that the device holds every change up to
+	// cursor. The stored cursor never moves backwards.
+	AcknowledgeCursor(ctx context.Context, id string, cursor int64, at time.Time) error
+}
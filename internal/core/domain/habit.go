@@ -11,18 +11,23 @@ import (
 )
 
 var (
-	ErrHabitTitleEmpty    = errors.New("habit title cannot be empty")
-	ErrHabitTitleTooLong  = errors.New("habit title is too long (max 100 chars)")
-	ErrHabitDescTooLong   = errors.New("habit description is too long (max 500 chars)")
-	ErrHabitInvalidUserID = errors.New("invalid user id")
-	ErrInvalidColor       = errors.New("invalid color format (must be #RRGGBB)")
-	ErrInvalidWeekdays    = errors.New("invalid weekdays (must be 0-6)")
-	ErrInvalidTarget      = errors.New("target cannot be negative")
-	ErrInvalidInterval    = errors.New("interval cannot be negative")
-	ErrHabitArchived      = errors.New("cannot update an archived habit")
-	ErrInvalidHabitType   = errors.New("invalid habit type (must be boolean, numeric, or timer)")
-	ErrInvalidReminder    = errors.New("invalid reminder format (must be HH:MM 24h)")
-	ErrHabitConflict      = errors.New("habit version conflict")
+	ErrHabitTitleEmpty       = errors.New("habit title cannot be empty")
+	ErrHabitTitleTooLong     = errors.New("habit title is too long (max 100 chars)")
+	ErrHabitDescTooLong      = errors.New("habit description is too long (max 500 chars)")
+	ErrHabitInvalidUserID    = errors.New("invalid user id")
+	ErrInvalidColor          = errors.New("invalid color format (must be #RRGGBB)")
+	ErrInvalidWeekdays       = errors.New("invalid weekdays (must be 0-6)")
+	ErrInvalidTarget         = errors.New("target cannot be negative")
+	ErrInvalidInterval       = errors.New("interval cannot be negative")
+	ErrHabitArchived         = errors.New("cannot update an archived habit")
+	ErrInvalidHabitType      = errors.New("invalid habit type (must be boolean, numeric, or timer)")
+	ErrInvalidReminder       = errors.New("invalid reminder format (must be HH:MM 24h)")
+	ErrHabitConflict         = errors.New("habit version conflict")
+	ErrInvalidFrequency      = errors.New("invalid frequency type (must be daily, specific_days, interval, weekly, monthly, or rrule with an rrule)")
+	ErrInvalidTimesPerPeriod = errors.New("invalid times per period (must be 1-7 for weekly, 1-31 for monthly)")
+	ErrInvalidHabitDate      = errors.New("invalid date (must be YYYY-MM-DD or RFC 3339)")
+	ErrInvalidDateRange      = errors.New("end date cannot be before start date")
+	ErrInvalidHabitOrder     = errors.New("invalid habit order (must list every active habit exactly once)")
 )
 
 var colorRegex = regexp.MustCompile(`^#([A-Fa-f0-9]{6}|[A-Fa-f0-9]{3})$`)
@@ -35,6 +40,9 @@ const (
 	HabitFreqDaily        = "daily"
 	HabitFreqSpecificDays = "specific_days"
 	HabitFreqInterval     = "interval"
+	HabitFreqWeekly       = "weekly"
+	HabitFreqMonthly      = "monthly"
+	HabitFreqRRule        = "rrule"
 	DefaultIcon           = "default_icon"
 	MaxTitleLen           = 100
 	MaxDescLen            = 500
@@ -55,6 +63,15 @@ type Habit struct {
 
 	Weekdays []int `json:"weekdays,omitempty" db:"weekdays"`
 
+	// TimesPerPeriod is how many completions a weekly or monthly habit
+	// needs in each week or month, on whichever days.
+	TimesPerPeriod int `json:"times_per_period,omitempty" db:"times_per_period"`
+
+	// RRule is an RFC 5545 recurrence rule that replaces the frequency
+	// shorthand, and ExDates the days (YYYY-MM-DD) excluded from it.
+	RRule   string   `json:"rrule,omitempty" db:"rrule"`
+	ExDates []string `json:"exdates,omitempty" db:"exdates"`
+
 	ReminderTime *string `json:"reminder_time,omitempty" db:"reminder_time"`
 	Interval     int     `json:"interval,omitempty" db:"interval"`
 	TargetValue  int     `json:"target_value" db:"target_value"`
@@ -63,28 +80,47 @@ type Habit struct {
 	CurrentStreak int `json:"current_streak" db:"current_streak"`
 	LongestStreak int `json:"longest_streak" db:"longest_streak"`
 
+	// StartDate and EndDate are calendar dates of the user, as midnight UTC.
 	StartDate  time.Time  `json:"start_date" db:"start_date"`
 	EndDate    *time.Time `json:"end_date,omitempty" db:"end_date"`
 	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
 
+	// StartDateSet is whether the user chose StartDate. Until then it is the
+	// creation time, and entries from before it are still accepted.
+	StartDateSet bool `json:"start_date_set" db:"start_date_set"`
+
 	Version   int        `json:"version" db:"version"`
+	ChangeSeq int64      `json:"change_seq" db:"change_seq"`
+	HLC       string     `json:"hlc,omitempty" db:"hlc"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	rrule *parsedRRule
+}
+
+// parsedRRule is the Recurrence parsed from a habit's RRule, kept so that
+// checking the schedule of many days parses the rule once.
+type parsedRRule struct {
+	source string
+	rule   *Recurrence
 }
 
 type habitData struct {
-	Title         string
-	Description   string
-	Color         string
-	Type          string
-	ReminderTime  *string
-	Unit          string
-	TargetValue   int
-	Interval      int
-	Weekdays      []int
-	FrequencyType string
+	Title          string
+	Description    string
+	Color          string
+	Type           string
+	ReminderTime   *string
+	Unit           string
+	TargetValue    int
+	Interval       int
+	Weekdays       []int
+	FrequencyType  string
+	TimesPerPeriod int
+	RRule          string
+	ExDates        []string
 }
 
 func normalizeWeekdays(days []int) []int {
@@ -103,7 +139,47 @@ func normalizeWeekdays(days []int) []int {
 	return uniqueDays
 }
 
-func prepareHabitData(title, desc, color, hType, reminder, unit string, target, interval int, weekdays []int) (*habitData, error) {
+// resolveFrequency validates the requested frequency type. An empty type is
+// derived from the schedule: rrule when a rule is set, specific days when
+// weekdays are, an interval above one day, daily otherwise. Only weekly and
+// monthly habits keep a times-per-period count.
+func resolveFrequency(freqType string, weekdays []int, interval, timesPerPeriod int, rrule string) (string, int, error) {
+	if rrule != "" {
+		if freqType != "" && freqType != HabitFreqRRule {
+			return "", 0, ErrInvalidFrequency
+		}
+		return HabitFreqRRule, 0, nil
+	}
+
+	switch freqType {
+	case "":
+		if len(weekdays) > 0 {
+			return HabitFreqSpecificDays, 0, nil
+		}
+		if interval > 1 {
+			return HabitFreqInterval, 0, nil
+		}
+		return HabitFreqDaily, 0, nil
+	case HabitFreqDaily, HabitFreqSpecificDays, HabitFreqInterval:
+		return freqType, 0, nil
+	case HabitFreqRRule:
+		return "", 0, ErrInvalidRRule
+	case HabitFreqWeekly:
+		if timesPerPeriod < 1 || timesPerPeriod > 7 {
+			return "", 0, ErrInvalidTimesPerPeriod
+		}
+		return freqType, timesPerPeriod, nil
+	case HabitFreqMonthly:
+		if timesPerPeriod < 1 || timesPerPeriod > 31 {
+			return "", 0, ErrInvalidTimesPerPeriod
+		}
+		return freqType, timesPerPeriod, nil
+	default:
+		return "", 0, ErrInvalidFrequency
+	}
+}
+
+func prepareHabitData(title, desc, color, hType, reminder, unit string, target, interval int, weekdays []int, freqType string, timesPerPeriod int, rrule string, exdates []string) (*habitData, error) {
 	trimmedTitle := strings.TrimSpace(title)
 	cleanDesc := strings.TrimSpace(desc)
 
@@ -145,11 +221,24 @@ func prepareHabitData(title, desc, color, hType, reminder, unit string, target,
 
 	safeWeekdays := normalizeWeekdays(weekdays)
 
-	freqType := HabitFreqDaily
-	if len(safeWeekdays) > 0 {
-		freqType = HabitFreqSpecificDays
-	} else if interval > 1 {
-		freqType = HabitFreqInterval
+	cleanRule := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rrule)), "RRULE:")
+	if cleanRule != "" {
+		if len(cleanRule) > MaxRRuleLen {
+			return nil, ErrInvalidRRule
+		}
+		if _, err := ParseRRule(cleanRule); err != nil {
+			return nil, err
+		}
+	}
+
+	safeExDates, err := normalizeExDates(exdates)
+	if err != nil {
+		return nil, err
+	}
+
+	finalFreq, finalTimes, err := resolveFrequency(freqType, safeWeekdays, interval, timesPerPeriod, cleanRule)
+	if err != nil {
+		return nil, err
 	}
 
 	safeInterval := interval
@@ -163,16 +252,19 @@ func prepareHabitData(title, desc, color, hType, reminder, unit string, target,
 	}
 
 	return &habitData{
-		Title:         trimmedTitle,
-		Description:   cleanDesc,
-		Color:         color,
-		Type:          hType,
-		ReminderTime:  remPtr,
-		Unit:          unit,
-		TargetValue:   finalTarget,
-		Interval:      safeInterval,
-		Weekdays:      safeWeekdays,
-		FrequencyType: freqType,
+		Title:          trimmedTitle,
+		Description:    cleanDesc,
+		Color:          color,
+		Type:           hType,
+		ReminderTime:   remPtr,
+		Unit:           unit,
+		TargetValue:    finalTarget,
+		Interval:       safeInterval,
+		Weekdays:       safeWeekdays,
+		FrequencyType:  finalFreq,
+		TimesPerPeriod: finalTimes,
+		RRule:          cleanRule,
+		ExDates:        safeExDates,
 	}, nil
 }
 
@@ -187,6 +279,9 @@ func (h *Habit) applyChanges(data *habitData, iconInput string) {
 	h.Interval = data.Interval
 	h.Weekdays = data.Weekdays
 	h.FrequencyType = data.FrequencyType
+	h.TimesPerPeriod = data.TimesPerPeriod
+	h.RRule = data.RRule
+	h.ExDates = data.ExDates
 
 	if iconInput == "" {
 		h.Icon = DefaultIcon
@@ -200,7 +295,7 @@ func NewHabit(id string, title, userID string) (*Habit, error) {
 		return nil, ErrHabitInvalidUserID
 	}
 
-	data, err := prepareHabitData(title, "", "", HabitTypeBoolean, "", "", 1, 1, nil)
+	data, err := prepareHabitData(title, "", "", HabitTypeBoolean, "", "", 1, 1, nil, "", 0, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -229,9 +324,9 @@ func NewHabit(id string, title, userID string) (*Habit, error) {
 	return h, nil
 }
 
-func (h *Habit) Update(title, description, color, icon, hType, reminder, unit string, target, interval int, weekdays []int) error {
+func (h *Habit) Update(title, description, color, icon, hType, reminder, unit string, target, interval int, weekdays []int, freqType string, timesPerPeriod int, rrule string, exdates []string) error {
 
-	data, err := prepareHabitData(title, description, color, hType, reminder, unit, target, interval, weekdays)
+	data, err := prepareHabitData(title, description, color, hType, reminder, unit, target, interval, weekdays, freqType, timesPerPeriod, rrule, exdates)
 	if err != nil {
 		return err
 	}
@@ -242,6 +337,93 @@ func (h *Habit) Update(title, description, color, icon, hType, reminder, unit st
 	return nil
 }
 
+// ParseHabitDate reads a start or end date sent by a client: a calendar date
+// (YYYY-MM-DD), or an RFC 3339 timestamp whose date in loc is taken. Either
+// way the result is the calendar date as midnight UTC, the form habit and
+// pause dates are stored in, so that they name the same day wherever they
+// are evaluated.
+func ParseHabitDate(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidHabitDate
+	}
+	return civilDate(t.In(loc)), nil
+}
+
+// Today is the current calendar date in loc, as midnight UTC.
+func Today(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return civilDate(time.Now().In(loc))
+}
+
+// storedDate is the calendar date of a stored habit or pause date. Dates set
+// by the user are midnight UTC already; the default start date of a habit is
+// its creation time, read in UTC like the days of the streak worker.
+func storedDate(t time.Time) time.Time {
+	return civilDate(t.UTC())
+}
+
+// SetDates changes the days the habit is active on. A nil end leaves it
+// open-ended; the end may fall on the start date but not before it.
+func (h *Habit) SetDates(start time.Time, end *time.Time) error {
+	if start.IsZero() {
+		return ErrInvalidHabitDate
+	}
+	if end != nil && storedDate(*end).Before(storedDate(start)) {
+		return ErrInvalidDateRange
+	}
+
+	h.StartDate = start.UTC()
+	h.EndDate = nil
+	if end != nil {
+		utcEnd := end.UTC()
+		h.EndDate = &utcEnd
+	}
+	h.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// IsPeriodic reports whether the habit is due a number of times per week or
+// month rather than on given days.
+func (h *Habit) IsPeriodic() bool {
+	return h.FrequencyType == HabitFreqWeekly || h.FrequencyType == HabitFreqMonthly
+}
+
+// PeriodTarget is the number of completions a periodic habit needs in each
+// period. Rows stored before the count existed need one.
+func (h *Habit) PeriodTarget() int {
+	if h.TimesPerPeriod < 1 {
+		return 1
+	}
+	return h.TimesPerPeriod
+}
+
+// PeriodStart is the first day of the week, starting on Monday, or of the
+// month that contains t, in t's location.
+func (h *Habit) PeriodStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if h.FrequencyType == HabitFreqMonthly {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// NextPeriod is the start of the period after the one starting at start.
+func (h *Habit) NextPeriod(start time.Time) time.Time {
+	if h.FrequencyType == HabitFreqMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
 func (h *Habit) UpdateStreak(current, longest int) {
 	h.CurrentStreak = current
 	h.LongestStreak = longest
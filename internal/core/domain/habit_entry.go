@@ -2,12 +2,15 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
 var (
 	ErrInvalidEntry = errors.New("invalid habit entry data")
+
+	ErrEntryOutsideHabitDates = fmt.Errorf("%w: completion date is outside the habit's start and end dates", ErrInvalidEntry)
 )
 
 type HabitEntry struct {
@@ -20,6 +23,8 @@ type HabitEntry struct {
 	Notes          string    `json:"notes" db:"notes"`
 
 	Version   int        `json:"version" db:"version"`
+	ChangeSeq int64      `json:"change_seq" db:"change_seq"`
+	HLC       string     `json:"hlc,omitempty" db:"hlc"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -42,16 +47,16 @@ func NewHabitEntry(habitID, userID string, date time.Time, value int) *HabitEntr
 
 func (e *HabitEntry) Validate() error {
 	if strings.TrimSpace(e.HabitID) == "" {
-		return errors.New("habit_id is required")
+		return fmt.Errorf("%w: habit_id is required", ErrInvalidEntry)
 	}
 	if strings.TrimSpace(e.UserID) == "" {
-		return errors.New("user_id is required")
+		return fmt.Errorf("%w: user_id is required", ErrInvalidEntry)
 	}
 	if e.Value < 0 {
-		return errors.New("value cannot be negative")
+		return fmt.Errorf("%w: value cannot be negative", ErrInvalidEntry)
 	}
 	if e.CompletionDate.IsZero() {
-		return errors.New("completion_date is required")
+		return fmt.Errorf("%w: completion_date is required", ErrInvalidEntry)
 	}
 	return nil
 }
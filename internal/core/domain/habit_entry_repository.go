@@ -22,6 +22,11 @@ type HabitEntryRepository interface {
 	// Delete performs a Soft Delete on the entry.
 	Delete(ctx context.Context, id string, userID string) error
 
+	// Resurrect overwrites a soft-deleted entry of entry.UserID with entry's
+	// data and makes it live again. It returns ErrEntryNotFound if the user
+	// has no deleted entry with that ID.
+	Resurrect(ctx context.Context, entry *HabitEntry) error
+
 	// GetByID retrieves a single active (non-deleted) entry by its ID.
 	GetByID(ctx context.Context, id string) (*HabitEntry, error)
 
@@ -29,8 +34,8 @@ type HabitEntryRepository interface {
 
 	ListByHabitIDWithRange(ctx context.Context, habitID string, from, to time.Time) ([]*HabitEntry, error)
 
-	// GetChanges returns changes for sync.
-	GetChanges(ctx context.Context, userID string, since time.Time) ([]*HabitEntry, error)
+	// GetChanges returns at most limit changes for sync, ordered by change sequence.
+	GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*HabitEntry, error)
 
 	ListByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]HabitEntry, error)
 }
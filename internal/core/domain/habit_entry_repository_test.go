@@ -2,6 +2,7 @@ package domain_test
 
 import (
 	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 
 type InMemoryEntryRepository struct {
 	entries map[string]*domain.HabitEntry
+	seq     int64
 }
 
 func NewInMemoryEntryRepository() *InMemoryEntryRepository {
@@ -29,6 +31,8 @@ func (r *InMemoryEntryRepository) Create(ctx context.Context, entry *domain.Habi
 	if entry.Version == 0 {
 		entry.Version = 1
 	}
+	r.seq++
+	entry.ChangeSeq = r.seq
 	r.entries[entry.ID] = entry
 	return nil
 }
@@ -57,6 +61,8 @@ func (r *InMemoryEntryRepository) Update(ctx context.Context, entry *domain.Habi
 
 	entry.Version++
 	entry.UpdatedAt = time.Now().UTC()
+	r.seq++
+	entry.ChangeSeq = r.seq
 	r.entries[entry.ID] = entry
 	return nil
 }
@@ -74,6 +80,8 @@ func (r *InMemoryEntryRepository) Delete(ctx context.Context, id string, userID
 	entry.DeletedAt = &now
 	entry.UpdatedAt = now
 	entry.Version++
+	r.seq++
+	entry.ChangeSeq = r.seq
 
 	return nil
 }
@@ -92,14 +100,18 @@ func (r *InMemoryEntryRepository) ListByHabitID(ctx context.Context, habitID str
 	return list, nil
 }
 
-func (r *InMemoryEntryRepository) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.HabitEntry, error) {
+func (r *InMemoryEntryRepository) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.HabitEntry, error) {
 	var changes []*domain.HabitEntry
 	for _, e := range r.entries {
-		if e.UserID == userID && e.UpdatedAt.After(since) {
+		if e.UserID == userID && e.ChangeSeq > since {
 			val := *e
 			changes = append(changes, &val)
 		}
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeSeq < changes[j].ChangeSeq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
 	return changes, nil
 }
 
@@ -167,16 +179,14 @@ func TestHabitEntryRepository_Contract_60k(t *testing.T) {
 		e1 := domain.NewHabitEntry("h-1", "user-sync", today, 1)
 		syncRepo.Create(ctx, e1)
 
-		time.Sleep(10 * time.Millisecond)
-		lastSync := time.Now().UTC()
-		time.Sleep(10 * time.Millisecond)
+		lastSync := e1.ChangeSeq
 
 		e2 := domain.NewHabitEntry("h-1", "user-sync", today, 2)
 		syncRepo.Create(ctx, e2)
 
 		syncRepo.Delete(ctx, e1.ID, "user-sync")
 
-		changes, err := syncRepo.GetChanges(ctx, "user-sync", lastSync)
+		changes, err := syncRepo.GetChanges(ctx, "user-sync", lastSync, 100)
 		require.NoError(t, err)
 
 		assert.Len(t, changes, 2)
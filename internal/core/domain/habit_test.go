@@ -65,10 +65,13 @@ func TestHabit_Validation(t *testing.T) {
 		target       int
 		interval     int
 		weekdays     []int
+		freqType     string
+		times        int
 		wantErr      error
 		wantTarget   int
 		wantFreq     string
 		wantInterval int
+		wantTimes    int
 	}{
 		{
 			name:         "Success: Interval (Every 3 Days)",
@@ -216,6 +219,79 @@ func TestHabit_Validation(t *testing.T) {
 			target:      -1,
 			wantErr:     domain.ErrInvalidTarget,
 		},
+		{
+			name:         "Success: Three Times a Week",
+			title:        "Run",
+			description:  "desc",
+			hType:        domain.HabitTypeBoolean,
+			freqType:     domain.HabitFreqWeekly,
+			times:        3,
+			target:       1,
+			wantTarget:   1,
+			wantFreq:     domain.HabitFreqWeekly,
+			wantInterval: 1,
+			wantTimes:    3,
+		},
+		{
+			name:         "Success: Ten Times a Month",
+			title:        "Swim",
+			description:  "desc",
+			hType:        domain.HabitTypeBoolean,
+			freqType:     domain.HabitFreqMonthly,
+			times:        10,
+			target:       1,
+			wantTarget:   1,
+			wantFreq:     domain.HabitFreqMonthly,
+			wantInterval: 1,
+			wantTimes:    10,
+		},
+		{
+			name:         "Success: Explicit Daily Drops Times Per Period",
+			title:        "Walk",
+			description:  "desc",
+			hType:        domain.HabitTypeBoolean,
+			freqType:     domain.HabitFreqDaily,
+			times:        4,
+			target:       1,
+			wantTarget:   1,
+			wantFreq:     domain.HabitFreqDaily,
+			wantInterval: 1,
+		},
+		{
+			name:        "Error: Weekly Without Times",
+			title:       "Run",
+			description: "desc",
+			hType:       domain.HabitTypeBoolean,
+			freqType:    domain.HabitFreqWeekly,
+			wantErr:     domain.ErrInvalidTimesPerPeriod,
+		},
+		{
+			name:        "Error: Weekly More Than 7 Times",
+			title:       "Run",
+			description: "desc",
+			hType:       domain.HabitTypeBoolean,
+			freqType:    domain.HabitFreqWeekly,
+			times:       8,
+			wantErr:     domain.ErrInvalidTimesPerPeriod,
+		},
+		{
+			name:        "Error: Monthly More Than 31 Times",
+			title:       "Swim",
+			description: "desc",
+			hType:       domain.HabitTypeBoolean,
+			freqType:    domain.HabitFreqMonthly,
+			times:       32,
+			wantErr:     domain.ErrInvalidTimesPerPeriod,
+		},
+		{
+			name:        "Error: Unknown Frequency",
+			title:       "Yearly",
+			description: "desc",
+			hType:       domain.HabitTypeBoolean,
+			freqType:    "yearly",
+			times:       1,
+			wantErr:     domain.ErrInvalidFrequency,
+		},
 		{
 			name:        "Error: Negative Interval",
 			title:       "Bad Interval",
@@ -234,6 +310,7 @@ func TestHabit_Validation(t *testing.T) {
 				tt.title, tt.description, tt.color, "icon",
 				tt.hType, tt.reminder, "unit",
 				tt.target, tt.interval, tt.weekdays,
+				tt.freqType, tt.times, "", nil,
 			)
 
 			if tt.wantErr != nil {
@@ -245,6 +322,7 @@ func TestHabit_Validation(t *testing.T) {
 				assert.Equal(t, tt.wantTarget, habit.TargetValue)
 				assert.Equal(t, tt.wantFreq, habit.FrequencyType)
 				assert.Equal(t, tt.wantInterval, habit.Interval)
+				assert.Equal(t, tt.wantTimes, habit.TimesPerPeriod)
 
 				if tt.reminder != "" {
 					assert.NotNil(t, habit.ReminderTime)
@@ -260,7 +338,7 @@ func TestHabit_Validation(t *testing.T) {
 func TestHabit_Lifecycle(t *testing.T) {
 	createStandardHabit := func() *domain.Habit {
 		h, _ := domain.NewHabit("", "Original Title", "u1")
-		_ = h.Update("Original Title", "Desc", "#000", "icon", domain.HabitTypeNumeric, "", "ml", 10, 1, nil)
+		_ = h.Update("Original Title", "Desc", "#000", "icon", domain.HabitTypeNumeric, "", "ml", 10, 1, nil, "", 0, "", nil)
 		time.Sleep(1 * time.Millisecond)
 		return h
 	}
@@ -271,7 +349,7 @@ func TestHabit_Lifecycle(t *testing.T) {
 		originalVersion := habit.Version
 
 		err := habit.Update("New Title", "New Desc", "#FFF", "new_icon",
-			domain.HabitTypeTimer, "20:00", "kg", 20, 3, nil)
+			domain.HabitTypeTimer, "20:00", "kg", 20, 3, nil, "", 0, "", nil)
 
 		assert.Nil(t, err)
 		assert.Equal(t, "New Title", habit.Title)
@@ -282,10 +360,10 @@ func TestHabit_Lifecycle(t *testing.T) {
 
 	t.Run("Success: Clear Reminder", func(t *testing.T) {
 		habit := createStandardHabit()
-		_ = habit.Update("T", "D", "#000", "i", domain.HabitTypeBoolean, "09:00", "u", 1, 1, nil)
+		_ = habit.Update("T", "D", "#000", "i", domain.HabitTypeBoolean, "09:00", "u", 1, 1, nil, "", 0, "", nil)
 		assert.NotNil(t, habit.ReminderTime)
 
-		err := habit.Update("T", "D", "#000", "i", domain.HabitTypeBoolean, "", "u", 1, 1, nil)
+		err := habit.Update("T", "D", "#000", "i", domain.HabitTypeBoolean, "", "u", 1, 1, nil, "", 0, "", nil)
 
 		assert.Nil(t, err)
 		assert.Nil(t, habit.ReminderTime)
@@ -298,14 +376,14 @@ func TestHabit_Lifecycle(t *testing.T) {
 
 		assert.NotNil(t, habit.ArchivedAt)
 
-		err := habit.Update("Updated While Archived", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, nil)
+		err := habit.Update("Updated While Archived", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, nil, "", 0, "", nil)
 		assert.Nil(t, err, "Should allow updating archived habits")
 		assert.Equal(t, "Updated While Archived", habit.Title)
 
 		habit.Restore()
 		assert.Nil(t, habit.ArchivedAt)
 
-		err = habit.Update("Success", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, nil)
+		err = habit.Update("Success", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, nil, "", 0, "", nil)
 		assert.Nil(t, err)
 	})
 }
@@ -344,13 +422,87 @@ func TestHabit_ChangePosition(t *testing.T) {
 	})
 }
 
+func TestHabit_SetDates(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success: Set start and end dates", func(t *testing.T) {
+		h, _ := domain.NewHabit("", "Bounded", "u1")
+		end := start.AddDate(0, 0, 29)
+
+		err := h.SetDates(start, &end)
+
+		assert.Nil(t, err)
+		assert.Equal(t, start, h.StartDate)
+		assert.Equal(t, end, *h.EndDate)
+	})
+
+	t.Run("Success: End on the start date", func(t *testing.T) {
+		h, _ := domain.NewHabit("", "One Day", "u1")
+		end := start.Add(12 * time.Hour)
+
+		assert.Nil(t, h.SetDates(start, &end))
+	})
+
+	t.Run("Success: Nil end makes the habit open-ended", func(t *testing.T) {
+		h, _ := domain.NewHabit("", "Open", "u1")
+		end := start.AddDate(0, 1, 0)
+		h.EndDate = &end
+
+		assert.Nil(t, h.SetDates(start, nil))
+		assert.Nil(t, h.EndDate)
+	})
+
+	t.Run("Error: End before start", func(t *testing.T) {
+		h, _ := domain.NewHabit("", "Backwards", "u1")
+		end := start.AddDate(0, 0, -1)
+
+		err := h.SetDates(start, &end)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidDateRange)
+		assert.Nil(t, h.EndDate)
+	})
+
+	t.Run("Error: Missing start", func(t *testing.T) {
+		h, _ := domain.NewHabit("", "No Start", "u1")
+
+		assert.ErrorIs(t, h.SetDates(time.Time{}, nil), domain.ErrInvalidHabitDate)
+	})
+}
+
+func TestParseHabitDate(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	t.Run("Calendar dates are kept as midnight UTC", func(t *testing.T) {
+		got, err := domain.ParseHabitDate("2026-10-01", rome)
+
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), got)
+	})
+
+	t.Run("Timestamps give their calendar date in the given location", func(t *testing.T) {
+		got, err := domain.ParseHabitDate("2026-09-30T23:30:00Z", rome)
+
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), got)
+	})
+
+	t.Run("Rejects other formats", func(t *testing.T) {
+		_, err := domain.ParseHabitDate("01/10/2026", rome)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidHabitDate)
+	})
+}
+
 func TestHabit_DefensiveCopyAndHygiene(t *testing.T) {
 	t.Run("Safety: Update isolates Weekdays slice", func(t *testing.T) {
 		habit, _ := domain.NewHabit("", "Defensive", "u1")
 
 		inputWeekdays := []int{1, 2}
 
-		_ = habit.Update("Defensive", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, inputWeekdays)
+		_ = habit.Update("Defensive", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, inputWeekdays, "", 0, "", nil)
 
 		inputWeekdays[0] = 6
 
@@ -361,7 +513,7 @@ func TestHabit_DefensiveCopyAndHygiene(t *testing.T) {
 		habit, _ := domain.NewHabit("", "Sort", "u1")
 		inputWeekdays := []int{5, 1, 1, 3}
 
-		_ = habit.Update("Sort", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, inputWeekdays)
+		_ = habit.Update("Sort", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, inputWeekdays, "", 0, "", nil)
 
 		assert.Equal(t, []int{1, 3, 5}, habit.Weekdays, "Days must be sorted and unique")
 	})
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidHLC     = errors.New("invalid hybrid logical clock, expected <RFC3339 UTC millis>-<4 hex counter>-<node>")
+	ErrHLCTooFarAhead = errors.New("hybrid logical clock is too far in the future")
+)
+
+// DefaultMaxClockDrift is how far ahead of the server clock a client HLC may be.
+const DefaultMaxClockDrift = 5 * time.Minute
+
+const hlcWallLayout = "2006-01-02T15:04:05.000Z"
+
+var hlcPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z)-([0-9a-f]{4})-([A-Za-z0-9_-]{1,64})$`)
+
+// HLC is a hybrid logical clock timestamp stamped by the device that made a
+// change: its wall clock in milliseconds, a counter ordering changes that
+// share the same wall time, and the device node breaking ties. The text form
+// "2024-01-10T08:00:00.000Z-0001-phone" sorts lexicographically in clock
+// order, so it is stored and compared as a plain string.
+type HLC struct {
+	Wall    time.Time
+	Counter uint16
+	Node    string
+}
+
+func ParseHLC(s string) (HLC, error) {
+	m := hlcPattern.FindStringSubmatch(s)
+	if m == nil {
+		return HLC{}, ErrInvalidHLC
+	}
+
+	wall, err := time.Parse(hlcWallLayout, m[1])
+	if err != nil {
+		return HLC{}, ErrInvalidHLC
+	}
+
+	counter, err := strconv.ParseUint(m[2], 16, 16)
+	if err != nil {
+		return HLC{}, ErrInvalidHLC
+	}
+
+	return HLC{Wall: wall, Counter: uint16(counter), Node: m[3]}, nil
+}
+
+func (h HLC) String() string {
+	return fmt.Sprintf("%s-%04x-%s", h.Wall.UTC().Format(hlcWallLayout), h.Counter, h.Node)
+}
+
+// CheckDrift rejects clocks more than maxDrift ahead of now, so that a device
+// with a broken clock cannot win every last-writer-wins conflict.
+func (h HLC) CheckDrift(now time.Time, maxDrift time.Duration) error {
+	if h.Wall.After(now.Add(maxDrift)) {
+		return ErrHLCTooFarAhead
+	}
+	return nil
+}
+
+// NormalizeHLC validates a client HLC against the server clock and returns
+// its canonical form. An empty value is allowed for clients without a clock.
+func NormalizeHLC(raw string, now time.Time, maxDrift time.Duration) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	h, err := ParseHLC(raw)
+	if err != nil {
+		return "", err
+	}
+	if err := h.CheckDrift(now, maxDrift); err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
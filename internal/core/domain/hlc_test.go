@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHLC(t *testing.T) {
+	t.Run("Parse and format round-trip", func(t *testing.T) {
+		h, err := ParseHLC("2024-01-10T08:00:00.123Z-00ff-phone_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2024, 1, 10, 8, 0, 0, 123e6, time.UTC), h.Wall)
+		assert.Equal(t, uint16(255), h.Counter)
+		assert.Equal(t, "phone_1", h.Node)
+		assert.Equal(t, "2024-01-10T08:00:00.123Z-00ff-phone_1", h.String())
+	})
+
+	t.Run("Malformed clocks are rejected", func(t *testing.T) {
+		for _, raw := range []string{
+			"2024-01-10T08:00:00Z-0001-phone",
+			"2024-01-10T08:00:00.000Z-1-phone",
+			"2024-01-10T08:00:00.000Z-0001-",
+			"2024-01-10T08:00:00.000Z-0001-ph one",
+			"2024-13-10T08:00:00.000Z-0001-phone",
+		} {
+			_, err := ParseHLC(raw)
+			assert.ErrorIs(t, err, ErrInvalidHLC, raw)
+		}
+	})
+
+	t.Run("Text order is clock order", func(t *testing.T) {
+		ordered := []string{
+			"2024-01-10T08:00:00.000Z-0001-b",
+			"2024-01-10T08:00:00.000Z-0002-a",
+			"2024-01-10T08:00:00.001Z-0000-a",
+			"2025-01-01T00:00:00.000Z-0000-a",
+		}
+		for i := 1; i < len(ordered); i++ {
+			assert.Less(t, ordered[i-1], ordered[i])
+		}
+	})
+
+	t.Run("Clocks too far in the future are rejected", func(t *testing.T) {
+		now := time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+
+		_, err := NormalizeHLC(HLC{Wall: now.Add(time.Minute), Node: "a"}.String(), now, DefaultMaxClockDrift)
+		assert.NoError(t, err)
+
+		_, err = NormalizeHLC(HLC{Wall: now.Add(time.Hour), Node: "a"}.String(), now, DefaultMaxClockDrift)
+		assert.ErrorIs(t, err, ErrHLCTooFarAhead)
+
+		empty, err := NormalizeHLC("", now, DefaultMaxClockDrift)
+		assert.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	OperationCreate    = "create"
+	OperationUpdate    = "update"
+	OperationDelete    = "delete"
+	OperationRestore   = "restore"
+	OperationArchive   = "archive"
+	OperationUnarchive = "unarchive"
+	OperationStreak    = "streak"
+
+	OperationEntityHabit = "habit"
+	OperationEntityEntry = "entry"
+
+	DefaultOperationPageSize = 50
+	MaxOperationPageSize     = 200
+)
+
+// Operation is one append-only record of a write to a habit or an entry.
+// OldValue and NewValue hold the JSON of the row before and after the write;
+// creates have no old value and deletes no new one.
+type Operation struct {
+	ID         int64           `json:"id,string"`
+	UserID     string          `json:"user_id"`
+	DeviceID   string          `json:"device_id,omitempty"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	HabitID    string          `json:"habit_id"`
+	Op         string          `json:"op"`
+	OldValue   json.RawMessage `json:"old_value,omitempty" swaggertype:"object"`
+	NewValue   json.RawMessage `json:"new_value,omitempty" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// OperationQuery selects a page of a user's history, newest first. HabitID
+// narrows it to one habit and its entries; Before continues after the last
+// operation of the previous page.
+type OperationQuery struct {
+	UserID  string
+	HabitID string
+	Before  int64
+	Limit   int
+}
+
+type OperationRepository interface {
+	// Append records an operation, setting its ID and CreatedAt. Called with
+	// a transactional context, it commits or rolls back with the write it
+	// describes.
+	Append(ctx context.Context, op *Operation) error
+
+	// List returns at most q.Limit operations matching q, newest first.
+	List(ctx context.Context, q OperationQuery) ([]*Operation, error)
+}
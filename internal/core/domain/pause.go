@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrPauseNotFound      = errors.New("pause not found")
+	ErrPauseReasonTooLong = errors.New("pause reason too long (max 200 chars)")
+)
+
+// Pause suspends one habit, or every habit of the user when HabitID is
+// empty, from StartDate to EndDate included, or until further notice when
+// there is no end. Paused days are not due, do not count in the stats and
+// neither break nor extend a streak. Like those of habits, its dates are
+// calendar dates of the user, as midnight UTC.
+type Pause struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	HabitID   string     `json:"habit_id,omitempty" db:"habit_id"`
+	StartDate time.Time  `json:"start_date" db:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty" db:"end_date"`
+	Reason    string     `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func NewPause(id, userID, habitID string, start time.Time, end *time.Time, reason string) (*Pause, error) {
+	now := time.Now().UTC()
+	p := &Pause{
+		ID:        id,
+		UserID:    userID,
+		HabitID:   habitID,
+		CreatedAt: now,
+	}
+	if err := p.Update(start, end, reason); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update changes the days the pause covers and its reason. As with habits,
+// the end may fall on the start date but not before it.
+func (p *Pause) Update(start time.Time, end *time.Time, reason string) error {
+	if start.IsZero() {
+		return ErrInvalidHabitDate
+	}
+	if end != nil && storedDate(*end).Before(storedDate(start)) {
+		return ErrInvalidDateRange
+	}
+
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > 200 {
+		return ErrPauseReasonTooLong
+	}
+
+	p.StartDate = start.UTC()
+	p.EndDate = nil
+	if end != nil {
+		utcEnd := end.UTC()
+		p.EndDate = &utcEnd
+	}
+	p.Reason = reason
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// AppliesTo reports whether the pause suspends the habit: it was set on the
+// habit itself or on the whole account.
+func (p *Pause) AppliesTo(habitID string) bool {
+	return p.HabitID == "" || p.HabitID == habitID
+}
+
+// IsActiveOn reports whether the calendar date of day, read in day's
+// location, falls within the pause.
+func (p *Pause) IsActiveOn(day time.Time) bool {
+	date := civilDate(day)
+	if date.Before(storedDate(p.StartDate)) {
+		return false
+	}
+	return p.EndDate == nil || !date.After(storedDate(*p.EndDate))
+}
+
+// Pauses is the set of a user's pauses.
+type Pauses []*Pause
+
+// ForHabit returns the pauses that apply to the habit.
+func (ps Pauses) ForHabit(habitID string) Pauses {
+	var out Pauses
+	for _, p := range ps {
+		if p.AppliesTo(habitID) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Covers reports whether the habit is paused on the calendar date of day,
+// read in day's location.
+func (ps Pauses) Covers(habitID string, day time.Time) bool {
+	for _, p := range ps {
+		if p.AppliesTo(habitID) && p.IsActiveOn(day) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+type PauseRepository interface {
+	Create(ctx context.Context, pause *Pause) error
+	GetByID(ctx context.Context, id string) (*Pause, error)
+	// ListByUserID returns the user's pauses, per habit and account-wide,
+	// most recent start first.
+	ListByUserID(ctx context.Context, userID string) ([]*Pause, error)
+	Update(ctx context.Context, pause *Pause) error
+	Delete(ctx context.Context, id string) error
+}
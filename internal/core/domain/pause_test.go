@@ -0,0 +1,79 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestNewPause(t *testing.T) {
+	start := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success: Account-wide pause with an end", func(t *testing.T) {
+		end := start.AddDate(0, 0, 13)
+
+		p, err := domain.NewPause("p1", "u1", "", start, &end, "  Summer holiday ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Summer holiday", p.Reason)
+		assert.True(t, p.AppliesTo("any-habit"))
+	})
+
+	t.Run("Error: End before start", func(t *testing.T) {
+		end := start.AddDate(0, 0, -1)
+
+		_, err := domain.NewPause("p1", "u1", "", start, &end, "")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidDateRange)
+	})
+
+	t.Run("Error: Reason too long", func(t *testing.T) {
+		_, err := domain.NewPause("p1", "u1", "", start, nil, strings.Repeat("a", 201))
+
+		assert.ErrorIs(t, err, domain.ErrPauseReasonTooLong)
+	})
+}
+
+func TestPauses_Covers(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	on := func(day int) time.Time {
+		return time.Date(2026, 8, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := on(14)
+	pauses := domain.Pauses{
+		{HabitID: "", StartDate: on(1), EndDate: &end},
+		{HabitID: "run", StartDate: on(20)},
+	}
+
+	tests := []struct {
+		name    string
+		habitID string
+		day     time.Time
+		want    bool
+	}{
+		{"Account-wide pause covers every habit", "read", on(5), true},
+		{"Start and end dates are included", "read", on(14), true},
+		{"Not paused after the end", "read", on(15), false},
+		{"Habit pause covers that habit", "run", on(25), true},
+		{"Habit pause does not cover other habits", "read", on(25), false},
+		{"Dates are read in the user's timezone", "read", time.Date(2026, 7, 31, 23, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pauses.Covers(tt.habitID, tt.day.In(rome)))
+		})
+	}
+
+	assert.Len(t, pauses.ForHabit("read"), 1)
+	assert.Len(t, pauses.ForHabit("run"), 2)
+}
@@ -0,0 +1,389 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRRule  = errors.New("invalid rrule")
+	ErrInvalidExDate = errors.New("invalid exdate (must be YYYY-MM-DD)")
+)
+
+const (
+	RecurDaily   = "DAILY"
+	RecurWeekly  = "WEEKLY"
+	RecurMonthly = "MONTHLY"
+	RecurYearly  = "YEARLY"
+
+	MaxRRuleLen = 500
+	MaxExDates  = 366
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceDay is one BYDAY value: a weekday, optionally the Nth of the
+// month or year (counting from the end when N is negative).
+type RecurrenceDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Recurrence is the subset of an RFC 5545 RRULE that applies to habits,
+// which are scheduled on whole days: FREQ (DAILY, WEEKLY, MONTHLY or
+// YEARLY), INTERVAL, COUNT or UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+	ByMonth    []int
+	WeekStart  time.Weekday
+
+	count *countScan
+}
+
+// countScan is how far the occurrences of a COUNT rule have been counted
+// from start: n of them up to and including through, and the last one once
+// all Count have been found.
+type countScan struct {
+	start   time.Time
+	through time.Time
+	n       int
+	last    *time.Time
+}
+
+// ParseRRule parses an RRULE value, with or without the "RRULE:" prefix.
+func ParseRRule(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRRule)
+	}
+
+	r := &Recurrence{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case RecurDaily, RecurWeekly, RecurMonthly, RecurYearly:
+				r.Freq = value
+			default:
+				err = fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRRule, value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseRRuleInt(key, value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseRRuleInt(key, value, 1, 100000)
+		case "UNTIL":
+			r.Until, err = parseRRuleDate(value)
+		case "BYDAY":
+			r.ByDay, err = parseRRuleDays(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRRuleInts(key, value, -31, 31)
+		case "BYMONTH":
+			r.ByMonth, err = parseRRuleInts(key, value, 1, 12)
+		case "WKST":
+			weekday, ok := rruleWeekdays[value]
+			if !ok {
+				err = fmt.Errorf("%w: invalid WKST %s", ErrInvalidRRule, value)
+			}
+			r.WeekStart = weekday
+		default:
+			err = fmt.Errorf("%w: unsupported part %s", ErrInvalidRRule, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRRule)
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != RecurMonthly && r.Freq != RecurYearly {
+			return nil, fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY or YEARLY", ErrInvalidRRule)
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == RecurWeekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRRule)
+	}
+
+	return r, nil
+}
+
+func parseRRuleInt(key, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%w: invalid %s %s", ErrInvalidRRule, key, value)
+	}
+	return n, nil
+}
+
+func parseRRuleInts(key, value string, min, max int) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseRRuleInt(key, item, min, max)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("%w: invalid %s %s", ErrInvalidRRule, key, item)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func parseRRuleDays(value string) ([]RecurrenceDay, error) {
+	var out []RecurrenceDay
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRRule, item)
+		}
+		weekday, ok := rruleWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRRule, item)
+		}
+
+		day := RecurrenceDay{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRRule, item)
+			}
+			day.N = n
+		}
+		out = append(out, day)
+	}
+	return out, nil
+}
+
+// parseRRuleDate reads an UNTIL date or date-time. Habits are scheduled on
+// whole days, so only the date is kept.
+func parseRRuleDate(value string) (*time.Time, error) {
+	if len(value) < 8 {
+		return nil, fmt.Errorf("%w: invalid UNTIL %s", ErrInvalidRRule, value)
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid UNTIL %s", ErrInvalidRRule, value)
+	}
+	return &t, nil
+}
+
+// normalizeExDates validates the dates excluded from a schedule and returns
+// them sorted and deduplicated as YYYY-MM-DD. The iCalendar basic format
+// (YYYYMMDD) is accepted too.
+func normalizeExDates(dates []string) ([]string, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	if len(dates) > MaxExDates {
+		return nil, ErrInvalidExDate
+	}
+
+	seen := make(map[string]bool, len(dates))
+	out := make([]string, 0, len(dates))
+	for _, raw := range dates {
+		raw = strings.TrimSpace(raw)
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			if t, err = time.Parse("20060102", raw); err != nil {
+				return nil, ErrInvalidExDate
+			}
+		}
+		date := t.Format("2006-01-02")
+		if !seen[date] {
+			seen[date] = true
+			out = append(out, date)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// OccursOn reports whether the rule, starting on the calendar date start,
+// has an occurrence on the calendar date date. Both are read in their own
+// locations.
+func (r *Recurrence) OccursOn(start, date time.Time) bool {
+	start, date = civilDate(start), civilDate(date)
+	if date.Before(start) {
+		return false
+	}
+	if r.Until != nil && date.After(*r.Until) {
+		return false
+	}
+	if !r.matches(start, date) {
+		return false
+	}
+	return r.Count == 0 || r.withinCount(start, date)
+}
+
+// withinCount reports whether date, an occurrence of the rule, is one of the
+// first Count occurrences from start. The count is kept on the rule and
+// resumed where the previous call stopped, so checking a run of days scans
+// each of them once rather than once per day checked.
+func (r *Recurrence) withinCount(start, date time.Time) bool {
+	scan := r.count
+	if scan == nil || !scan.start.Equal(start) {
+		scan = &countScan{start: start, through: start.AddDate(0, 0, -1)}
+		r.count = scan
+	}
+
+	for scan.last == nil && scan.through.Before(date) {
+		scan.through = scan.through.AddDate(0, 0, 1)
+		if r.matches(start, scan.through) {
+			scan.n++
+			if scan.n == r.Count {
+				last := scan.through
+				scan.last = &last
+			}
+		}
+	}
+	return scan.last == nil || !date.After(*scan.last)
+}
+
+// matches applies the rule to date, ignoring COUNT and UNTIL. As in RFC 5545,
+// the parts the rule leaves out are taken from start: the weekday of weekly
+// rules, the day of monthly ones and the day and month of yearly ones.
+func (r *Recurrence) matches(start, date time.Time) bool {
+	switch r.Freq {
+	case RecurDaily:
+		if daysBetween(start, date)%r.Interval != 0 {
+			return false
+		}
+	case RecurWeekly:
+		weeks := daysBetween(r.weekOf(start), r.weekOf(date)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+	case RecurMonthly:
+		months := (date.Year()-start.Year())*12 + int(date.Month()-start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+	case RecurYearly:
+		if (date.Year()-start.Year())%r.Interval != 0 {
+			return false
+		}
+	}
+
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(date.Month())) {
+		return false
+	}
+
+	byDay, byMonthDay := r.ByDay, r.ByMonthDay
+	switch r.Freq {
+	case RecurWeekly:
+		if len(byDay) == 0 {
+			byDay = []RecurrenceDay{{Weekday: start.Weekday()}}
+		}
+	case RecurMonthly:
+		if len(byDay) == 0 && len(byMonthDay) == 0 {
+			byMonthDay = []int{start.Day()}
+		}
+	case RecurYearly:
+		if len(byDay) == 0 && len(byMonthDay) == 0 {
+			byMonthDay = []int{start.Day()}
+			if len(r.ByMonth) == 0 && date.Month() != start.Month() {
+				return false
+			}
+		}
+	}
+
+	if len(byMonthDay) > 0 && !matchesMonthDay(date, byMonthDay) {
+		return false
+	}
+	if len(byDay) > 0 && !r.matchesDay(date, byDay) {
+		return false
+	}
+	return true
+}
+
+// weekOf is the first day of the week containing date, per WKST.
+func (r *Recurrence) weekOf(date time.Time) time.Time {
+	offset := (int(date.Weekday()) - int(r.WeekStart) + 7) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// matchesDay checks BYDAY. Ordinals count within the month for monthly
+// rules and yearly rules with BYMONTH, within the year otherwise.
+func (r *Recurrence) matchesDay(date time.Time, days []RecurrenceDay) bool {
+	for _, d := range days {
+		if d.Weekday != date.Weekday() {
+			continue
+		}
+		if d.N == 0 {
+			return true
+		}
+
+		var index, length int
+		if r.Freq == RecurMonthly || len(r.ByMonth) > 0 {
+			index, length = date.Day(), daysIn(date.Year(), date.Month())
+		} else {
+			index, length = date.YearDay(), daysIn(date.Year(), 0)
+		}
+
+		if d.N > 0 && (index-1)/7+1 == d.N {
+			return true
+		}
+		if d.N < 0 && (length-index)/7+1 == -d.N {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesMonthDay(date time.Time, days []int) bool {
+	length := daysIn(date.Year(), date.Month())
+	for _, d := range days {
+		if d == date.Day() || (d < 0 && length+d+1 == date.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn is the number of days of a month, or of the whole year when month
+// is zero.
+func daysIn(year int, month time.Month) int {
+	if month == 0 {
+		return time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func containsInt(values []int, v int) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
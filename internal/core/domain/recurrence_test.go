@@ -0,0 +1,179 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestParseRRule(t *testing.T) {
+	valid := []string{
+		"FREQ=DAILY",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+		"freq=monthly;byday=1mo",
+		"FREQ=MONTHLY;BYDAY=-1FR",
+		"FREQ=MONTHLY;BYMONTHDAY=1,15,-1",
+		"FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=25",
+		"FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20261231T235959Z",
+		"FREQ=DAILY;COUNT=30;WKST=SU",
+	}
+	for _, rule := range valid {
+		t.Run("Valid: "+rule, func(t *testing.T) {
+			_, err := domain.ParseRRule(rule)
+			assert.NoError(t, err)
+		})
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=3;UNTIL=20261231",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ",
+	}
+	for _, rule := range invalid {
+		t.Run("Invalid: "+rule, func(t *testing.T) {
+			_, err := domain.ParseRRule(rule)
+			assert.ErrorIs(t, err, domain.ErrInvalidRRule)
+		})
+	}
+}
+
+func TestRecurrence_OccursOn(t *testing.T) {
+	// Tuesday 6 January 2026.
+	start := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		day  time.Time
+		want bool
+	}{
+		{"Every other Tuesday: on week 0", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", day(1, 6), true},
+		{"Every other Tuesday: skips week 1", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", day(1, 13), false},
+		{"Every other Tuesday: on week 2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", day(1, 20), true},
+		{"Every other week defaults to the start weekday", "FREQ=WEEKLY;INTERVAL=2", day(2, 3), true},
+		{"First Monday of the month", "FREQ=MONTHLY;BYDAY=1MO", day(2, 2), true},
+		{"First Monday of the month: not the second", "FREQ=MONTHLY;BYDAY=1MO", day(2, 9), false},
+		{"Last Friday of the month", "FREQ=MONTHLY;BYDAY=-1FR", day(1, 30), true},
+		{"Last Friday of the month: not the one before", "FREQ=MONTHLY;BYDAY=-1FR", day(1, 23), false},
+		{"Last day of the month", "FREQ=MONTHLY;BYMONTHDAY=-1", day(2, 28), true},
+		{"Monthly defaults to the start day", "FREQ=MONTHLY", day(3, 6), true},
+		{"Monthly defaults to the start day: other days", "FREQ=MONTHLY", day(3, 7), false},
+		{"Weekdays: Friday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", day(1, 9), true},
+		{"Weekdays: Saturday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", day(1, 10), false},
+		{"Yearly on Christmas", "FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=25", day(12, 25), true},
+		{"Yearly defaults to the start date", "FREQ=YEARLY", time.Date(2027, 1, 6, 0, 0, 0, 0, time.UTC), true},
+		{"Every third day", "FREQ=DAILY;INTERVAL=3", day(1, 9), true},
+		{"Nothing before the start", "FREQ=DAILY", day(1, 5), false},
+		{"Until is inclusive", "FREQ=DAILY;UNTIL=20260110", day(1, 10), true},
+		{"Nothing after until", "FREQ=DAILY;UNTIL=20260110", day(1, 11), false},
+		{"Count: last occurrence", "FREQ=WEEKLY;COUNT=3", day(1, 20), true},
+		{"Count: past the last occurrence", "FREQ=WEEKLY;COUNT=3", day(1, 27), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := domain.ParseRRule(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.OccursOn(start, tt.day))
+		})
+	}
+}
+
+func TestRecurrence_OccursOn_CountIsReused(t *testing.T) {
+	start := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	rule, err := domain.ParseRRule("FREQ=DAILY;INTERVAL=2;COUNT=5")
+	require.NoError(t, err)
+
+	// The fifth occurrence is 14 January; days are checked newest first, as
+	// the streak worker does, and then again oldest first.
+	var backwards, forwards []int
+	for d := 20; d >= 6; d-- {
+		if rule.OccursOn(start, time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)) {
+			backwards = append(backwards, d)
+		}
+	}
+	for d := 6; d <= 20; d++ {
+		if rule.OccursOn(start, time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)) {
+			forwards = append(forwards, d)
+		}
+	}
+	assert.Equal(t, []int{14, 12, 10, 8, 6}, backwards)
+	assert.Equal(t, []int{6, 8, 10, 12, 14}, forwards)
+
+	later := start.AddDate(0, 0, 10)
+	assert.True(t, rule.OccursOn(later, later.AddDate(0, 0, 8)), "A new start date is counted afresh")
+	assert.False(t, rule.OccursOn(later, later.AddDate(0, 0, 10)))
+}
+
+func TestHabit_RRule(t *testing.T) {
+	newHabit := func() *domain.Habit {
+		h, _ := domain.NewHabit("", "Recurring", "u1")
+		return h
+	}
+	update := func(h *domain.Habit, freqType, rrule string, exdates []string) error {
+		return h.Update("Recurring", "", "", "", domain.HabitTypeBoolean, "", "", 1, 1, nil, freqType, 0, rrule, exdates)
+	}
+
+	t.Run("Success: A rule sets the rrule frequency", func(t *testing.T) {
+		h := newHabit()
+		err := update(h, "", "rrule:freq=weekly;interval=2;byday=tu", []string{"20261225", "2026-01-01", "2026-01-01"})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.HabitFreqRRule, h.FrequencyType)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", h.RRule)
+		assert.Equal(t, []string{"2026-01-01", "2026-12-25"}, h.ExDates)
+	})
+
+	t.Run("Success: Clearing the rule falls back to the shorthand", func(t *testing.T) {
+		h := newHabit()
+		require.NoError(t, update(h, "", "FREQ=DAILY", nil))
+		require.NoError(t, update(h, "", "", nil))
+
+		assert.Equal(t, domain.HabitFreqDaily, h.FrequencyType)
+		assert.Empty(t, h.RRule)
+	})
+
+	t.Run("Fail: Invalid rule", func(t *testing.T) {
+		assert.ErrorIs(t, update(newHabit(), "", "FREQ=SECONDLY", nil), domain.ErrInvalidRRule)
+	})
+
+	t.Run("Fail: rrule frequency without a rule", func(t *testing.T) {
+		assert.ErrorIs(t, update(newHabit(), domain.HabitFreqRRule, "", nil), domain.ErrInvalidRRule)
+	})
+
+	t.Run("Fail: Another frequency with a rule", func(t *testing.T) {
+		assert.ErrorIs(t, update(newHabit(), domain.HabitFreqDaily, "FREQ=DAILY", nil), domain.ErrInvalidFrequency)
+	})
+
+	t.Run("Fail: Invalid exdate", func(t *testing.T) {
+		assert.ErrorIs(t, update(newHabit(), "", "", []string{"25/12/2026"}), domain.ErrInvalidExDate)
+	})
+
+	t.Run("Schedule: Excluded days are not due", func(t *testing.T) {
+		h := newHabit()
+		h.StartDate = time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+		require.NoError(t, update(h, "", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", []string{"2026-01-06"}))
+
+		assert.True(t, h.IsDueOn(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+		assert.False(t, h.IsDueOn(time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)))
+		assert.False(t, h.IsDueOn(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)))
+	})
+}
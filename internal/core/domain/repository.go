@@ -20,14 +20,43 @@ type HabitRepository interface {
 	// ListByUserID retrieves all habits associated with a specific user.
 	ListByUserID(ctx context.Context, userID string) ([]*Habit, error)
 
+	// ListByUserIDForUpdate retrieves all habits of a user from the database,
+	// bypassing any cache, and locks them until the enclosing transaction ends.
+	ListByUserIDForUpdate(ctx context.Context, userID string) ([]*Habit, error)
+
 	// Update modifies the state of an existing habit.
 	Update(ctx context.Context, habit *Habit) error
 
 	// Delete permanently removes a habit from the system.
 	Delete(ctx context.Context, id string) error
 
-	// GetChanges [SYNC] Returns only the deltas (changes) occurring after a specific date.
-	GetChanges(ctx context.Context, userID string, since time.Time) ([]*Habit, error)
+	// GetChanges [SYNC] Returns at most limit deltas (changes) with a change sequence greater than since,
+	// ordered by change sequence.
+	GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*Habit, error)
 
 	UpdateStreaks(ctx context.Context, id string, current, longest int) error
+
+	// GetVersion returns the habit as it was stored at the given version.
+	GetVersion(ctx context.Context, id string, version int) (*Habit, error)
+}
+
+// HabitTrashRepository gives access to soft-deleted habits that have not
+// been purged yet.
+type HabitTrashRepository interface {
+	// ListDeleted returns the user's habits deleted at or after deletedSince,
+	// most recently deleted first.
+	ListDeleted(ctx context.Context, userID string, deletedSince time.Time) ([]*Habit, error)
+
+	// Restore undeletes a habit of the user deleted at or after deletedSince,
+	// bumping its version, together with the entries deleted with or after
+	// it. It returns ErrHabitNotFound if there is no such habit.
+	Restore(ctx context.Context, id, userID string, deletedSince time.Time) (*Habit, error)
+}
+
+// EndedHabitRepository finds the habits that have run past their end date.
+type EndedHabitRepository interface {
+	// ListEnded returns up to limit habits, of any user, that are neither
+	// archived nor deleted and whose end date is before endedBefore, oldest
+	// end date first.
+	ListEnded(ctx context.Context, endedBefore time.Time, limit int) ([]*Habit, error)
 }
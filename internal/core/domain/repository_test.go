@@ -2,6 +2,7 @@ package domain_test
 
 import (
 	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 
 type InMemoryHabitRepository struct {
 	habits map[string]*domain.Habit
+	seq    int64
 }
 
 func NewInMemoryHabitRepository() *InMemoryHabitRepository {
@@ -24,6 +26,8 @@ func (r *InMemoryHabitRepository) Create(ctx context.Context, habit *domain.Habi
 	if habit.Version == 0 {
 		habit.Version = 1
 	}
+	r.seq++
+	habit.ChangeSeq = r.seq
 	r.habits[habit.ID] = habit
 	return nil
 }
@@ -66,6 +70,8 @@ func (r *InMemoryHabitRepository) Update(ctx context.Context, habit *domain.Habi
 
 	habit.Version++
 	habit.UpdatedAt = time.Now().UTC()
+	r.seq++
+	habit.ChangeSeq = r.seq
 
 	r.habits[habit.ID] = habit
 	return nil
@@ -84,18 +90,24 @@ func (r *InMemoryHabitRepository) Delete(ctx context.Context, id string) error {
 	habit.DeletedAt = &now
 	habit.UpdatedAt = now
 	habit.Version++
+	r.seq++
+	habit.ChangeSeq = r.seq
 
 	return nil
 }
 
-func (r *InMemoryHabitRepository) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.Habit, error) {
+func (r *InMemoryHabitRepository) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, error) {
 	var changes []*domain.Habit
 	for _, h := range r.habits {
-		if h.UserID == userID && h.UpdatedAt.After(since) {
+		if h.UserID == userID && h.ChangeSeq > since {
 			val := *h
 			changes = append(changes, &val)
 		}
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeSeq < changes[j].ChangeSeq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
 	return changes, nil
 }
 
@@ -142,9 +154,7 @@ func TestHabitRepository_Contract_60k(t *testing.T) {
 		h1, _ := domain.NewHabit("", "Old Habit", "user-sync")
 		deltaRepo.Create(ctx, h1)
 
-		time.Sleep(10 * time.Millisecond)
-		lastSync := time.Now().UTC()
-		time.Sleep(10 * time.Millisecond)
+		lastSync := h1.ChangeSeq
 
 		h2, _ := domain.NewHabit("", "New Habit", "user-sync")
 		deltaRepo.Create(ctx, h2)
@@ -153,7 +163,7 @@ func TestHabitRepository_Contract_60k(t *testing.T) {
 		h1_update.Title = "Updated Habit"
 		deltaRepo.Update(ctx, h1_update)
 
-		changes, err := deltaRepo.GetChanges(ctx, "user-sync", lastSync)
+		changes, err := deltaRepo.GetChanges(ctx, "user-sync", lastSync, 100)
 		require.NoError(t, err)
 
 		assert.Len(t, changes, 2)
@@ -166,7 +176,7 @@ func TestHabitRepository_Contract_60k(t *testing.T) {
 		_, err = repo.GetByID(ctx, habit.ID)
 		assert.Equal(t, domain.ErrHabitNotFound, err)
 
-		changes, err := repo.GetChanges(ctx, "user-123", time.Time{})
+		changes, err := repo.GetChanges(ctx, "user-123", 0, 100)
 		require.NoError(t, err)
 
 		var deletedHabit *domain.Habit
@@ -0,0 +1,123 @@
+package domain
+
+import "time"
+
+// DueHabit is a habit scheduled on a given day, with the progress made on
+// that day. Weekly and monthly habits also report the days completed so far
+// in their current period.
+type DueHabit struct {
+	Habit          *Habit `json:"habit"`
+	Progress       int    `json:"progress"`
+	Completed      bool   `json:"completed"`
+	PeriodProgress int    `json:"period_progress,omitempty"`
+	PeriodTarget   int    `json:"period_target,omitempty"`
+}
+
+// DueHabits lists the habits due on one calendar date of the user.
+type DueHabits struct {
+	Date      string     `json:"date"`
+	Timezone  string     `json:"timezone"`
+	Total     int        `json:"total"`
+	Completed int        `json:"completed"`
+	Habits    []DueHabit `json:"habits"`
+}
+
+// civilDate is the calendar date of t in its own location, as midnight UTC,
+// so that dates from different locations compare and subtract exactly.
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int((to.Unix() - from.Unix()) / (24 * 60 * 60))
+}
+
+// Recurrence is the rule the habit is scheduled by: its RRule, or the rule
+// its frequency type is shorthand for. Weekly and monthly habits can be done
+// on any day of their period and have none. The parsed RRule is kept on the
+// habit until the RRule changes.
+func (h *Habit) Recurrence() (*Recurrence, error) {
+	if h.RRule != "" {
+		if h.rrule == nil || h.rrule.source != h.RRule {
+			rule, err := ParseRRule(h.RRule)
+			if err != nil {
+				return nil, err
+			}
+			h.rrule = &parsedRRule{source: h.RRule, rule: rule}
+		}
+		return h.rrule.rule, nil
+	}
+
+	switch h.FrequencyType {
+	case HabitFreqWeekly, HabitFreqMonthly:
+		return nil, nil
+	case HabitFreqSpecificDays:
+		if len(h.Weekdays) == 0 {
+			break
+		}
+		days := make([]RecurrenceDay, 0, len(h.Weekdays))
+		for _, weekday := range h.Weekdays {
+			days = append(days, RecurrenceDay{Weekday: time.Weekday(weekday)})
+		}
+		return &Recurrence{Freq: RecurWeekly, Interval: 1, ByDay: days, WeekStart: time.Monday}, nil
+	case HabitFreqInterval:
+		if h.Interval > 1 {
+			return &Recurrence{Freq: RecurDaily, Interval: h.Interval}, nil
+		}
+	}
+	return &Recurrence{Freq: RecurDaily, Interval: 1}, nil
+}
+
+// IsActiveOn reports whether the calendar date of day, read in day's
+// location, falls between the habit's start and end dates, both included.
+func (h *Habit) IsActiveOn(day time.Time) bool {
+	date := civilDate(day)
+	if date.Before(storedDate(h.StartDate)) {
+		return false
+	}
+	return h.EndDate == nil || !date.After(storedDate(*h.EndDate))
+}
+
+// AcceptsEntryOn reports whether a completion on the calendar date of day,
+// read in day's location, falls in the habit's active window. A start date
+// the user never chose does not bound it, so completions recorded offline or
+// backfilled from before the habit was created are kept.
+func (h *Habit) AcceptsEntryOn(day time.Time) bool {
+	if h.StartDateSet {
+		return h.IsActiveOn(day)
+	}
+	return h.EndDate == nil || !civilDate(day).After(storedDate(*h.EndDate))
+}
+
+// IsScheduledOn reports whether the calendar date of day, read in day's
+// location, is in the habit's schedule: between its start and end dates, not
+// excluded, and an occurrence of its recurrence anchored to the start date.
+func (h *Habit) IsScheduledOn(day time.Time) bool {
+	if !h.IsActiveOn(day) {
+		return false
+	}
+	date := civilDate(day)
+	start := storedDate(h.StartDate)
+
+	dateKey := date.Format("2006-01-02")
+	for _, excluded := range h.ExDates {
+		if excluded == dateKey {
+			return false
+		}
+	}
+
+	rule, err := h.Recurrence()
+	if err != nil {
+		return false
+	}
+	return rule == nil || rule.OccursOn(start, date)
+}
+
+// IsDueOn reports whether an active habit is scheduled on the calendar date
+// of day, read in day's location.
+func (h *Habit) IsDueOn(day time.Time) bool {
+	if h.ArchivedAt != nil || h.DeletedAt != nil {
+		return false
+	}
+	return h.IsScheduledOn(day)
+}
@@ -0,0 +1,117 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+func TestHabit_IsDueOn(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	// Thursday 1 October 2026, Rome time.
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, rome)
+	on := func(day int) time.Time {
+		return time.Date(2026, 10, day, 0, 0, 0, 0, rome)
+	}
+	endDate := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	archivedAt := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		habit domain.Habit
+		day   time.Time
+		want  bool
+	}{
+		{
+			name:  "Daily: due every day",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: start},
+			day:   on(9),
+			want:  true,
+		},
+		{
+			name:  "Not due before the start date",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: start},
+			day:   time.Date(2026, 9, 30, 0, 0, 0, 0, rome),
+			want:  false,
+		},
+		{
+			name:  "Start date is read in the user's timezone",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: time.Date(2026, 9, 30, 23, 30, 0, 0, time.UTC)},
+			day:   on(1),
+			want:  true,
+		},
+		{
+			name:  "Due on the end date",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: start, EndDate: &endDate},
+			day:   on(20),
+			want:  true,
+		},
+		{
+			name:  "Not due after the end date",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: start, EndDate: &endDate},
+			day:   on(21),
+			want:  false,
+		},
+		{
+			name:  "Archived habits are never due",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqDaily, StartDate: start, ArchivedAt: &archivedAt},
+			day:   on(9),
+			want:  false,
+		},
+		{
+			name:  "Specific days: due on a listed weekday",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{1, 3}, StartDate: start},
+			day:   on(7),
+			want:  true,
+		},
+		{
+			name:  "Specific days: not due on other weekdays",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{1, 3}, StartDate: start},
+			day:   on(8),
+			want:  false,
+		},
+		{
+			name:  "Interval: due on the start date",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqInterval, Interval: 3, StartDate: start},
+			day:   on(1),
+			want:  true,
+		},
+		{
+			name:  "Interval: due every third day from the start date",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqInterval, Interval: 3, StartDate: start},
+			day:   on(31),
+			want:  true,
+		},
+		{
+			name:  "Interval: not due in between",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqInterval, Interval: 3, StartDate: start},
+			day:   on(5),
+			want:  false,
+		},
+		{
+			name:  "Interval: anchored across the DST change",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqInterval, Interval: 2, StartDate: start},
+			day:   time.Date(2026, 10, 27, 0, 0, 0, 0, rome),
+			want:  true,
+		},
+		{
+			name:  "Weekly: due on any day",
+			habit: domain.Habit{FrequencyType: domain.HabitFreqWeekly, TimesPerPeriod: 3, StartDate: start},
+			day:   on(11),
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.habit.IsDueOn(tt.day))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "context"
+
+// SnapshotWriter receives a user's live data, as read by a SnapshotRepository.
+// Begin is called once with the sync cursor the data is current as of, before
+// any habit or entry.
+type SnapshotWriter interface {
+	Begin(cursor int64) error
+	Habit(h *Habit) error
+	Entry(e *HabitEntry) error
+}
+
+type SnapshotRepository interface {
+	// Snapshot streams every live habit and entry of the user to w from a
+	// single consistent read, so that delta sync can resume from the cursor
+	// passed to Begin without gaps or duplicates.
+	Snapshot(ctx context.Context, userID string, w SnapshotWriter) error
+}
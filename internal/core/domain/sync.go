@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidSyncOp     = errors.New("invalid sync operation (must be create, update, or delete)")
+	ErrSyncCursorExpired = errors.New("sync cursor expired, full resync required")
+)
+
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+
+	DefaultSyncPageSize = 500
+	MaxSyncPageSize     = 1000
+)
+
+type HabitSyncResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Habit  *Habit `json:"habit,omitempty"`
+}
+
+type EntrySyncResult struct {
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Entry  *HabitEntry `json:"entry,omitempty"`
+}
+
+type SyncChanges struct {
+	Habits  []*Habit      `json:"habits"`
+	Entries []*HabitEntry `json:"entries"`
+}
+
+// SyncResult carries the highest change sequence seen so far as an opaque
+// cursor. Clients send it back unchanged on the next sync, and keep syncing
+// while HasMore is set.
+type SyncResult struct {
+	Habits  []HabitSyncResult `json:"habits"`
+	Entries []EntrySyncResult `json:"entries"`
+	Changes SyncChanges       `json:"changes"`
+	Cursor  int64             `json:"cursor,string"`
+	HasMore bool              `json:"has_more"`
+}
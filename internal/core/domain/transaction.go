@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type Transactor interface {
+	// WithinTransaction runs fn inside a single database transaction.
+	// Repositories invoked with the context passed to fn take part in it.
+	// Nested calls are isolated with a savepoint, so a failing inner unit
+	// of work can be rolled back without aborting the outer one.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit defers fn, a side effect such as a notification, until
+	// the transaction in ctx has committed. It is dropped if that
+	// transaction, or the savepoint it was registered in, rolls back.
+	// Outside of a transaction fn runs immediately.
+	AfterCommit(ctx context.Context, fn func())
+}
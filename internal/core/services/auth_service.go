@@ -11,12 +11,14 @@ import (
 type AuthService struct {
 	repo         domain.UserRepository
 	tokenService *TokenService
+	devices      *DeviceService
 }
 
-func NewAuthService(repo domain.UserRepository, tokenService *TokenService) *AuthService {
+func NewAuthService(repo domain.UserRepository, tokenService *TokenService, devices *DeviceService) *AuthService {
 	return &AuthService{
 		repo:         repo,
 		tokenService: tokenService,
+		devices:      devices,
 	}
 }
 
@@ -46,11 +48,15 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*domai
 type LoginInput struct {
 	Email    string
 	Password string
+	// Device, when set, registers the device the user logs in from and
+	// binds the issued token to it.
+	Device *RegisterDeviceInput
 }
 
 type LoginOutput struct {
-	Token string
-	User  *domain.User
+	Token  string
+	User   *domain.User
+	Device *domain.Device
 }
 
 func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginOutput, error) {
@@ -63,14 +69,31 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginOutput
 		return nil, domain.ErrInvalidCredentials
 	}
 
-	token, err := s.tokenService.GenerateToken(user.ID)
+	var device *domain.Device
+	if input.Device != nil && s.devices != nil {
+		deviceInput := *input.Device
+		deviceInput.UserID = user.ID
+
+		device, err = s.devices.Register(ctx, deviceInput)
+		if err != nil {
+			return nil, fmt.Errorf("auth service: device registration failed: %w", err)
+		}
+	}
+
+	deviceID := ""
+	if device != nil {
+		deviceID = device.ID
+	}
+
+	token, err := s.tokenService.GenerateDeviceToken(user.ID, deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("auth service: failed to generate token: %w", err)
 	}
 
 	return &LoginOutput{
-		Token: token,
-		User:  user,
+		Token:  token,
+		User:   user,
+		Device: device,
 	}, nil
 }
 
@@ -46,8 +46,8 @@ func TestAuthService_Register(t *testing.T) {
 
 	setup := func() (*AuthService, *MockUserRepository) {
 		mockRepo := new(MockUserRepository)
-		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo)
-		return NewAuthService(mockRepo, tokenService), mockRepo
+		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo, nil)
+		return NewAuthService(mockRepo, tokenService, nil), mockRepo
 	}
 
 	t.Run("Success: Should register a valid user", func(t *testing.T) {
@@ -126,8 +126,8 @@ func TestAuthService_Login(t *testing.T) {
 
 	setup := func() (*AuthService, *MockUserRepository, *TokenService) {
 		mockRepo := new(MockUserRepository)
-		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo)
-		return NewAuthService(mockRepo, tokenService), mockRepo, tokenService
+		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo, nil)
+		return NewAuthService(mockRepo, tokenService, nil), mockRepo, tokenService
 	}
 
 	getValidUser := func() *domain.User {
@@ -198,8 +198,8 @@ func TestAuthService_DeleteAccount(t *testing.T) {
 
 	setup := func() (*AuthService, *MockUserRepository) {
 		mockRepo := new(MockUserRepository)
-		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo)
-		return NewAuthService(mockRepo, tokenService), mockRepo
+		tokenService := NewTokenService("test-secret", "test-issuer", 1*time.Hour, mockRepo, nil)
+		return NewAuthService(mockRepo, tokenService, nil), mockRepo
 	}
 
 	t.Run("Success: Should delete account successfully", func(t *testing.T) {
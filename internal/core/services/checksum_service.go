@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type ChecksumService struct {
+	repo domain.ChecksumRepository
+}
+
+func NewChecksumService(repo domain.ChecksumRepository) *ChecksumService {
+	return &ChecksumService{
+		repo: repo,
+	}
+}
+
+// Checksum digests the user's data so that a client can check its local copy
+// bucket by bucket. When month is set, the items of that bucket are listed
+// too, for the client to find the rows it has to fetch again.
+func (s *ChecksumService) Checksum(ctx context.Context, userID, month string) (*domain.SyncChecksum, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	if month != "" {
+		var err error
+		if month, err = domain.ParseChecksumMonth(month); err != nil {
+			return nil, err
+		}
+	}
+
+	cursor, items, err := s.repo.ChecksumItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.BuildChecksum(cursor, items, month), nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+// ConflictPolicy decides what happens to an update made from a stale version.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyVersion merges stale habit updates field by field and
+	// rejects stale entry updates. It is the default.
+	ConflictPolicyVersion ConflictPolicy = "version"
+
+	// ConflictPolicyLastWriterWins applies a stale update if its HLC is newer
+	// than the one stored, and rejects it otherwise. Updates without an HLC
+	// fall back to the version policy.
+	ConflictPolicyLastWriterWins ConflictPolicy = "lww"
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case "", ConflictPolicyVersion:
+		return ConflictPolicyVersion, nil
+	case ConflictPolicyLastWriterWins:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (must be %s or %s)", s, ConflictPolicyVersion, ConflictPolicyLastWriterWins)
+}
+
+// ClockPolicy configures how the hybrid logical clocks sent by clients are
+// validated and whether they resolve conflicts. The zero value validates
+// against domain.DefaultMaxClockDrift and keeps the version policy.
+type ClockPolicy struct {
+	Conflicts ConflictPolicy
+	MaxDrift  time.Duration
+}
+
+// normalize validates a client HLC and returns its canonical form.
+func (p ClockPolicy) normalize(raw string) (string, error) {
+	maxDrift := p.MaxDrift
+	if maxDrift <= 0 {
+		maxDrift = domain.DefaultMaxClockDrift
+	}
+	return domain.NormalizeHLC(raw, time.Now().UTC(), maxDrift)
+}
+
+// lastWriterWins settles a stale update by HLC. decided is false when the
+// version policy applies instead; otherwise wins reports whether the update
+// is newer than the stored row. Rows written without an HLC are older than
+// any clocked update.
+func (p ClockPolicy) lastWriterWins(submitted, stored string) (decided, wins bool) {
+	if p.Conflicts != ConflictPolicyLastWriterWins || submitted == "" {
+		return false, false
+	}
+	// Canonical HLCs sort lexicographically in clock order.
+	return true, submitted > stored
+}
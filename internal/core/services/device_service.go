@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type DeviceService struct {
+	repo domain.DeviceRepository
+}
+
+func NewDeviceService(repo domain.DeviceRepository) *DeviceService {
+	return &DeviceService{
+		repo: repo,
+	}
+}
+
+type RegisterDeviceInput struct {
+	ID       string
+	UserID   string
+	Name     string
+	Platform string
+}
+
+// Register records the device a user logs in from. A known device is
+// refreshed; an ID that is unknown, missing or owned by another user gets a
+// new registration. Revoked devices cannot register again.
+func (s *DeviceService) Register(ctx context.Context, input RegisterDeviceInput) (*domain.Device, error) {
+	if input.ID != "" {
+		existing, err := s.repo.GetByID(ctx, input.ID)
+		switch {
+		case err == nil && existing.UserID == input.UserID:
+			return s.refresh(ctx, existing, input)
+		case err == nil:
+			input.ID = ""
+		case !errors.Is(err, domain.ErrDeviceNotFound):
+			return nil, err
+		}
+	}
+
+	if input.ID == "" {
+		input.ID = uuid.NewString()
+	}
+
+	device, err := domain.NewDevice(input.ID, input.UserID, input.Name, input.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *DeviceService) refresh(ctx context.Context, device *domain.Device, input RegisterDeviceInput) (*domain.Device, error) {
+	if device.IsRevoked() {
+		return nil, domain.ErrDeviceRevoked
+	}
+
+	if input.Name != "" {
+		if err := device.Rename(input.Name); err != nil {
+			return nil, err
+		}
+	}
+	if input.Platform != "" {
+		device.Platform = input.Platform
+	}
+	device.LastSeenAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *DeviceService) GetByID(ctx context.Context, id string, userID string) (*domain.Device, error) {
+	device, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if device.UserID != userID {
+		return nil, domain.ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+func (s *DeviceService) List(ctx context.Context, userID string) ([]*domain.Device, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+func (s *DeviceService) Rename(ctx context.Context, id string, userID string, name string) (*domain.Device, error) {
+	device, err := s.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := device.Rename(name); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Revoke permanently cuts a device off: its tokens stop being accepted and
+// it no longer holds back the tombstone GC.
+func (s *DeviceService) Revoke(ctx context.Context, id string, userID string) error {
+	device, err := s.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if device.IsRevoked() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	device.RevokedAt = &now
+
+	return s.repo.Update(ctx, device)
+}
+
+// Acknowledge records the sync cursor a device reported, meaning it holds
+// every change up to it.
+func (s *DeviceService) Acknowledge(ctx context.Context, id string, cursor int64) error {
+	return s.repo.AcknowledgeCursor(ctx, id, cursor, time.Now().UTC())
+}
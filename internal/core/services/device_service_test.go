@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type MockDeviceRepo struct {
+	store map[string]*domain.Device
+}
+
+func NewMockDeviceRepo() *MockDeviceRepo {
+	return &MockDeviceRepo{store: make(map[string]*domain.Device)}
+}
+
+func (m *MockDeviceRepo) Create(ctx context.Context, d *domain.Device) error {
+	clone := *d
+	m.store[d.ID] = &clone
+	return nil
+}
+
+func (m *MockDeviceRepo) GetByID(ctx context.Context, id string) (*domain.Device, error) {
+	d, ok := m.store[id]
+	if !ok {
+		return nil, domain.ErrDeviceNotFound
+	}
+	clone := *d
+	return &clone, nil
+}
+
+func (m *MockDeviceRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Device, error) {
+	var out []*domain.Device
+	for _, d := range m.store {
+		if d.UserID == userID {
+			clone := *d
+			out = append(out, &clone)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockDeviceRepo) Update(ctx context.Context, d *domain.Device) error {
+	existing, ok := m.store[d.ID]
+	if !ok {
+		return domain.ErrDeviceNotFound
+	}
+	existing.Name = d.Name
+	existing.Platform = d.Platform
+	existing.LastSeenAt = d.LastSeenAt
+	existing.RevokedAt = d.RevokedAt
+	return nil
+}
+
+func (m *MockDeviceRepo) AcknowledgeCursor(ctx context.Context, id string, cursor int64, at time.Time) error {
+	d, ok := m.store[id]
+	if !ok || d.IsRevoked() {
+		return domain.ErrDeviceNotFound
+	}
+	d.LastCursor = max(d.LastCursor, cursor)
+	d.LastSyncedAt = &at
+	d.LastSeenAt = at
+	return nil
+}
+
+func TestDeviceService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Register: Creates a device with a server ID when none is sent", func(t *testing.T) {
+		svc := NewDeviceService(NewMockDeviceRepo())
+
+		d, err := svc.Register(ctx, RegisterDeviceInput{UserID: "user-1", Name: " Pixel 8 ", Platform: "android"})
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, d.ID)
+		assert.Equal(t, "Pixel 8", d.Name)
+		assert.Equal(t, "android", d.Platform)
+	})
+
+	t.Run("Register: A known device is refreshed, not duplicated", func(t *testing.T) {
+		repo := NewMockDeviceRepo()
+		svc := NewDeviceService(repo)
+
+		first, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1", Name: "Phone"})
+		require.NoError(t, err)
+
+		again, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, again.ID)
+		assert.Equal(t, "Phone", again.Name, "An empty name keeps the current one")
+		assert.Len(t, repo.store, 1)
+	})
+
+	t.Run("Register: An ID owned by another user gets a fresh device", func(t *testing.T) {
+		repo := NewMockDeviceRepo()
+		svc := NewDeviceService(repo)
+
+		_, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		require.NoError(t, err)
+
+		d, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-2"})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, "device-1", d.ID)
+		assert.Equal(t, "user-1", repo.store["device-1"].UserID)
+	})
+
+	t.Run("Register: Revoked devices cannot come back", func(t *testing.T) {
+		svc := NewDeviceService(NewMockDeviceRepo())
+
+		_, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		require.NoError(t, err)
+		require.NoError(t, svc.Revoke(ctx, "device-1", "user-1"))
+
+		_, err = svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		assert.ErrorIs(t, err, domain.ErrDeviceRevoked)
+	})
+
+	t.Run("Rename and Revoke: Only the owner can manage a device", func(t *testing.T) {
+		svc := NewDeviceService(NewMockDeviceRepo())
+
+		_, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		require.NoError(t, err)
+
+		_, err = svc.Rename(ctx, "device-1", "user-2", "Stolen")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+		assert.ErrorIs(t, svc.Revoke(ctx, "device-1", "user-2"), domain.ErrDeviceNotFound)
+
+		renamed, err := svc.Rename(ctx, "device-1", "user-1", "Work laptop")
+		require.NoError(t, err)
+		assert.Equal(t, "Work laptop", renamed.Name)
+	})
+
+	t.Run("Acknowledge: The cursor never moves backwards", func(t *testing.T) {
+		repo := NewMockDeviceRepo()
+		svc := NewDeviceService(repo)
+
+		_, err := svc.Register(ctx, RegisterDeviceInput{ID: "device-1", UserID: "user-1"})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Acknowledge(ctx, "device-1", 42))
+		require.NoError(t, svc.Acknowledge(ctx, "device-1", 7))
+
+		assert.Equal(t, int64(42), repo.store["device-1"].LastCursor)
+		assert.NotNil(t, repo.store["device-1"].LastSyncedAt)
+	})
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type entryBatchKey struct{}
+
+// entryBatch spans several entry writes applied in one transaction. It
+// remembers the habits already loaded for the ownership check, and collects
+// the habits whose streaks need recomputing so that each gets a single job.
+type entryBatch struct {
+	habits  map[string]habitLookup
+	streaks []string
+	queued  map[string]bool
+}
+
+type habitLookup struct {
+	habit *domain.Habit
+	err   error
+}
+
+func withEntryBatch(ctx context.Context) (context.Context, *entryBatch) {
+	batch := &entryBatch{
+		habits: make(map[string]habitLookup),
+		queued: make(map[string]bool),
+	}
+	return context.WithValue(ctx, entryBatchKey{}, batch), batch
+}
+
+func entryBatchFromContext(ctx context.Context) *entryBatch {
+	batch, _ := ctx.Value(entryBatchKey{}).(*entryBatch)
+	return batch
+}
+
+// addStreak queues a streak recompute for habitID unless one is already
+// queued.
+func (b *entryBatch) addStreak(habitID string) {
+	if b.queued[habitID] {
+		return
+	}
+	b.queued[habitID] = true
+	b.streaks = append(b.streaks, habitID)
+}
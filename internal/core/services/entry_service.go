@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/workers"
 )
@@ -12,22 +17,34 @@ type EntryService struct {
 	repo      domain.HabitEntryRepository
 	habitRepo domain.HabitRepository
 	worker    *workers.StreakWorker
+	notifier  domain.ChangeNotifier
+	clock     ClockPolicy
+	ops       operationLog
+	tx        domain.Transactor
 }
 
-func NewEntryService(repo domain.HabitEntryRepository, habitRepo domain.HabitRepository, worker *workers.StreakWorker) *EntryService {
+func NewEntryService(repo domain.HabitEntryRepository, habitRepo domain.HabitRepository, worker *workers.StreakWorker, notifier domain.ChangeNotifier, opts WriteOptions) *EntryService {
 	return &EntryService{
 		repo:      repo,
 		habitRepo: habitRepo,
 		worker:    worker,
+		notifier:  notifier,
+		clock:     opts.Clock,
+		ops:       operationLog{repo: opts.Operations},
+		tx:        transactorOrNone(opts.Tx),
 	}
 }
 
 type CreateEntryInput struct {
+	// ID is the client-generated UUID of the entry; the server generates
+	// one when empty.
+	ID             string
 	HabitID        string
 	UserID         string
 	CompletionDate time.Time
 	Value          int
 	Notes          string
+	HLC            string
 }
 
 type UpdateEntryInput struct {
@@ -36,58 +53,217 @@ type UpdateEntryInput struct {
 	Value   int
 	Notes   string
 	Version int
+	HLC     string
 }
 
+// Create is idempotent on client IDs: repeating a create returns the stored
+// entry, and creating an entry the user deleted brings it back.
 func (s *EntryService) Create(ctx context.Context, input CreateEntryInput) (*domain.HabitEntry, error) {
+	var entry *domain.HabitEntry
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		entry, err = s.create(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *EntryService) create(ctx context.Context, input CreateEntryInput) (*domain.HabitEntry, error) {
 	entry := domain.NewHabitEntry(input.HabitID, input.UserID, input.CompletionDate, input.Value)
 	entry.Notes = input.Notes
 
+	entry.ID = input.ID
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	} else if _, err := uuid.Parse(entry.ID); err != nil {
+		return nil, fmt.Errorf("%w: id must be a UUID", domain.ErrInvalidEntry)
+	}
+
 	if err := entry.Validate(); err != nil {
 		return nil, err
 	}
 
-	habit, err := s.habitRepo.GetByID(ctx, entry.HabitID)
+	hlc, err := s.clock.normalize(input.HLC)
 	if err != nil {
 		return nil, err
 	}
-	if habit.UserID != entry.UserID {
-		return nil, domain.ErrUnauthorized
+	entry.HLC = hlc
+
+	habit, err := s.checkHabitOwner(ctx, entry.HabitID, entry.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// The date is checked as the client sent it, in its own offset, since
+	// the habit's dates are calendar dates of the user.
+	if !habit.AcceptsEntryOn(input.CompletionDate) {
+		return nil, domain.ErrEntryOutsideHabitDates
 	}
 
 	if err := s.repo.Create(ctx, entry); err != nil {
+		if errors.Is(err, domain.ErrEntryConflict) && input.ID != "" {
+			return s.existingOrResurrected(ctx, entry)
+		}
 		return nil, err
 	}
 
-	s.worker.Enqueue(entry.HabitID)
+	if err := s.ops.record(ctx, entryOperation(entry, domain.OperationCreate), nil, entry); err != nil {
+		return nil, err
+	}
+
+	s.enqueueStreak(ctx, entry.HabitID)
+	notifyChange(ctx, s.tx, s.notifier, entry.UserID)
 
 	return entry, nil
 }
 
+// checkHabitOwner makes sure the habit exists and belongs to userID. Within
+// a batch each habit is loaded only once.
+func (s *EntryService) checkHabitOwner(ctx context.Context, habitID, userID string) (*domain.Habit, error) {
+	batch := entryBatchFromContext(ctx)
+
+	var lookup habitLookup
+	cached := false
+	if batch != nil {
+		lookup, cached = batch.habits[habitID]
+	}
+	if !cached {
+		lookup.habit, lookup.err = s.habitRepo.GetByID(ctx, habitID)
+		if batch != nil && (lookup.err == nil || errors.Is(lookup.err, domain.ErrHabitNotFound)) {
+			batch.habits[habitID] = lookup
+		}
+	}
+
+	if lookup.err != nil {
+		return nil, lookup.err
+	}
+	if lookup.habit.UserID != userID {
+		return nil, domain.ErrUnauthorized
+	}
+	return lookup.habit, nil
+}
+
+// existingOrResurrected resolves a create whose ID is already taken. A live
+// entry of the same user is a retried create and is returned unchanged; a
+// deleted one is overwritten with the new data. IDs belonging to another
+// user are a conflict.
+func (s *EntryService) existingOrResurrected(ctx context.Context, entry *domain.HabitEntry) (*domain.HabitEntry, error) {
+	existing, err := s.repo.GetByID(ctx, entry.ID)
+	if err == nil {
+		if existing.UserID != entry.UserID {
+			return nil, domain.ErrEntryConflict
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, domain.ErrEntryNotFound) {
+		return nil, err
+	}
+
+	if err := s.repo.Resurrect(ctx, entry); err != nil {
+		if errors.Is(err, domain.ErrEntryNotFound) {
+			return nil, domain.ErrEntryConflict
+		}
+		return nil, err
+	}
+
+	if err := s.ops.record(ctx, entryOperation(entry, domain.OperationCreate), nil, entry); err != nil {
+		return nil, err
+	}
+
+	s.enqueueStreak(ctx, entry.HabitID)
+	notifyChange(ctx, s.tx, s.notifier, entry.UserID)
+
+	return entry, nil
+}
+
+func entryOperation(e *domain.HabitEntry, op string) *domain.Operation {
+	return &domain.Operation{
+		UserID:     e.UserID,
+		EntityType: domain.OperationEntityEntry,
+		EntityID:   e.ID,
+		HabitID:    e.HabitID,
+		Op:         op,
+	}
+}
+
 func (s *EntryService) Update(ctx context.Context, input UpdateEntryInput) (*domain.HabitEntry, error) {
+	var entry *domain.HabitEntry
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		entry, err = s.update(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *EntryService) update(ctx context.Context, input UpdateEntryInput) (*domain.HabitEntry, error) {
+	hlc, err := s.clock.normalize(input.HLC)
+	if err != nil {
+		return nil, err
+	}
+
 	existing, err := s.GetByID(ctx, input.ID, input.UserID)
 	if err != nil {
 		return nil, err
 	}
 
 	if input.Version > 0 && existing.Version != input.Version {
-		return nil, domain.ErrEntryConflict
+		if _, wins := s.clock.lastWriterWins(hlc, existing.HLC); !wins {
+			return nil, &domain.EntryConflictError{Server: existing, Diff: diffEntryFields(existing, input)}
+		}
+	}
+
+	before, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
 	}
 
 	existing.Value = input.Value
 	existing.Notes = input.Notes
+	if hlc != "" {
+		existing.HLC = hlc
+	}
 
 	existing.Version++
 	existing.UpdatedAt = time.Now().UTC()
 
 	if err := s.repo.Update(ctx, existing); err != nil {
+		if errors.Is(err, domain.ErrEntryConflict) {
+			if current, ferr := s.repo.GetByID(ctx, input.ID); ferr == nil {
+				return nil, &domain.EntryConflictError{Server: current, Diff: diffEntryFields(current, input)}
+			}
+		}
 		return nil, err
 	}
 
-	s.worker.Enqueue(existing.HabitID)
+	if err := s.ops.record(ctx, entryOperation(existing, domain.OperationUpdate), json.RawMessage(before), existing); err != nil {
+		return nil, err
+	}
+
+	s.enqueueStreak(ctx, existing.HabitID)
+	notifyChange(ctx, s.tx, s.notifier, existing.UserID)
 
 	return existing, nil
 }
 
+// diffEntryFields lists the submitted fields whose value differs from the
+// stored entry.
+func diffEntryFields(stored *domain.HabitEntry, input UpdateEntryInput) []domain.FieldDiff {
+	var diff []domain.FieldDiff
+	if stored.Value != input.Value {
+		diff = append(diff, domain.FieldDiff{Field: "value", Client: input.Value, Server: stored.Value})
+	}
+	if stored.Notes != input.Notes {
+		diff = append(diff, domain.FieldDiff{Field: "notes", Client: input.Notes, Server: stored.Notes})
+	}
+	return diff
+}
+
 func (s *EntryService) GetByID(ctx context.Context, id string, userID string) (*domain.HabitEntry, error) {
 	entry, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -112,6 +288,12 @@ func (s *EntryService) ListByHabitID(ctx context.Context, habitID string, userID
 }
 
 func (s *EntryService) Delete(ctx context.Context, id string, userID string) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, id, userID)
+	})
+}
+
+func (s *EntryService) delete(ctx context.Context, id string, userID string) error {
 	entry, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -127,11 +309,38 @@ func (s *EntryService) Delete(ctx context.Context, id string, userID string) err
 		return err
 	}
 
-	s.worker.Enqueue(habitID)
+	if err := s.ops.record(ctx, entryOperation(entry, domain.OperationDelete), entry, nil); err != nil {
+		return err
+	}
+
+	s.enqueueStreak(ctx, habitID)
+	notifyChange(ctx, s.tx, s.notifier, userID)
 
 	return nil
 }
 
-func (s *EntryService) GetDelta(ctx context.Context, userID string, since time.Time) ([]*domain.HabitEntry, error) {
-	return s.repo.GetChanges(ctx, userID, since)
+func (s *EntryService) enqueueStreak(ctx context.Context, habitID string) {
+	s.tx.AfterCommit(ctx, func() {
+		if batch := entryBatchFromContext(ctx); batch != nil {
+			batch.addStreak(habitID)
+			return
+		}
+		s.worker.Enqueue(habitID)
+	})
+}
+
+// GetDelta returns one page of changes after since and reports whether more
+// changes are waiting past it.
+func (s *EntryService) GetDelta(ctx context.Context, userID string, since int64, limit int) ([]*domain.HabitEntry, bool, error) {
+	limit = syncPageSize(limit)
+
+	entries, err := s.repo.GetChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(entries) > limit {
+		return entries[:limit], true, nil
+	}
+	return entries, false, nil
 }
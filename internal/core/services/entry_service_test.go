@@ -33,6 +33,11 @@ func (m *MockHabitEntryRepo) Delete(ctx context.Context, id string, userID strin
 	return args.Error(0)
 }
 
+func (m *MockHabitEntryRepo) Resurrect(ctx context.Context, entry *domain.HabitEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
 func (m *MockHabitEntryRepo) GetByID(ctx context.Context, id string) (*domain.HabitEntry, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -57,8 +62,8 @@ func (m *MockHabitEntryRepo) ListByHabitIDWithRange(ctx context.Context, habitID
 	return args.Get(0).([]*domain.HabitEntry), args.Error(1)
 }
 
-func (m *MockHabitEntryRepo) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.HabitEntry, error) {
-	args := m.Called(ctx, userID, since)
+func (m *MockHabitEntryRepo) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.HabitEntry, error) {
+	args := m.Called(ctx, userID, since, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -89,6 +94,10 @@ func (m *MockHabitRepo) Create(ctx context.Context, h *domain.Habit) error {
 	return nil
 }
 
+func (m *MockHabitRepo) ListByUserIDForUpdate(ctx context.Context, u string) ([]*domain.Habit, error) {
+	return m.ListByUserID(ctx, u)
+}
+
 func (m *MockHabitRepo) ListByUserID(ctx context.Context, u string) ([]*domain.Habit, error) {
 	args := m.Called(ctx, u)
 	if args.Get(0) == nil {
@@ -99,7 +108,7 @@ func (m *MockHabitRepo) ListByUserID(ctx context.Context, u string) ([]*domain.H
 
 func (m *MockHabitRepo) Update(ctx context.Context, h *domain.Habit) error { return nil }
 func (m *MockHabitRepo) Delete(ctx context.Context, id string) error       { return nil }
-func (m *MockHabitRepo) GetChanges(ctx context.Context, u string, t time.Time) ([]*domain.Habit, error) {
+func (m *MockHabitRepo) GetChanges(ctx context.Context, u string, since int64, limit int) ([]*domain.Habit, error) {
 	return nil, nil
 }
 
@@ -107,8 +116,12 @@ func (m *MockHabitRepo) UpdateStreaks(ctx context.Context, id string, current, l
 	return nil
 }
 
+func (m *MockHabitRepo) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	return nil, domain.ErrHabitVersionNotFound
+}
+
 func getTestWorker() *workers.StreakWorker {
-	return workers.NewStreakWorker(nil, nil)
+	return workers.NewStreakWorker(nil, nil, workers.StreakOptions{})
 }
 
 func TestEntryService_Create(t *testing.T) {
@@ -122,7 +135,7 @@ func TestEntryService_Create(t *testing.T) {
 		habitRepo := new(MockHabitRepo)
 		worker := getTestWorker()
 
-		svc := services.NewEntryService(entryRepo, habitRepo, worker)
+		svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 
 		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
 
@@ -149,7 +162,7 @@ func TestEntryService_Create(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		habitRepo := new(MockHabitRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, habitRepo, worker)
+		svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 
 		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: "hacker-target"}, nil)
 
@@ -166,7 +179,7 @@ func TestEntryService_Create(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		habitRepo := new(MockHabitRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, habitRepo, worker)
+		svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 
 		habitRepo.On("GetByID", ctx, hid).Return(nil, domain.ErrHabitNotFound)
 
@@ -175,6 +188,118 @@ func TestEntryService_Create(t *testing.T) {
 
 		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
 	})
+
+	t.Run("Fail: Should reject completions outside the habit's dates", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		end := now.AddDate(0, 0, 7)
+		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid, StartDate: now, StartDateSet: true, EndDate: &end}, nil)
+
+		for _, date := range []time.Time{now.AddDate(0, 0, -1), end.AddDate(0, 0, 1)} {
+			_, err := svc.Create(ctx, services.CreateEntryInput{HabitID: hid, UserID: uid, CompletionDate: date, Value: 1})
+			assert.ErrorIs(t, err, domain.ErrEntryOutsideHabitDates)
+			assert.ErrorIs(t, err, domain.ErrInvalidEntry)
+		}
+		entryRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Success: Should keep completions from before a default start date", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		habit, _ := domain.NewHabit(hid, "Read", uid)
+		habitRepo.On("GetByID", ctx, hid).Return(habit, nil)
+		entryRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+		backfilled := habit.StartDate.AddDate(0, 0, -3)
+		created, err := svc.Create(ctx, services.CreateEntryInput{HabitID: hid, UserID: uid, CompletionDate: backfilled, Value: 1})
+		require.NoError(t, err, "A completion recorded offline before the habit reached the server is not lost")
+		assert.True(t, created.CompletionDate.Equal(backfilled))
+	})
+
+	t.Run("Success: Should keep the client-generated ID", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		clientID := "0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90"
+		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
+		entryRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.HabitEntry) bool { return e.ID == clientID })).Return(nil)
+
+		created, err := svc.Create(ctx, services.CreateEntryInput{ID: clientID, HabitID: hid, UserID: uid, CompletionDate: now, Value: 1})
+		require.NoError(t, err)
+		assert.Equal(t, clientID, created.ID)
+	})
+
+	t.Run("Idempotency: Should return the stored entry when the create is retried", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		clientID := "0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90"
+		stored := &domain.HabitEntry{ID: clientID, HabitID: hid, UserID: uid, Value: 1, Version: 2}
+		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
+		entryRepo.On("Create", ctx, mock.Anything).Return(domain.ErrEntryConflict)
+		entryRepo.On("GetByID", ctx, clientID).Return(stored, nil)
+
+		created, err := svc.Create(ctx, services.CreateEntryInput{ID: clientID, HabitID: hid, UserID: uid, CompletionDate: now, Value: 5})
+		require.NoError(t, err)
+		assert.Equal(t, stored, created, "A retry must not overwrite the stored entry")
+		entryRepo.AssertNotCalled(t, "Resurrect", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Resurrection: Should bring back an entry deleted on the server", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		clientID := "0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90"
+		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
+		entryRepo.On("Create", ctx, mock.Anything).Return(domain.ErrEntryConflict)
+		entryRepo.On("GetByID", ctx, clientID).Return(nil, domain.ErrEntryNotFound)
+		entryRepo.On("Resurrect", ctx, mock.MatchedBy(func(e *domain.HabitEntry) bool {
+			return e.ID == clientID && e.UserID == uid && e.Value == 5
+		})).Return(nil)
+
+		created, err := svc.Create(ctx, services.CreateEntryInput{ID: clientID, HabitID: hid, UserID: uid, CompletionDate: now, Value: 5})
+		require.NoError(t, err)
+		assert.Equal(t, 5, created.Value)
+		entryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Security: Should not expose an ID taken by another user", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		habitRepo := new(MockHabitRepo)
+		svc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+
+		liveID := "0b6f8c2e-4d1a-4c3b-9a51-7f2d8e6b1c90"
+		deletedID := "6a0e9d41-58c2-4f7e-b3a6-2c91d7e05f48"
+		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
+		entryRepo.On("Create", ctx, mock.Anything).Return(domain.ErrEntryConflict)
+		entryRepo.On("GetByID", ctx, liveID).Return(&domain.HabitEntry{ID: liveID, UserID: "someone-else"}, nil)
+		entryRepo.On("GetByID", ctx, deletedID).Return(nil, domain.ErrEntryNotFound)
+		entryRepo.On("Resurrect", ctx, mock.Anything).Return(domain.ErrEntryNotFound)
+
+		created, err := svc.Create(ctx, services.CreateEntryInput{ID: liveID, HabitID: hid, UserID: uid, CompletionDate: now})
+		assert.ErrorIs(t, err, domain.ErrEntryConflict)
+		assert.Nil(t, created)
+
+		_, err = svc.Create(ctx, services.CreateEntryInput{ID: deletedID, HabitID: hid, UserID: uid, CompletionDate: now})
+		assert.ErrorIs(t, err, domain.ErrEntryConflict)
+	})
+
+	t.Run("Fail: Should reject IDs that are not UUIDs", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), getTestWorker(), nil, services.WriteOptions{})
+
+		_, err := svc.Create(ctx, services.CreateEntryInput{ID: "entry-1", HabitID: hid, UserID: uid, CompletionDate: now})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidEntry)
+		entryRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestEntryService_Update(t *testing.T) {
@@ -185,7 +310,7 @@ func TestEntryService_Update(t *testing.T) {
 	t.Run("Success: Should update valid entry", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		existing := &domain.HabitEntry{ID: entryID, HabitID: "habit-1", UserID: uid, Value: 5, Version: 1}
 
@@ -210,7 +335,7 @@ func TestEntryService_Update(t *testing.T) {
 	t.Run("Concurrency: Should fail if version conflict", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		existing := &domain.HabitEntry{ID: entryID, UserID: uid, Value: 5, Version: 2}
 		entryRepo.On("GetByID", ctx, entryID).Return(existing, nil)
@@ -220,13 +345,17 @@ func TestEntryService_Update(t *testing.T) {
 		_, err := svc.Update(ctx, input)
 
 		assert.ErrorIs(t, err, domain.ErrEntryConflict)
+		var conflict *domain.EntryConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, existing, conflict.Server)
+		assert.Equal(t, []domain.FieldDiff{{Field: "value", Client: 10, Server: 5}}, conflict.Diff)
 		entryRepo.AssertNotCalled(t, "Update")
 	})
 
 	t.Run("Security: Should fail if updating entry of another user", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		existing := &domain.HabitEntry{ID: entryID, UserID: "victim", Value: 5}
 		entryRepo.On("GetByID", ctx, entryID).Return(existing, nil)
@@ -239,6 +368,54 @@ func TestEntryService_Update(t *testing.T) {
 	})
 }
 
+func TestEntryService_LastWriterWins(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-123"
+	now := time.Now().UTC()
+
+	setup := func(storedHLC string) (*services.EntryService, *MockHabitEntryRepo) {
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), getTestWorker(), nil, services.WriteOptions{
+			Clock: services.ClockPolicy{Conflicts: services.ConflictPolicyLastWriterWins},
+		})
+
+		entryRepo.On("GetByID", ctx, "entry-1").Return(&domain.HabitEntry{ID: "entry-1", UserID: uid, Value: 1, Version: 3, HLC: storedHLC}, nil)
+		entryRepo.On("Update", ctx, mock.Anything).Return(nil)
+		return svc, entryRepo
+	}
+
+	stored := domain.HLC{Wall: now.Add(-time.Minute), Node: "laptop"}.String()
+
+	t.Run("Success: A newer clock overwrites a stale version", func(t *testing.T) {
+		svc, _ := setup(stored)
+		newer := domain.HLC{Wall: now, Node: "phone"}.String()
+
+		updated, err := svc.Update(ctx, services.UpdateEntryInput{ID: "entry-1", UserID: uid, Value: 5, Version: 1, HLC: newer})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, updated.Value)
+		assert.Equal(t, newer, updated.HLC)
+	})
+
+	t.Run("Fail: An older clock is a conflict", func(t *testing.T) {
+		svc, repo := setup(stored)
+		older := domain.HLC{Wall: now.Add(-time.Hour), Node: "phone"}.String()
+
+		_, err := svc.Update(ctx, services.UpdateEntryInput{ID: "entry-1", UserID: uid, Value: 5, Version: 1, HLC: older})
+
+		assert.ErrorIs(t, err, domain.ErrEntryConflict)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail: Updates without a clock keep the version check", func(t *testing.T) {
+		svc, _ := setup("")
+
+		_, err := svc.Update(ctx, services.UpdateEntryInput{ID: "entry-1", UserID: uid, Value: 5, Version: 1})
+
+		assert.ErrorIs(t, err, domain.ErrEntryConflict)
+	})
+}
+
 func TestEntryService_Delete(t *testing.T) {
 	ctx := context.Background()
 	uid := "user-123"
@@ -247,7 +424,7 @@ func TestEntryService_Delete(t *testing.T) {
 	t.Run("Success: Should delete owned entry", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		entryRepo.On("GetByID", ctx, entryID).Return(&domain.HabitEntry{ID: entryID, HabitID: "habit-1", UserID: uid}, nil)
 		entryRepo.On("Delete", ctx, entryID, uid).Return(nil)
@@ -260,7 +437,7 @@ func TestEntryService_Delete(t *testing.T) {
 	t.Run("Security: Should return Unauthorized if user mismatch", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		entryRepo.On("GetByID", ctx, entryID).Return(&domain.HabitEntry{ID: entryID, UserID: "owner"}, nil)
 
@@ -272,7 +449,7 @@ func TestEntryService_Delete(t *testing.T) {
 	t.Run("Fail: Should return NotFound if entry doesn't exist", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		entryRepo.On("GetByID", ctx, entryID).Return(nil, domain.ErrEntryNotFound)
 
@@ -284,20 +461,50 @@ func TestEntryService_Delete(t *testing.T) {
 func TestEntryService_GetDelta(t *testing.T) {
 	ctx := context.Background()
 	uid := "user-sync"
-	since := time.Now().Add(-24 * time.Hour)
+	since := int64(42)
 
 	t.Run("Success: Should propagate sync parameters to repo", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		expectedList := []*domain.HabitEntry{{ID: "1"}, {ID: "2"}}
-		entryRepo.On("GetChanges", ctx, uid, since).Return(expectedList, nil)
+		entryRepo.On("GetChanges", ctx, uid, since, 11).Return(expectedList, nil)
+
+		result, hasMore, err := svc.GetDelta(ctx, uid, since, 10)
+
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.False(t, hasMore)
+		entryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success: Should report more changes past a full page", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), getTestWorker(), nil, services.WriteOptions{})
 
-		result, err := svc.GetDelta(ctx, uid, since)
+		page := []*domain.HabitEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+		entryRepo.On("GetChanges", ctx, uid, since, 3).Return(page, nil)
+
+		result, hasMore, err := svc.GetDelta(ctx, uid, since, 2)
 
 		require.NoError(t, err)
 		assert.Len(t, result, 2)
+		assert.True(t, hasMore)
+	})
+
+	t.Run("Success: Should apply default and maximum page sizes", func(t *testing.T) {
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), getTestWorker(), nil, services.WriteOptions{})
+
+		entryRepo.On("GetChanges", ctx, uid, since, domain.DefaultSyncPageSize+1).Return([]*domain.HabitEntry{}, nil).Once()
+		entryRepo.On("GetChanges", ctx, uid, since, domain.MaxSyncPageSize+1).Return([]*domain.HabitEntry{}, nil).Once()
+
+		_, _, err := svc.GetDelta(ctx, uid, since, 0)
+		require.NoError(t, err)
+		_, _, err = svc.GetDelta(ctx, uid, since, 1_000_000)
+		require.NoError(t, err)
+
 		entryRepo.AssertExpectations(t)
 	})
 }
@@ -310,7 +517,7 @@ func TestEntryService_GetByID(t *testing.T) {
 	t.Run("Success: Should return entry if owned by user", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		expected := &domain.HabitEntry{ID: entryID, UserID: uid, Value: 10}
 		entryRepo.On("GetByID", ctx, entryID).Return(expected, nil)
@@ -324,7 +531,7 @@ func TestEntryService_GetByID(t *testing.T) {
 	t.Run("Security: Should prevent reading other users' entries", func(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker)
+		svc := services.NewEntryService(entryRepo, new(MockHabitRepo), worker, nil, services.WriteOptions{})
 
 		found := &domain.HabitEntry{ID: entryID, UserID: "other-user"}
 		entryRepo.On("GetByID", ctx, entryID).Return(found, nil)
@@ -346,7 +553,7 @@ func TestEntryService_ListByHabitID(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		habitRepo := new(MockHabitRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, habitRepo, worker)
+		svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 
 		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: uid}, nil)
 
@@ -364,7 +571,7 @@ func TestEntryService_ListByHabitID(t *testing.T) {
 		entryRepo := new(MockHabitEntryRepo)
 		habitRepo := new(MockHabitRepo)
 		worker := getTestWorker()
-		svc := services.NewEntryService(entryRepo, habitRepo, worker)
+		svc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{})
 
 		habitRepo.On("GetByID", ctx, hid).Return(&domain.Habit{ID: hid, UserID: "stranger"}, nil)
 
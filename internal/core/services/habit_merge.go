@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+// habitField describes a client-editable habit field: its value on a stored
+// habit, the value submitted in an update (if any) normalized the same way,
+// and how to drop it from the update.
+type habitField struct {
+	name   string
+	stored func(h *domain.Habit) interface{}
+	input  func(in *UpdateHabitInput) (interface{}, bool)
+	clear  func(in *UpdateHabitInput)
+}
+
+var mergeableHabitFields = []habitField{
+	{
+		name:   "title",
+		stored: func(h *domain.Habit) interface{} { return h.Title },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return trimmedOrNil(in.Title) },
+		clear:  func(in *UpdateHabitInput) { in.Title = nil },
+	},
+	{
+		name:   "description",
+		stored: func(h *domain.Habit) interface{} { return h.Description },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return trimmedOrNil(in.Description) },
+		clear:  func(in *UpdateHabitInput) { in.Description = nil },
+	},
+	{
+		name:   "color",
+		stored: func(h *domain.Habit) interface{} { return h.Color },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return stringOrNil(in.Color) },
+		clear:  func(in *UpdateHabitInput) { in.Color = nil },
+	},
+	{
+		name:   "icon",
+		stored: func(h *domain.Habit) interface{} { return h.Icon },
+		input: func(in *UpdateHabitInput) (interface{}, bool) {
+			if in.Icon != nil && *in.Icon == "" {
+				return domain.DefaultIcon, true
+			}
+			return stringOrNil(in.Icon)
+		},
+		clear: func(in *UpdateHabitInput) { in.Icon = nil },
+	},
+	{
+		name:   "type",
+		stored: func(h *domain.Habit) interface{} { return h.Type },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return stringOrNil(in.Type) },
+		clear:  func(in *UpdateHabitInput) { in.Type = nil },
+	},
+	{
+		name:   "reminder_time",
+		stored: func(h *domain.Habit) interface{} { return getStringOrDefault(h.ReminderTime, "") },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return stringOrNil(in.ReminderTime) },
+		clear:  func(in *UpdateHabitInput) { in.ReminderTime = nil },
+	},
+	{
+		name:   "unit",
+		stored: func(h *domain.Habit) interface{} { return h.Unit },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return stringOrNil(in.Unit) },
+		clear:  func(in *UpdateHabitInput) { in.Unit = nil },
+	},
+	{
+		name:   "target_value",
+		stored: func(h *domain.Habit) interface{} { return h.TargetValue },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return intOrNil(in.TargetValue) },
+		clear:  func(in *UpdateHabitInput) { in.TargetValue = nil },
+	},
+	{
+		name:   "interval",
+		stored: func(h *domain.Habit) interface{} { return h.Interval },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return intOrNil(in.Interval) },
+		clear:  func(in *UpdateHabitInput) { in.Interval = nil },
+	},
+	{
+		name:   "weekdays",
+		stored: func(h *domain.Habit) interface{} { return sortedWeekdays(h.Weekdays) },
+		input: func(in *UpdateHabitInput) (interface{}, bool) {
+			if in.Weekdays == nil {
+				return nil, false
+			}
+			return sortedWeekdays(in.Weekdays), true
+		},
+		clear: func(in *UpdateHabitInput) { in.Weekdays = nil },
+	},
+	{
+		name:   "frequency_type",
+		stored: func(h *domain.Habit) interface{} { return h.FrequencyType },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return stringOrNil(in.FrequencyType) },
+		clear:  func(in *UpdateHabitInput) { in.FrequencyType = nil },
+	},
+	{
+		name:   "times_per_period",
+		stored: func(h *domain.Habit) interface{} { return h.TimesPerPeriod },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return intOrNil(in.TimesPerPeriod) },
+		clear:  func(in *UpdateHabitInput) { in.TimesPerPeriod = nil },
+	},
+	{
+		name:   "rrule",
+		stored: func(h *domain.Habit) interface{} { return h.RRule },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return normalizedRRuleOrNil(in.RRule) },
+		clear:  func(in *UpdateHabitInput) { in.RRule = nil },
+	},
+	{
+		name:   "exdates",
+		stored: func(h *domain.Habit) interface{} { return sortedStrings(h.ExDates) },
+		input: func(in *UpdateHabitInput) (interface{}, bool) {
+			if in.ExDates == nil {
+				return nil, false
+			}
+			return sortedStrings(in.ExDates), true
+		},
+		clear: func(in *UpdateHabitInput) { in.ExDates = nil },
+	},
+	{
+		name:   "start_date",
+		stored: func(h *domain.Habit) interface{} { return h.StartDate.UTC().Format(time.RFC3339) },
+		input:  func(in *UpdateHabitInput) (interface{}, bool) { return habitDateOrNil(in.StartDate, in.Location) },
+		clear:  func(in *UpdateHabitInput) { in.StartDate = nil },
+	},
+	{
+		name: "end_date",
+		stored: func(h *domain.Habit) interface{} {
+			if h.EndDate == nil {
+				return ""
+			}
+			return h.EndDate.UTC().Format(time.RFC3339)
+		},
+		input: func(in *UpdateHabitInput) (interface{}, bool) { return habitDateOrNil(in.EndDate, in.Location) },
+		clear: func(in *UpdateHabitInput) { in.EndDate = nil },
+	},
+	{
+		name:   "archived_at",
+		stored: archivedAtValue,
+		input: func(in *UpdateHabitInput) (interface{}, bool) {
+			if in.ArchivedAt == nil {
+				return nil, false
+			}
+			if t, err := time.Parse(time.RFC3339, *in.ArchivedAt); err == nil {
+				return t.UTC().Format(time.RFC3339), true
+			}
+			return *in.ArchivedAt, true
+		},
+		clear: func(in *UpdateHabitInput) { in.ArchivedAt = nil },
+	},
+}
+
+// mergeConcurrentUpdate reconciles an update made from an older version of
+// the habit with the changes stored since then. The client's base version
+// is the common ancestor: fields the client left untouched are dropped from
+// the update so the newer server values survive, and only fields changed on
+// both sides to different values are reported as conflicts. Without the base
+// version every submitted field that differs from the server is a conflict.
+func (s *HabitService) mergeConcurrentUpdate(ctx context.Context, current *domain.Habit, input *UpdateHabitInput) error {
+	diff := diffHabitFields(current, input)
+
+	base, err := s.repo.GetVersion(ctx, current.ID, input.Version)
+	if err != nil && !errors.Is(err, domain.ErrHabitVersionNotFound) {
+		return err
+	}
+
+	var conflicts []string
+	for _, field := range mergeableHabitFields {
+		mine, ok := field.input(input)
+		if !ok {
+			continue
+		}
+
+		theirs := field.stored(current)
+		if reflect.DeepEqual(mine, theirs) {
+			continue
+		}
+
+		if base == nil {
+			conflicts = append(conflicts, field.name)
+			continue
+		}
+
+		ancestor := field.stored(base)
+		if reflect.DeepEqual(mine, ancestor) {
+			field.clear(input)
+			continue
+		}
+		if !reflect.DeepEqual(theirs, ancestor) {
+			conflicts = append(conflicts, field.name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &domain.HabitConflictError{Fields: conflicts, Server: current, Diff: diff}
+	}
+	return nil
+}
+
+// diffHabitFields lists the submitted fields whose value differs from the
+// stored habit.
+func diffHabitFields(stored *domain.Habit, input *UpdateHabitInput) []domain.FieldDiff {
+	var diff []domain.FieldDiff
+	for _, field := range mergeableHabitFields {
+		mine, ok := field.input(input)
+		if !ok {
+			continue
+		}
+		theirs := field.stored(stored)
+		if !reflect.DeepEqual(mine, theirs) {
+			diff = append(diff, domain.FieldDiff{Field: field.name, Client: mine, Server: theirs})
+		}
+	}
+	return diff
+}
+
+// conflictWithServerCopy turns a version conflict detected by the repository,
+// when another writer got in between our read and our write, into a
+// HabitConflictError carrying the habit as it is stored now. If the stored
+// habit already holds every submitted value, there is nothing to resolve
+// and it is returned instead.
+func (s *HabitService) conflictWithServerCopy(ctx context.Context, input *UpdateHabitInput, cause error) (*domain.Habit, error) {
+	current, err := s.repo.GetByID(ctx, input.ID)
+	if err != nil {
+		return nil, cause
+	}
+
+	if err := staleUpdateConflict(current, input); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// staleUpdateConflict rejects every submitted field that differs from the
+// current habit. An update that only repeats the current values is not a
+// conflict and returns nil.
+func staleUpdateConflict(current *domain.Habit, input *UpdateHabitInput) error {
+	diff := diffHabitFields(current, input)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(diff))
+	for _, d := range diff {
+		fields = append(fields, d.Field)
+	}
+
+	return &domain.HabitConflictError{Fields: fields, Server: current, Diff: diff}
+}
+
+// archiveConflict rejects archiving or unarchiving a habit that changed
+// since the client's base version, reporting archived_at as a stale update
+// of that field would.
+func archiveConflict(current *domain.Habit, archived bool) error {
+	proposed := *current
+	if archived {
+		proposed.Archive()
+	} else {
+		proposed.Restore()
+	}
+
+	return &domain.HabitConflictError{
+		Fields: []string{"archived_at"},
+		Server: current,
+		Diff: []domain.FieldDiff{
+			{Field: "archived_at", Client: archivedAtValue(&proposed), Server: archivedAtValue(current)},
+		},
+	}
+}
+
+// reorderConflict turns a version conflict on a habit being moved to
+// position into a HabitConflictError carrying the habit as it is stored now,
+// reported as a stale update of sort_order.
+func (s *HabitService) reorderConflict(ctx context.Context, id string, position int, cause error) error {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return cause
+	}
+
+	return &domain.HabitConflictError{
+		Fields: []string{"sort_order"},
+		Server: current,
+		Diff:   []domain.FieldDiff{{Field: "sort_order", Client: position, Server: current.SortOrder}},
+	}
+}
+
+func archivedAtValue(h *domain.Habit) interface{} {
+	if h.ArchivedAt == nil {
+		return ""
+	}
+	return h.ArchivedAt.UTC().Format(time.RFC3339)
+}
+
+func trimmedOrNil(ptr *string) (interface{}, bool) {
+	if ptr == nil {
+		return nil, false
+	}
+	return strings.TrimSpace(*ptr), true
+}
+
+func stringOrNil(ptr *string) (interface{}, bool) {
+	if ptr == nil {
+		return nil, false
+	}
+	return *ptr, true
+}
+
+func intOrNil(ptr *int) (interface{}, bool) {
+	if ptr == nil {
+		return nil, false
+	}
+	return *ptr, true
+}
+
+func sortedWeekdays(days []int) []int {
+	if len(days) == 0 {
+		return nil
+	}
+	seen := make(map[int]bool, len(days))
+	out := make([]int, 0, len(days))
+	for _, d := range days {
+		if !seen[d] {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func normalizedRRuleOrNil(ptr *string) (interface{}, bool) {
+	if ptr == nil {
+		return nil, false
+	}
+	return strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(*ptr)), "RRULE:"), true
+}
+
+func sortedStrings(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func habitDateOrNil(ptr *string, loc *time.Location) (interface{}, bool) {
+	if ptr == nil {
+		return nil, false
+	}
+	if *ptr == "" {
+		return "", true
+	}
+	if t, err := domain.ParseHabitDate(*ptr, loc); err == nil {
+		return t.UTC().Format(time.RFC3339), true
+	}
+	return *ptr, true
+}
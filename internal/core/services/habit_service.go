@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -10,47 +11,71 @@ import (
 )
 
 type HabitService struct {
-	repo domain.HabitRepository
+	repo     domain.HabitRepository
+	notifier domain.ChangeNotifier
+	clock    ClockPolicy
+	ops      operationLog
+	tx       domain.Transactor
 }
 
-func NewHabitService(repo domain.HabitRepository) *HabitService {
+func NewHabitService(repo domain.HabitRepository, notifier domain.ChangeNotifier, opts WriteOptions) *HabitService {
 	return &HabitService{
-		repo: repo,
+		repo:     repo,
+		notifier: notifier,
+		clock:    opts.Clock,
+		ops:      operationLog{repo: opts.Operations},
+		tx:       transactorOrNone(opts.Tx),
 	}
 }
 
 type CreateHabitInput struct {
-	ID            string
-	UserID        string
-	Title         string
-	Description   string
-	Color         string
-	Icon          string
-	Type          string
-	ReminderTime  string
-	Unit          string
-	TargetValue   int
-	Interval      int
-	Weekdays      []int
-	FrequencyType string
+	ID             string
+	UserID         string
+	Title          string
+	Description    string
+	Color          string
+	Icon           string
+	Type           string
+	ReminderTime   string
+	Unit           string
+	TargetValue    int
+	Interval       int
+	Weekdays       []int
+	FrequencyType  string
+	TimesPerPeriod int
+	RRule          string
+	ExDates        []string
+	StartDate      string
+	EndDate        string
+	// Location is the timezone calendar dates in StartDate and EndDate are
+	// read in; nil means UTC.
+	Location *time.Location
+	HLC      string
 }
 
 type UpdateHabitInput struct {
-	ID            string
-	UserID        string
-	Title         *string
-	Description   *string
-	Color         *string
-	Icon          *string
-	Type          *string
-	ReminderTime  *string
-	Unit          *string
-	TargetValue   *int
-	Interval      *int
-	Weekdays      []int
-	FrequencyType *string
-	ArchivedAt    *string
-	Version       int
+	ID             string
+	UserID         string
+	Title          *string
+	Description    *string
+	Color          *string
+	Icon           *string
+	Type           *string
+	ReminderTime   *string
+	Unit           *string
+	TargetValue    *int
+	Interval       *int
+	Weekdays       []int
+	FrequencyType  *string
+	TimesPerPeriod *int
+	RRule          *string
+	ExDates        []string
+	StartDate      *string
+	EndDate        *string
+	Location       *time.Location
+	ArchivedAt     *string
+	Version        int
+	HLC            string
 }
 
 func getStringOrDefault(ptr *string, def string) string {
@@ -67,7 +92,90 @@ func getIntOrDefault(ptr *int, def int) int {
 	return def
 }
 
+func nonEmptyOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// setHabitDates applies the start and end dates sent by a client. A nil
+// value keeps the current date, and an empty end date makes the habit
+// open-ended again.
+func setHabitDates(habit *domain.Habit, start, end *string, loc *time.Location) error {
+	if start == nil && end == nil {
+		return nil
+	}
+
+	startDate := habit.StartDate
+	if start != nil && *start != "" {
+		t, err := domain.ParseHabitDate(*start, loc)
+		if err != nil {
+			return err
+		}
+		startDate = t
+	}
+
+	endDate := habit.EndDate
+	if end != nil {
+		endDate = nil
+		if *end != "" {
+			t, err := domain.ParseHabitDate(*end, loc)
+			if err != nil {
+				return err
+			}
+			endDate = &t
+		}
+	}
+
+	if err := habit.SetDates(startDate, endDate); err != nil {
+		return err
+	}
+	if start != nil && *start != "" {
+		habit.StartDateSet = true
+	}
+	return nil
+}
+
+func createInputFromUpdate(input UpdateHabitInput) CreateHabitInput {
+	return CreateHabitInput{
+		ID:             input.ID,
+		UserID:         input.UserID,
+		Title:          getStringOrDefault(input.Title, ""),
+		Description:    getStringOrDefault(input.Description, ""),
+		Color:          getStringOrDefault(input.Color, "#000000"),
+		Icon:           getStringOrDefault(input.Icon, "default"),
+		Type:           getStringOrDefault(input.Type, domain.HabitTypeBoolean),
+		ReminderTime:   getStringOrDefault(input.ReminderTime, ""),
+		Unit:           getStringOrDefault(input.Unit, ""),
+		TargetValue:    getIntOrDefault(input.TargetValue, 1),
+		Interval:       getIntOrDefault(input.Interval, 1),
+		Weekdays:       input.Weekdays,
+		FrequencyType:  getStringOrDefault(input.FrequencyType, ""),
+		TimesPerPeriod: getIntOrDefault(input.TimesPerPeriod, 0),
+		RRule:          getStringOrDefault(input.RRule, ""),
+		ExDates:        input.ExDates,
+		StartDate:      getStringOrDefault(input.StartDate, ""),
+		EndDate:        getStringOrDefault(input.EndDate, ""),
+		Location:       input.Location,
+		HLC:            input.HLC,
+	}
+}
+
 func (s *HabitService) Create(ctx context.Context, input CreateHabitInput) (*domain.Habit, error) {
+	var habit *domain.Habit
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		habit, err = s.create(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return habit, nil
+}
+
+func (s *HabitService) create(ctx context.Context, input CreateHabitInput) (*domain.Habit, error) {
 	habit, err := domain.NewHabit(input.ID, input.Title, input.UserID)
 	if err != nil {
 		return nil, err
@@ -96,19 +204,26 @@ func (s *HabitService) Create(ctx context.Context, input CreateHabitInput) (*dom
 		input.TargetValue,
 		input.Interval,
 		input.Weekdays,
+		input.FrequencyType,
+		input.TimesPerPeriod,
+		input.RRule,
+		input.ExDates,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	if input.FrequencyType != "" {
-		habit.FrequencyType = input.FrequencyType
-	} else {
-		habit.FrequencyType = domain.HabitFreqDaily
+	if err := setHabitDates(habit, nonEmptyOrNil(input.StartDate), nonEmptyOrNil(input.EndDate), input.Location); err != nil {
+		return nil, err
 	}
 
 	habit.Version = 1
 
+	habit.HLC, err = s.clock.normalize(input.HLC)
+	if err != nil {
+		return nil, err
+	}
+
 	existing, err := s.repo.GetByID(ctx, habit.ID)
 	if err == nil && existing != nil {
 		if existing.UserID == input.UserID {
@@ -129,12 +244,27 @@ func (s *HabitService) Create(ctx context.Context, input CreateHabitInput) (*dom
 		}
 
 		fmt.Printf("Resurrection success for %s\n", habit.ID)
-		return habit, nil
 	}
 
+	if err := s.ops.record(ctx, habitOperation(habit, domain.OperationCreate), nil, habit); err != nil {
+		return nil, err
+	}
+
+	notifyChange(ctx, s.tx, s.notifier, habit.UserID)
+
 	return habit, nil
 }
 
+func habitOperation(h *domain.Habit, op string) *domain.Operation {
+	return &domain.Operation{
+		UserID:     h.UserID,
+		EntityType: domain.OperationEntityHabit,
+		EntityID:   h.ID,
+		HabitID:    h.ID,
+		Op:         op,
+	}
+}
+
 func (s *HabitService) GetByID(ctx context.Context, id string, userID string) (*domain.Habit, error) {
 	habit, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -150,32 +280,48 @@ func (s *HabitService) ListByUserID(ctx context.Context, userID string) ([]*doma
 	return s.repo.ListByUserID(ctx, userID)
 }
 
-func (s *HabitService) GetDelta(ctx context.Context, userID string, lastSync time.Time) ([]*domain.Habit, error) {
-	return s.repo.GetChanges(ctx, userID, lastSync)
+// GetDelta returns one page of changes after since and reports whether more
+// changes are waiting past it.
+func (s *HabitService) GetDelta(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, bool, error) {
+	limit = syncPageSize(limit)
+
+	habits, err := s.repo.GetChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(habits) > limit {
+		return habits[:limit], true, nil
+	}
+	return habits, false, nil
 }
 
 func (s *HabitService) Update(ctx context.Context, input UpdateHabitInput) (*domain.Habit, error) {
+	var habit *domain.Habit
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		habit, err = s.update(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return habit, nil
+}
+
+func (s *HabitService) update(ctx context.Context, input UpdateHabitInput) (*domain.Habit, error) {
+	hlc, err := s.clock.normalize(input.HLC)
+	if err != nil {
+		return nil, err
+	}
+	input.HLC = hlc
+
 	habit, err := s.repo.GetByID(ctx, input.ID)
 
 	if errors.Is(err, domain.ErrHabitNotFound) && input.Title != nil {
 		fmt.Printf("Resurrecting Ghost Habit (Upsert): %s\n", input.ID)
 
-		createInput := CreateHabitInput{
-			ID:            input.ID,
-			UserID:        input.UserID,
-			Title:         *input.Title,
-			Description:   getStringOrDefault(input.Description, ""),
-			Color:         getStringOrDefault(input.Color, "#000000"),
-			Icon:          getStringOrDefault(input.Icon, "default"),
-			Type:          getStringOrDefault(input.Type, domain.HabitTypeBoolean),
-			ReminderTime:  getStringOrDefault(input.ReminderTime, ""),
-			Unit:          getStringOrDefault(input.Unit, ""),
-			TargetValue:   getIntOrDefault(input.TargetValue, 1),
-			Interval:      getIntOrDefault(input.Interval, 1),
-			Weekdays:      input.Weekdays,
-			FrequencyType: getStringOrDefault(input.FrequencyType, domain.HabitFreqDaily),
-		}
-		return s.Create(ctx, createInput)
+		return s.create(ctx, createInputFromUpdate(input))
 	}
 
 	if err != nil {
@@ -186,8 +332,24 @@ func (s *HabitService) Update(ctx context.Context, input UpdateHabitInput) (*dom
 		return nil, domain.ErrHabitNotFound
 	}
 
+	before, err := json.Marshal(habit)
+	if err != nil {
+		return nil, err
+	}
+	wasArchived := habit.ArchivedAt != nil
+
+	submitted := input
 	if input.Version > 0 && habit.Version != input.Version {
-		return nil, fmt.Errorf("%w: client v%d vs server v%d", domain.ErrHabitConflict, input.Version, habit.Version)
+		if decided, wins := s.clock.lastWriterWins(input.HLC, habit.HLC); decided {
+			if !wins {
+				if err := staleUpdateConflict(habit, &input); err != nil {
+					return nil, err
+				}
+				return habit, nil
+			}
+		} else if err := s.mergeConcurrentUpdate(ctx, habit, &input); err != nil {
+			return nil, err
+		}
 	}
 
 	if input.Title != nil {
@@ -235,6 +397,29 @@ func (s *HabitService) Update(ctx context.Context, input UpdateHabitInput) (*dom
 		habit.Unit = *input.Unit
 	}
 
+	// Daily, specific-days, interval and rrule habits follow their schedule
+	// unless the client names a frequency; weekly and monthly ones keep theirs.
+	var frequency string
+	if input.FrequencyType != nil {
+		frequency = *input.FrequencyType
+	} else if habit.IsPeriodic() {
+		frequency = habit.FrequencyType
+	}
+
+	// Weekly and monthly rows written before times_per_period existed
+	// store 0, which PeriodTarget reads as once per period.
+	timesPerPeriod := habit.TimesPerPeriod
+	if habit.IsPeriodic() {
+		timesPerPeriod = habit.PeriodTarget()
+	}
+
+	if input.RRule != nil {
+		habit.RRule = *input.RRule
+	}
+	if input.ExDates != nil {
+		habit.ExDates = input.ExDates
+	}
+
 	err = habit.Update(
 		habit.Title,
 		habit.Description,
@@ -246,13 +431,17 @@ func (s *HabitService) Update(ctx context.Context, input UpdateHabitInput) (*dom
 		habit.TargetValue,
 		habit.Interval,
 		habit.Weekdays,
+		frequency,
+		getIntOrDefault(input.TimesPerPeriod, timesPerPeriod),
+		habit.RRule,
+		habit.ExDates,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	if input.FrequencyType != nil {
-		habit.FrequencyType = *input.FrequencyType
+	if err := setHabitDates(habit, input.StartDate, input.EndDate, input.Location); err != nil {
+		return nil, err
 	}
 
 	if input.ArchivedAt != nil {
@@ -267,17 +456,238 @@ func (s *HabitService) Update(ctx context.Context, input UpdateHabitInput) (*dom
 		}
 	}
 
+	if input.HLC != "" {
+		habit.HLC = input.HLC
+	}
+
 	habit.Version++
 	habit.UpdatedAt = time.Now().UTC()
 
 	if err := s.repo.Update(ctx, habit); err != nil {
+		if errors.Is(err, domain.ErrHabitConflict) {
+			return s.conflictWithServerCopy(ctx, &submitted, err)
+		}
+		return nil, err
+	}
+
+	op := domain.OperationUpdate
+	if archived := habit.ArchivedAt != nil; archived != wasArchived {
+		op = domain.OperationUnarchive
+		if archived {
+			op = domain.OperationArchive
+		}
+	}
+	if err := s.ops.record(ctx, habitOperation(habit, op), json.RawMessage(before), habit); err != nil {
 		return nil, err
 	}
 
+	notifyChange(ctx, s.tx, s.notifier, habit.UserID)
+
+	return habit, nil
+}
+
+// Archive hides a habit from the schedule while keeping its history.
+// Archiving an archived habit changes nothing. version is the version the
+// client last saw, or 0 to skip the check; if the habit has changed since,
+// Archive returns a HabitConflictError with the server copy.
+func (s *HabitService) Archive(ctx context.Context, id, userID string, version int) (*domain.Habit, error) {
+	return s.setArchived(ctx, id, userID, version, true)
+}
+
+// Unarchive brings an archived habit back. Unarchiving an active habit
+// changes nothing. version is checked as by Archive.
+func (s *HabitService) Unarchive(ctx context.Context, id, userID string, version int) (*domain.Habit, error) {
+	return s.setArchived(ctx, id, userID, version, false)
+}
+
+func (s *HabitService) setArchived(ctx context.Context, id, userID string, version int, archived bool) (*domain.Habit, error) {
+	var habit *domain.Habit
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		habit, err = s.GetByID(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		if (habit.ArchivedAt != nil) == archived {
+			return nil
+		}
+		if version > 0 && version != habit.Version {
+			return archiveConflict(habit, archived)
+		}
+
+		before, err := json.Marshal(habit)
+		if err != nil {
+			return err
+		}
+
+		op := domain.OperationUnarchive
+		if archived {
+			habit.Archive()
+			op = domain.OperationArchive
+		} else {
+			habit.Restore()
+		}
+		habit.Version++
+
+		if err := s.repo.Update(ctx, habit); err != nil {
+			return err
+		}
+
+		if err := s.ops.record(ctx, habitOperation(habit, op), json.RawMessage(before), habit); err != nil {
+			return err
+		}
+
+		notifyChange(ctx, s.tx, s.notifier, habit.UserID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return habit, nil
 }
 
+// Reorder sorts the user's active habits as listed in ids, which must name
+// each of them exactly once. Only the habits that moved get a new version,
+// and all of them are written in one transaction, so a drag and drop reaches
+// the other devices as a whole or not at all. The habits are read from the
+// database and locked in that transaction, and a habit that still changed
+// under it is reported as a HabitConflictError with the server copy. It
+// returns all of the user's habits: the active ones in their new order, then
+// the archived ones.
+func (s *HabitService) Reorder(ctx context.Context, userID string, ids []string) ([]*domain.Habit, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	var ordered []*domain.Habit
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		ordered, err = s.reorder(ctx, userID, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+func (s *HabitService) reorder(ctx context.Context, userID string, ids []string) ([]*domain.Habit, error) {
+	habits, err := s.repo.ListByUserIDForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*domain.Habit, len(habits))
+	active := 0
+	for _, h := range habits {
+		byID[h.ID] = h
+		if h.ArchivedAt == nil {
+			active++
+		}
+	}
+	if len(ids) != active {
+		return nil, domain.ErrInvalidHabitOrder
+	}
+
+	ordered := make([]*domain.Habit, 0, len(habits))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		habit, ok := byID[id]
+		if !ok || seen[id] {
+			return nil, domain.ErrInvalidHabitOrder
+		}
+		seen[id] = true
+		if habit.ArchivedAt != nil {
+			return nil, domain.ErrHabitArchived
+		}
+		ordered = append(ordered, habit)
+	}
+
+	changed := false
+	for i, habit := range ordered {
+		if habit.SortOrder == i {
+			continue
+		}
+
+		before, err := json.Marshal(habit)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := habit.ChangePosition(i); err != nil {
+			return nil, err
+		}
+		habit.Version++
+
+		if err := s.repo.Update(ctx, habit); err != nil {
+			if errors.Is(err, domain.ErrHabitConflict) {
+				return nil, s.reorderConflict(ctx, habit.ID, i, err)
+			}
+			return nil, err
+		}
+
+		if err := s.ops.record(ctx, habitOperation(habit, domain.OperationUpdate), json.RawMessage(before), habit); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+
+	if changed {
+		notifyChange(ctx, s.tx, s.notifier, userID)
+	}
+
+	for _, habit := range habits {
+		if habit.ArchivedAt != nil {
+			ordered = append(ordered, habit)
+		}
+	}
+	return ordered, nil
+}
+
+// Finalize archives a habit that has run past its end date. It returns
+// ErrHabitConflict if the habit was changed since it was read.
+func (s *HabitService) Finalize(ctx context.Context, habit *domain.Habit) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.finalize(ctx, habit)
+	})
+}
+
+func (s *HabitService) finalize(ctx context.Context, habit *domain.Habit) error {
+	if habit.ArchivedAt != nil || habit.DeletedAt != nil {
+		return nil
+	}
+
+	before, err := json.Marshal(habit)
+	if err != nil {
+		return err
+	}
+
+	habit.Archive()
+	habit.Version++
+
+	if err := s.repo.Update(ctx, habit); err != nil {
+		return err
+	}
+
+	if err := s.ops.record(ctx, habitOperation(habit, domain.OperationArchive), json.RawMessage(before), habit); err != nil {
+		return err
+	}
+
+	notifyChange(ctx, s.tx, s.notifier, habit.UserID)
+
+	return nil
+}
+
+// Delete soft-deletes the habit. Its entries are soft-deleted with it in the
+// same statement by the database, so their tombstones reach the entries delta
+// feed and they drop out of the stats.
 func (s *HabitService) Delete(ctx context.Context, id string, userID string) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, id, userID)
+	})
+}
+
+func (s *HabitService) delete(ctx context.Context, id string, userID string) error {
 	habit, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -287,6 +697,11 @@ func (s *HabitService) Delete(ctx context.Context, id string, userID string) err
 		return domain.ErrHabitNotFound
 	}
 
+	before, err := json.Marshal(habit)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 	habit.DeletedAt = &now
 	habit.Version++
@@ -296,5 +711,11 @@ func (s *HabitService) Delete(ctx context.Context, id string, userID string) err
 		return err
 	}
 
+	if err := s.ops.record(ctx, habitOperation(habit, domain.OperationDelete), json.RawMessage(before), nil); err != nil {
+		return err
+	}
+
+	notifyChange(ctx, s.tx, s.notifier, habit.UserID)
+
 	return nil
 }
@@ -3,12 +3,14 @@ package services_test
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
 	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func ptr[T any](v T) *T {
@@ -16,17 +18,21 @@ func ptr[T any](v T) *T {
 }
 
 func newTestService(repo domain.HabitRepository) *services.HabitService {
-	return services.NewHabitService(repo)
+	return services.NewHabitService(repo, nil, services.WriteOptions{})
 }
 
 type MockRepo struct {
 	store         map[string]*domain.Habit
+	history       map[string]map[int]domain.Habit
+	seq           int64
 	simulateError error
+	lockedReads   int
 }
 
 func NewMockRepo() *MockRepo {
 	return &MockRepo{
-		store: make(map[string]*domain.Habit),
+		store:   make(map[string]*domain.Habit),
+		history: make(map[string]map[int]domain.Habit),
 	}
 }
 
@@ -42,8 +48,11 @@ func (m *MockRepo) Create(ctx context.Context, habit *domain.Habit) error {
 	if habit.Version == 0 {
 		habit.Version = 1
 	}
+	m.seq++
+	habit.ChangeSeq = m.seq
 	clone := *habit
 	m.store[habit.ID] = &clone
+	m.record(habit)
 	return nil
 }
 
@@ -62,6 +71,12 @@ func (m *MockRepo) GetByID(ctx context.Context, id string) (*domain.Habit, error
 	return &clone, nil
 }
 
+// ListByUserIDForUpdate counts the locking reads; the mock has nothing to lock.
+func (m *MockRepo) ListByUserIDForUpdate(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	m.lockedReads++
+	return m.ListByUserID(ctx, userID)
+}
+
 func (m *MockRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Habit, error) {
 	if m.simulateError != nil {
 		return nil, m.simulateError
@@ -85,8 +100,11 @@ func (m *MockRepo) Update(ctx context.Context, habit *domain.Habit) error {
 		return domain.ErrHabitNotFound
 	}
 
+	m.seq++
+	habit.ChangeSeq = m.seq
 	clone := *habit
 	m.store[habit.ID] = &clone
+	m.record(habit)
 	return nil
 }
 
@@ -102,17 +120,24 @@ func (m *MockRepo) Delete(ctx context.Context, id string) error {
 	h.DeletedAt = &now
 	h.Version++
 	h.UpdatedAt = now
+	m.seq++
+	h.ChangeSeq = m.seq
+	m.record(h)
 	return nil
 }
 
-func (m *MockRepo) GetChanges(ctx context.Context, userID string, since time.Time) ([]*domain.Habit, error) {
+func (m *MockRepo) GetChanges(ctx context.Context, userID string, since int64, limit int) ([]*domain.Habit, error) {
 	var changes []*domain.Habit
 	for _, h := range m.store {
-		if h.UserID == userID && h.UpdatedAt.After(since) {
+		if h.UserID == userID && h.ChangeSeq > since {
 			clone := *h
 			changes = append(changes, &clone)
 		}
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeSeq < changes[j].ChangeSeq })
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
 	return changes, nil
 }
 
@@ -127,6 +152,8 @@ func (m *MockRepo) UpdateStreaks(ctx context.Context, id string, current, longes
 	h.CurrentStreak = current
 	h.LongestStreak = longest
 	h.UpdatedAt = time.Now().UTC()
+	m.seq++
+	h.ChangeSeq = m.seq
 	return nil
 }
 
@@ -330,6 +357,110 @@ func TestHabitService_Update(t *testing.T) {
 		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
 	})
 
+	t.Run("Success: Weekly frequency survives updates to other fields", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+		ctx := context.Background()
+
+		created, err := svc.Create(ctx, services.CreateHabitInput{
+			UserID:         "user-1",
+			Title:          "Run",
+			FrequencyType:  domain.HabitFreqWeekly,
+			TimesPerPeriod: 3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.HabitFreqWeekly, created.FrequencyType)
+		assert.Equal(t, 3, created.TimesPerPeriod)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID:       created.ID,
+			UserID:   "user-1",
+			Title:    ptr("Run outside"),
+			Weekdays: []int{1, 3},
+			Version:  created.Version,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.HabitFreqWeekly, updated.FrequencyType)
+		assert.Equal(t, 3, updated.TimesPerPeriod)
+
+		_, err = svc.Update(ctx, services.UpdateHabitInput{
+			ID:             created.ID,
+			UserID:         "user-1",
+			TimesPerPeriod: ptr(9),
+			Version:        updated.Version,
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidTimesPerPeriod)
+	})
+
+	t.Run("Success: Legacy weekly habits without times per period can be updated", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+		ctx := context.Background()
+
+		legacy, _ := domain.NewHabit("", "Swim", "user-1")
+		legacy.FrequencyType = domain.HabitFreqWeekly
+		legacy.TimesPerPeriod = 0
+		require.NoError(t, repo.Create(ctx, legacy))
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID:      legacy.ID,
+			UserID:  "user-1",
+			Title:   ptr("Swim laps"),
+			Version: legacy.Version,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.HabitFreqWeekly, updated.FrequencyType)
+		assert.Equal(t, 1, updated.TimesPerPeriod)
+	})
+
+	t.Run("Success: Start and end dates are set, moved and cleared", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+		ctx := context.Background()
+
+		rome, err := time.LoadLocation("Europe/Rome")
+		if err != nil {
+			t.Skip("timezone data not available")
+		}
+
+		created, err := svc.Create(ctx, services.CreateHabitInput{
+			UserID:    "user-1",
+			Title:     "30 Day Challenge",
+			StartDate: "2026-10-01",
+			EndDate:   "2026-10-30",
+			Location:  rome,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), created.StartDate)
+		assert.True(t, created.StartDateSet)
+		require.NotNil(t, created.EndDate)
+		assert.Equal(t, time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC), *created.EndDate)
+
+		_, err = svc.Update(ctx, services.UpdateHabitInput{
+			ID:        created.ID,
+			UserID:    "user-1",
+			StartDate: ptr("2026-11-01"),
+			Location:  rome,
+			Version:   created.Version,
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidDateRange)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID:      created.ID,
+			UserID:  "user-1",
+			EndDate: ptr(""),
+			Version: created.Version,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, updated.EndDate)
+		assert.True(t, updated.StartDate.Equal(created.StartDate))
+		assert.True(t, updated.StartDateSet)
+
+		untouched, err := svc.Create(ctx, services.CreateHabitInput{UserID: "user-1", Title: "Stretch"})
+		require.NoError(t, err)
+		assert.False(t, untouched.StartDateSet, "The default start date is not the user's choice")
+	})
+
 	t.Run("Optimistic Locking: Should fail if client has old version", func(t *testing.T) {
 		repo := NewMockRepo()
 		svc := newTestService(repo)
@@ -351,6 +482,182 @@ func TestHabitService_Update(t *testing.T) {
 	})
 }
 
+func TestHabitService_UpdateMerge(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*MockRepo, *services.HabitService, *domain.Habit) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		existing, _ := domain.NewHabit("habit-merge", "Read", "user-1")
+		existing.Color = "#111111"
+		require.NoError(t, repo.Create(ctx, existing))
+
+		// Another device changes the color: the server moves to v2.
+		_, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Color: ptr("#222222"), Version: 1,
+		})
+		require.NoError(t, err)
+
+		return repo, svc, existing
+	}
+
+	t.Run("Success: Non-overlapping field changes are merged", func(t *testing.T) {
+		_, svc, existing := setup(t)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Version: 1,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Read more", updated.Title)
+		assert.Equal(t, "#222222", updated.Color, "The concurrent color change must survive")
+		assert.Equal(t, 3, updated.Version)
+	})
+
+	t.Run("Success: Fields resent unchanged from the base keep the server value", func(t *testing.T) {
+		_, svc, existing := setup(t)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Color: ptr("#111111"), Version: 1,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Read more", updated.Title)
+		assert.Equal(t, "#222222", updated.Color)
+	})
+
+	t.Run("Fail: Same-field changes conflict and list the fields", func(t *testing.T) {
+		repo, svc, existing := setup(t)
+
+		_, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Color: ptr("#333333"), Version: 1,
+		})
+
+		require.ErrorIs(t, err, domain.ErrHabitConflict)
+		var conflict *domain.HabitConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{"color"}, conflict.Fields)
+		require.NotNil(t, conflict.Server, "Conflicts carry the server copy")
+		assert.Equal(t, "#222222", conflict.Server.Color)
+		assert.Equal(t, []domain.FieldDiff{
+			{Field: "title", Client: "Read more", Server: "Read"},
+			{Field: "color", Client: "#333333", Server: "#222222"},
+		}, conflict.Diff)
+
+		stored, _ := repo.GetByID(ctx, existing.ID)
+		assert.Equal(t, "Read", stored.Title, "Nothing is applied on conflict")
+	})
+
+	t.Run("Success: Same value on both sides is not a conflict", func(t *testing.T) {
+		_, svc, existing := setup(t)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Color: ptr("#222222"), Version: 1,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "#222222", updated.Color)
+	})
+
+	t.Run("Success: A lost write race that repeats the stored values returns the server copy", func(t *testing.T) {
+		repo, _, existing := setup(t)
+		svc := newTestService(&racingRepo{MockRepo: repo})
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{
+			ID: existing.ID, UserID: "user-1", Color: ptr("#222222"), Version: 2,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "#222222", updated.Color)
+		assert.Equal(t, 2, updated.Version)
+	})
+}
+
+// racingRepo loses every write to a concurrent writer, as if the row
+// changed between the read and the update.
+type racingRepo struct {
+	*MockRepo
+}
+
+func (r *racingRepo) Update(ctx context.Context, habit *domain.Habit) error {
+	return domain.ErrHabitConflict
+}
+
+func TestHabitService_Clock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	older := domain.HLC{Wall: now.Add(-time.Minute), Node: "laptop"}.String()
+	newer := domain.HLC{Wall: now, Node: "phone"}.String()
+
+	setup := func(t *testing.T, policy services.ConflictPolicy) (*services.HabitService, *domain.Habit) {
+		svc := services.NewHabitService(NewMockRepo(), nil, services.WriteOptions{Clock: services.ClockPolicy{Conflicts: policy}})
+
+		created, err := svc.Create(ctx, services.CreateHabitInput{ID: "habit-hlc", UserID: "user-1", Title: "Read", HLC: older})
+		require.NoError(t, err)
+		assert.Equal(t, older, created.HLC)
+
+		// Another device renames the habit: the server moves to v2.
+		_, err = svc.Update(ctx, services.UpdateHabitInput{ID: created.ID, UserID: "user-1", Title: ptr("Read daily"), Version: 1, HLC: older})
+		require.NoError(t, err)
+
+		return svc, created
+	}
+
+	t.Run("Last-writer-wins: A newer clock overwrites a stale version", func(t *testing.T) {
+		svc, existing := setup(t, services.ConflictPolicyLastWriterWins)
+
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Version: 1, HLC: newer})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Read more", updated.Title)
+		assert.Equal(t, newer, updated.HLC)
+	})
+
+	t.Run("Last-writer-wins: An older clock loses with the server copy", func(t *testing.T) {
+		svc, existing := setup(t, services.ConflictPolicyLastWriterWins)
+
+		stale := domain.HLC{Wall: now.Add(-time.Hour), Node: "tablet"}.String()
+		_, err := svc.Update(ctx, services.UpdateHabitInput{ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Version: 1, HLC: stale})
+
+		require.ErrorIs(t, err, domain.ErrHabitConflict)
+		var conflict *domain.HabitConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{"title"}, conflict.Fields)
+		assert.Equal(t, "Read daily", conflict.Server.Title)
+	})
+
+	t.Run("Last-writer-wins: An older clock repeating the server values succeeds", func(t *testing.T) {
+		svc, existing := setup(t, services.ConflictPolicyLastWriterWins)
+
+		stale := domain.HLC{Wall: now.Add(-time.Hour), Node: "tablet"}.String()
+		updated, err := svc.Update(ctx, services.UpdateHabitInput{ID: existing.ID, UserID: "user-1", Title: ptr("Read daily"), Version: 1, HLC: stale})
+
+		require.NoError(t, err, "There is nothing left to resolve")
+		assert.Equal(t, "Read daily", updated.Title)
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("Version policy: Clocks are stored but do not settle conflicts", func(t *testing.T) {
+		svc, existing := setup(t, services.ConflictPolicyVersion)
+
+		_, err := svc.Update(ctx, services.UpdateHabitInput{ID: existing.ID, UserID: "user-1", Title: ptr("Read more"), Version: 1, HLC: newer})
+
+		assert.ErrorIs(t, err, domain.ErrHabitConflict, "Both sides changed the title")
+	})
+
+	t.Run("Fail: Clocks too far in the future are rejected", func(t *testing.T) {
+		svc, existing := setup(t, services.ConflictPolicyLastWriterWins)
+
+		broken := domain.HLC{Wall: now.Add(24 * time.Hour), Node: "broken"}.String()
+		_, err := svc.Update(ctx, services.UpdateHabitInput{ID: existing.ID, UserID: "user-1", Title: ptr("Mine"), Version: 1, HLC: broken})
+		assert.ErrorIs(t, err, domain.ErrHLCTooFarAhead)
+
+		_, err = svc.Create(ctx, services.CreateHabitInput{UserID: "user-1", Title: "Run", HLC: "yesterday"})
+		assert.ErrorIs(t, err, domain.ErrInvalidHLC)
+	})
+}
+
 func TestHabitService_Delete(t *testing.T) {
 	t.Run("Success: Should soft-delete via Update", func(t *testing.T) {
 		repo := NewMockRepo()
@@ -392,6 +699,219 @@ func TestHabitService_Delete(t *testing.T) {
 	})
 }
 
+func TestHabitService_Finalize(t *testing.T) {
+	t.Run("Success: Archives the habit with a version bump", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Ended", "user-1")
+		repo.Create(context.Background(), h)
+		stored, _ := repo.GetByID(context.Background(), h.ID)
+
+		err := svc.Finalize(context.Background(), stored)
+
+		assert.NoError(t, err)
+		rawH := repo.store[h.ID]
+		assert.NotNil(t, rawH.ArchivedAt)
+		assert.Equal(t, h.Version+1, rawH.Version)
+	})
+
+	t.Run("Success: Already archived habits are left alone", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Archived", "user-1")
+		h.Archive()
+		repo.Create(context.Background(), h)
+
+		err := svc.Finalize(context.Background(), h)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, repo.store[h.ID].Version)
+	})
+}
+
+func TestHabitService_ArchiveAndUnarchive(t *testing.T) {
+	t.Run("Success: Archive and unarchive bump the version", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		repo.Create(context.Background(), h)
+
+		archived, err := svc.Archive(context.Background(), h.ID, "user-1", 0)
+		assert.NoError(t, err)
+		assert.NotNil(t, archived.ArchivedAt)
+		assert.Equal(t, 2, repo.store[h.ID].Version)
+
+		restored, err := svc.Unarchive(context.Background(), h.ID, "user-1", 0)
+		assert.NoError(t, err)
+		assert.Nil(t, restored.ArchivedAt)
+		assert.Equal(t, 3, repo.store[h.ID].Version)
+	})
+
+	t.Run("Success: Archiving an archived habit changes nothing", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Archived", "user-1")
+		h.Archive()
+		repo.Create(context.Background(), h)
+
+		habit, err := svc.Archive(context.Background(), h.ID, "user-1", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, habit.Version)
+		assert.Equal(t, 1, repo.store[h.ID].Version)
+	})
+
+	t.Run("Success: A matching version is accepted", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		repo.Create(context.Background(), h)
+
+		archived, err := svc.Archive(context.Background(), h.ID, "user-1", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, archived.Version)
+	})
+
+	t.Run("Fail: An outdated version returns the server copy", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		h.Version = 3
+		repo.Create(context.Background(), h)
+
+		_, err := svc.Archive(context.Background(), h.ID, "user-1", 2)
+
+		var conflict *domain.HabitConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.ErrorIs(t, err, domain.ErrHabitConflict)
+		assert.Equal(t, []string{"archived_at"}, conflict.Fields)
+		assert.Equal(t, 3, conflict.Server.Version)
+		require.Len(t, conflict.Diff, 1)
+		assert.Equal(t, "", conflict.Diff[0].Server)
+		assert.Nil(t, repo.store[h.ID].ArchivedAt)
+		assert.Equal(t, 3, repo.store[h.ID].Version)
+	})
+
+	t.Run("Success: An outdated version that asks for the current state changes nothing", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Seasonal", "user-1")
+		h.Version = 3
+		repo.Create(context.Background(), h)
+
+		habit, err := svc.Unarchive(context.Background(), h.ID, "user-1", 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, habit.Version)
+	})
+
+	t.Run("Fail: Security - Cannot archive other user's habit (IDOR)", func(t *testing.T) {
+		repo := NewMockRepo()
+		svc := newTestService(repo)
+
+		h, _ := domain.NewHabit("", "Don't Touch", "user-1")
+		repo.Create(context.Background(), h)
+
+		_, err := svc.Archive(context.Background(), h.ID, "user-2", 0)
+
+		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
+		assert.Nil(t, repo.store[h.ID].ArchivedAt)
+	})
+}
+
+func TestHabitService_Reorder(t *testing.T) {
+	setup := func() (*MockRepo, *services.HabitService, *fakeTransactor, []*domain.Habit) {
+		repo := NewMockRepo()
+		tx := &fakeTransactor{}
+		svc := services.NewHabitService(repo, nil, services.WriteOptions{Tx: tx})
+
+		var habits []*domain.Habit
+		for i, title := range []string{"A", "B", "C"} {
+			h, _ := domain.NewHabit("", title, "user-1")
+			h.SortOrder = i
+			repo.Create(context.Background(), h)
+			habits = append(habits, h)
+		}
+		return repo, svc, tx, habits
+	}
+
+	t.Run("Success: Moves habits and bumps only the ones that moved", func(t *testing.T) {
+		repo, svc, tx, h := setup()
+
+		ordered, err := svc.Reorder(context.Background(), "user-1", []string{h[1].ID, h[0].ID, h[2].ID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, tx.calls)
+		assert.Equal(t, 1, repo.lockedReads, "The habits are read with a lock, not from the cache")
+		assert.Equal(t, []string{h[1].ID, h[0].ID, h[2].ID}, []string{ordered[0].ID, ordered[1].ID, ordered[2].ID})
+		assert.Equal(t, 0, repo.store[h[1].ID].SortOrder)
+		assert.Equal(t, 1, repo.store[h[0].ID].SortOrder)
+		assert.Equal(t, 2, repo.store[h[0].ID].Version)
+		assert.Equal(t, 2, repo.store[h[1].ID].Version)
+		assert.Equal(t, 1, repo.store[h[2].ID].Version)
+	})
+
+	t.Run("Success: Archived habits are left out of the order", func(t *testing.T) {
+		repo, svc, _, h := setup()
+		repo.store[h[2].ID].Archive()
+
+		ordered, err := svc.Reorder(context.Background(), "user-1", []string{h[1].ID, h[0].ID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, repo.store[h[2].ID].SortOrder)
+		require.Len(t, ordered, 3, "The archived habit is still returned")
+		assert.Equal(t, []string{h[1].ID, h[0].ID, h[2].ID}, []string{ordered[0].ID, ordered[1].ID, ordered[2].ID})
+	})
+
+	t.Run("Fail: The order must list every active habit exactly once", func(t *testing.T) {
+		repo, svc, _, h := setup()
+		other, _ := domain.NewHabit("", "Other", "user-2")
+		repo.Create(context.Background(), other)
+
+		for name, ids := range map[string][]string{
+			"missing":   {h[1].ID, h[0].ID},
+			"duplicate": {h[1].ID, h[1].ID, h[0].ID},
+			"unknown":   {h[1].ID, h[0].ID, other.ID},
+		} {
+			_, err := svc.Reorder(context.Background(), "user-1", ids)
+			assert.ErrorIs(t, err, domain.ErrInvalidHabitOrder, name)
+		}
+		assert.Equal(t, 1, repo.store[h[0].ID].Version)
+	})
+
+	t.Run("Fail: Archived habits cannot be moved", func(t *testing.T) {
+		repo, svc, _, h := setup()
+		repo.store[h[2].ID].Archive()
+
+		_, err := svc.Reorder(context.Background(), "user-1", []string{h[2].ID, h[0].ID})
+
+		assert.ErrorIs(t, err, domain.ErrHabitArchived)
+	})
+
+	t.Run("Conflict: A habit changed under the reorder comes back with the server copy", func(t *testing.T) {
+		repo, _, _, h := setup()
+		svc := services.NewHabitService(&racingRepo{repo}, nil, services.WriteOptions{Tx: &fakeTransactor{}})
+
+		_, err := svc.Reorder(context.Background(), "user-1", []string{h[1].ID, h[0].ID, h[2].ID})
+
+		require.ErrorIs(t, err, domain.ErrHabitConflict)
+		var conflict *domain.HabitConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{"sort_order"}, conflict.Fields)
+		require.NotNil(t, conflict.Server)
+		assert.Equal(t, h[1].ID, conflict.Server.ID)
+		assert.Equal(t, []domain.FieldDiff{{Field: "sort_order", Client: 0, Server: 1}}, conflict.Diff)
+	})
+}
+
 func TestHabitService_ListAndGet(t *testing.T) {
 	repo := NewMockRepo()
 	svc := newTestService(repo)
@@ -432,20 +952,33 @@ func TestHabitService_SyncLogic(t *testing.T) {
 		ctx := context.Background()
 
 		h1, _ := domain.NewHabit("", "Old", "user-1")
-		h1.UpdatedAt = time.Now().Add(-1 * time.Hour)
 		repo.Create(ctx, h1)
 
-		lastSync := time.Now()
-		time.Sleep(1 * time.Millisecond)
+		lastSync := h1.ChangeSeq
 
 		h2, _ := domain.NewHabit("", "New", "user-1")
-		h2.UpdatedAt = time.Now().Add(1 * time.Minute)
 		repo.Create(ctx, h2)
 
-		deltas, err := svc.GetDelta(ctx, "user-1", lastSync)
+		deltas, hasMore, err := svc.GetDelta(ctx, "user-1", lastSync, 0)
 
 		assert.NoError(t, err)
+		assert.False(t, hasMore)
 		assert.Len(t, deltas, 1)
 		assert.Equal(t, h2.ID, deltas[0].ID)
 	})
 }
+
+func (m *MockRepo) record(h *domain.Habit) {
+	if m.history[h.ID] == nil {
+		m.history[h.ID] = make(map[int]domain.Habit)
+	}
+	m.history[h.ID][h.Version] = *h
+}
+
+func (m *MockRepo) GetVersion(ctx context.Context, id string, version int) (*domain.Habit, error) {
+	h, ok := m.history[id][version]
+	if !ok {
+		return nil, domain.ErrHabitVersionNotFound
+	}
+	return &h, nil
+}
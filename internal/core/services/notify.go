@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+const notifyTimeout = 2 * time.Second
+
+// notifyChange tells the user's connected devices that their data changed,
+// once the enclosing transaction of tx has committed.
+func notifyChange(ctx context.Context, tx domain.Transactor, notifier domain.ChangeNotifier, userID string) {
+	if notifier == nil {
+		return
+	}
+
+	tx.AfterCommit(ctx, func() {
+		pubCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+		defer cancel()
+
+		if err := notifier.Publish(pubCtx, userID); err != nil {
+			log.Printf("[NOTIFY] Failed to publish change for user %s: %v", userID, err)
+		}
+	})
+}
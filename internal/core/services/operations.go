@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type deviceContextKey struct{}
+
+// WithDevice marks the device a request was made from, for the operation log.
+func WithDevice(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, deviceContextKey{}, deviceID)
+}
+
+func deviceFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(deviceContextKey{}).(string)
+	return id
+}
+
+// operationLog records writes to the operations table, in the transaction
+// of the write it is called from. The zero value is disabled.
+type operationLog struct {
+	repo domain.OperationRepository
+}
+
+// record appends op with the JSON of the row before and after the write;
+// either may be nil.
+func (l operationLog) record(ctx context.Context, op *domain.Operation, before, after interface{}) error {
+	if l.repo == nil {
+		return nil
+	}
+
+	var err error
+	if op.OldValue, err = marshalOperationValue(before); err != nil {
+		return err
+	}
+	if op.NewValue, err = marshalOperationValue(after); err != nil {
+		return err
+	}
+	op.DeviceID = deviceFromContext(ctx)
+
+	return l.repo.Append(ctx, op)
+}
+
+func marshalOperationValue(v interface{}) (json.RawMessage, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		return v, nil
+	}
+	return json.Marshal(v)
+}
+
+type OperationService struct {
+	repo domain.OperationRepository
+}
+
+func NewOperationService(repo domain.OperationRepository) *OperationService {
+	return &OperationService{
+		repo: repo,
+	}
+}
+
+// List returns one page of the history matching q, newest first, and
+// reports whether older operations are left.
+func (s *OperationService) List(ctx context.Context, q domain.OperationQuery) ([]*domain.Operation, bool, error) {
+	if q.UserID == "" {
+		return nil, false, domain.ErrUnauthorized
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = domain.DefaultOperationPageSize
+	}
+	if limit > domain.MaxOperationPageSize {
+		limit = domain.MaxOperationPageSize
+	}
+	q.Limit = limit + 1
+
+	ops, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(ops) > limit {
+		return ops[:limit], true, nil
+	}
+	return ops, false, nil
+}
@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type memoryOperationRepo struct {
+	ops []*domain.Operation
+}
+
+func (r *memoryOperationRepo) Append(ctx context.Context, op *domain.Operation) error {
+	op.ID = int64(len(r.ops) + 1)
+	op.CreatedAt = time.Now().UTC()
+	r.ops = append(r.ops, op)
+	return nil
+}
+
+func (r *memoryOperationRepo) List(ctx context.Context, q domain.OperationQuery) ([]*domain.Operation, error) {
+	var result []*domain.Operation
+	for i := len(r.ops) - 1; i >= 0 && len(result) < q.Limit; i-- {
+		op := r.ops[i]
+		if op.UserID != q.UserID || (q.HabitID != "" && op.HabitID != q.HabitID) || (q.Before > 0 && op.ID >= q.Before) {
+			continue
+		}
+		result = append(result, op)
+	}
+	return result, nil
+}
+
+func TestHabitService_OperationLog(t *testing.T) {
+	ops := &memoryOperationRepo{}
+	tx := &fakeTransactor{}
+	svc := services.NewHabitService(NewMockRepo(), nil, services.WriteOptions{Tx: tx, Operations: ops})
+
+	ctx := services.WithDevice(context.Background(), "device-1")
+
+	created, err := svc.Create(ctx, services.CreateHabitInput{ID: "habit-1", UserID: "user-1", Title: "Read"})
+	require.NoError(t, err)
+
+	archivedAt := time.Now().UTC().Format(time.RFC3339)
+	_, err = svc.Update(ctx, services.UpdateHabitInput{ID: created.ID, UserID: "user-1", ArchivedAt: &archivedAt, Version: 1})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, services.UpdateHabitInput{ID: created.ID, UserID: "user-1", Title: ptr(""), Version: 2})
+	require.Error(t, err, "A rejected write is not logged")
+
+	require.NoError(t, svc.Delete(ctx, created.ID, "user-1"))
+
+	require.Len(t, ops.ops, 3)
+	assert.Equal(t, 4, tx.calls, "Each write runs in its own transaction")
+
+	create, archive, del := ops.ops[0], ops.ops[1], ops.ops[2]
+
+	assert.Equal(t, domain.OperationCreate, create.Op)
+	assert.Equal(t, domain.OperationEntityHabit, create.EntityType)
+	assert.Equal(t, "habit-1", create.EntityID)
+	assert.Equal(t, "device-1", create.DeviceID)
+	assert.Nil(t, create.OldValue)
+
+	assert.Equal(t, domain.OperationArchive, archive.Op)
+	var before, after domain.Habit
+	require.NoError(t, json.Unmarshal(archive.OldValue, &before))
+	require.NoError(t, json.Unmarshal(archive.NewValue, &after))
+	assert.Nil(t, before.ArchivedAt)
+	assert.NotNil(t, after.ArchivedAt)
+	assert.Equal(t, 2, after.Version)
+
+	assert.Equal(t, domain.OperationDelete, del.Op)
+	assert.NotNil(t, del.OldValue)
+	assert.Nil(t, del.NewValue)
+}
+
+func TestEntryService_OperationLog(t *testing.T) {
+	ops := &memoryOperationRepo{}
+	entryRepo := new(MockHabitEntryRepo)
+	svc := services.NewEntryService(entryRepo, new(MockHabitRepo), getTestWorker(), nil, services.WriteOptions{Tx: &fakeTransactor{}, Operations: ops})
+
+	entry := &domain.HabitEntry{ID: "entry-1", HabitID: "habit-1", UserID: "user-1", Value: 3, Version: 2}
+	entryRepo.On("GetByID", mock.Anything, "entry-1").Return(entry, nil)
+	entryRepo.On("Delete", mock.Anything, "entry-1", "user-1").Return(nil)
+
+	require.NoError(t, svc.Delete(context.Background(), "entry-1", "user-1"))
+
+	require.Len(t, ops.ops, 1)
+	op := ops.ops[0]
+	assert.Equal(t, domain.OperationDelete, op.Op)
+	assert.Equal(t, domain.OperationEntityEntry, op.EntityType)
+	assert.Equal(t, "entry-1", op.EntityID)
+	assert.Equal(t, "habit-1", op.HabitID, "Entry operations belong to their habit's history")
+	assert.Empty(t, op.DeviceID)
+	assert.JSONEq(t, `"entry-1"`, string(mustField(t, op.OldValue, "id")))
+	assert.Nil(t, op.NewValue)
+}
+
+func mustField(t *testing.T, raw json.RawMessage, field string) json.RawMessage {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(raw, &fields))
+	return fields[field]
+}
+
+func TestOperationService_List(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryOperationRepo{}
+	for i := 0; i < 5; i++ {
+		habitID := "habit-a"
+		if i%2 == 1 {
+			habitID = "habit-b"
+		}
+		require.NoError(t, repo.Append(ctx, &domain.Operation{UserID: "user-1", HabitID: habitID, Op: domain.OperationUpdate}))
+	}
+	require.NoError(t, repo.Append(ctx, &domain.Operation{UserID: "user-2", HabitID: "habit-c", Op: domain.OperationCreate}))
+
+	svc := services.NewOperationService(repo)
+
+	t.Run("Pages newest first", func(t *testing.T) {
+		page, hasMore, err := svc.List(ctx, domain.OperationQuery{UserID: "user-1", Limit: 3})
+		require.NoError(t, err)
+		require.Len(t, page, 3)
+		assert.True(t, hasMore)
+		assert.Equal(t, int64(5), page[0].ID)
+
+		page, hasMore, err = svc.List(ctx, domain.OperationQuery{UserID: "user-1", Limit: 3, Before: page[2].ID})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.False(t, hasMore)
+		assert.Equal(t, int64(1), page[1].ID)
+	})
+
+	t.Run("Narrows to one habit", func(t *testing.T) {
+		page, _, err := svc.List(ctx, domain.OperationQuery{UserID: "user-1", HabitID: "habit-b"})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		for _, op := range page {
+			assert.Equal(t, "habit-b", op.HabitID)
+		}
+	})
+
+	t.Run("Fail: Requires a user", func(t *testing.T) {
+		_, _, err := svc.List(ctx, domain.OperationQuery{})
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+}
@@ -0,0 +1,17 @@
+package services
+
+import "github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+
+// WriteOptions configures how the habit and entry services apply writes.
+// The zero value keeps the version conflict policy, runs writes outside of
+// a transaction and records no operations.
+type WriteOptions struct {
+	// Clock configures HLC validation and conflict resolution.
+	Clock ClockPolicy
+	// Tx runs each write, and every row it touches, in one transaction,
+	// and holds back its notifications and worker jobs until it commits.
+	Tx domain.Transactor
+	// Operations records every write within the same transaction as the
+	// write itself.
+	Operations domain.OperationRepository
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/workers"
+)
+
+type PauseService struct {
+	repo      domain.PauseRepository
+	habitRepo domain.HabitRepository
+	worker    *workers.StreakWorker
+}
+
+func NewPauseService(repo domain.PauseRepository, habitRepo domain.HabitRepository, worker *workers.StreakWorker) *PauseService {
+	return &PauseService{
+		repo:      repo,
+		habitRepo: habitRepo,
+		worker:    worker,
+	}
+}
+
+type CreatePauseInput struct {
+	UserID string
+	// HabitID is the habit to pause; empty pauses every habit of the user.
+	HabitID   string
+	StartDate string
+	EndDate   string
+	Reason    string
+	// Location is the timezone calendar dates in StartDate and EndDate are
+	// read in; nil means UTC.
+	Location *time.Location
+}
+
+type UpdatePauseInput struct {
+	ID        string
+	UserID    string
+	StartDate *string
+	EndDate   *string
+	Reason    *string
+	Location  *time.Location
+}
+
+// userPauses loads the user's pauses, or none when pauses are not enabled.
+func userPauses(ctx context.Context, repo domain.PauseRepository, userID string) (domain.Pauses, error) {
+	if repo == nil {
+		return nil, nil
+	}
+	return repo.ListByUserID(ctx, userID)
+}
+
+// Create pauses a habit, or the whole account, from the start date (today
+// by default) to the end date, or until further notice without one.
+func (s *PauseService) Create(ctx context.Context, input CreatePauseInput) (*domain.Pause, error) {
+	if input.UserID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	if input.HabitID != "" {
+		habit, err := s.habitRepo.GetByID(ctx, input.HabitID)
+		if err != nil {
+			return nil, err
+		}
+		if habit.UserID != input.UserID {
+			return nil, domain.ErrHabitNotFound
+		}
+	}
+
+	start, end, err := pauseDates(nonEmptyOrNil(input.StartDate), nonEmptyOrNil(input.EndDate), nil, input.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	pause, err := domain.NewPause(uuid.NewString(), input.UserID, input.HabitID, start, end, input.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, pause); err != nil {
+		return nil, err
+	}
+
+	s.recomputeStreaks(ctx, pause)
+	return pause, nil
+}
+
+func (s *PauseService) List(ctx context.Context, userID string) ([]*domain.Pause, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// Update moves or ends a pause, or changes its reason. An empty end date
+// makes it open-ended again.
+func (s *PauseService) Update(ctx context.Context, input UpdatePauseInput) (*domain.Pause, error) {
+	pause, err := s.get(ctx, input.ID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := pauseDates(input.StartDate, input.EndDate, pause, input.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := pause.Reason
+	if input.Reason != nil {
+		reason = *input.Reason
+	}
+
+	if err := pause.Update(start, end, reason); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, pause); err != nil {
+		return nil, err
+	}
+
+	s.recomputeStreaks(ctx, pause)
+	return pause, nil
+}
+
+// Delete removes a pause: its days count again, as if it never happened.
+func (s *PauseService) Delete(ctx context.Context, id, userID string) error {
+	pause, err := s.get(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.recomputeStreaks(ctx, pause)
+	return nil
+}
+
+func (s *PauseService) get(ctx context.Context, id, userID string) (*domain.Pause, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	pause, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pause.UserID != userID {
+		return nil, domain.ErrPauseNotFound
+	}
+	return pause, nil
+}
+
+// pauseDates resolves the dates sent by a client against the current ones
+// of pause, if any. A new pause starts today in loc by default.
+func pauseDates(start, end *string, pause *domain.Pause, loc *time.Location) (time.Time, *time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var startDate time.Time
+	var endDate *time.Time
+	if pause != nil {
+		startDate, endDate = pause.StartDate, pause.EndDate
+	} else {
+		startDate = domain.Today(loc)
+	}
+
+	if start != nil && *start != "" {
+		t, err := domain.ParseHabitDate(*start, loc)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		startDate = t
+	}
+
+	if end != nil {
+		endDate = nil
+		if *end != "" {
+			t, err := domain.ParseHabitDate(*end, loc)
+			if err != nil {
+				return time.Time{}, nil, err
+			}
+			endDate = &t
+		}
+	}
+
+	return startDate, endDate, nil
+}
+
+// recomputeStreaks has the streaks of every habit the pause applies to
+// recomputed, now that its days count differently.
+func (s *PauseService) recomputeStreaks(ctx context.Context, pause *domain.Pause) {
+	if pause.HabitID != "" {
+		s.worker.Enqueue(pause.HabitID)
+		return
+	}
+
+	habits, err := s.habitRepo.ListByUserID(ctx, pause.UserID)
+	if err != nil {
+		log.Printf("[ERROR] Listing habits to recompute streaks for user %s failed: %v", pause.UserID, err)
+		return
+	}
+	for _, h := range habits {
+		s.worker.Enqueue(h.ID)
+	}
+}
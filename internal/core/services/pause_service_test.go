@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type MockPauseRepo struct {
+	store map[string]*domain.Pause
+}
+
+func NewMockPauseRepo(pauses ...*domain.Pause) *MockPauseRepo {
+	m := &MockPauseRepo{store: make(map[string]*domain.Pause)}
+	for _, p := range pauses {
+		m.store[p.ID] = p
+	}
+	return m
+}
+
+func (m *MockPauseRepo) Create(ctx context.Context, pause *domain.Pause) error {
+	clone := *pause
+	m.store[pause.ID] = &clone
+	return nil
+}
+
+func (m *MockPauseRepo) GetByID(ctx context.Context, id string) (*domain.Pause, error) {
+	p, ok := m.store[id]
+	if !ok {
+		return nil, domain.ErrPauseNotFound
+	}
+	clone := *p
+	return &clone, nil
+}
+
+func (m *MockPauseRepo) ListByUserID(ctx context.Context, userID string) ([]*domain.Pause, error) {
+	var list []*domain.Pause
+	for _, p := range m.store {
+		if p.UserID == userID {
+			clone := *p
+			list = append(list, &clone)
+		}
+	}
+	return list, nil
+}
+
+func (m *MockPauseRepo) Update(ctx context.Context, pause *domain.Pause) error {
+	if _, ok := m.store[pause.ID]; !ok {
+		return domain.ErrPauseNotFound
+	}
+	clone := *pause
+	m.store[pause.ID] = &clone
+	return nil
+}
+
+func (m *MockPauseRepo) Delete(ctx context.Context, id string) error {
+	if _, ok := m.store[id]; !ok {
+		return domain.ErrPauseNotFound
+	}
+	delete(m.store, id)
+	return nil
+}
+
+func TestPauseService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*services.PauseService, *MockPauseRepo, *MockRepo) {
+		pauses := NewMockPauseRepo()
+		habits := NewMockRepo()
+		return services.NewPauseService(pauses, habits, getTestWorker()), pauses, habits
+	}
+
+	t.Run("Success: Account-wide pause starts today by default", func(t *testing.T) {
+		svc, pauses, _ := setup()
+
+		pause, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-1", EndDate: "2099-01-01", Reason: "Vacation"})
+
+		require.NoError(t, err)
+		assert.Empty(t, pause.HabitID)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), pause.StartDate.Format("2006-01-02"))
+		assert.Contains(t, pauses.store, pause.ID)
+	})
+
+	t.Run("Success: Pause a single habit in the user's timezone", func(t *testing.T) {
+		rome, err := time.LoadLocation("Europe/Rome")
+		if err != nil {
+			t.Skip("timezone data not available")
+		}
+
+		svc, _, habits := setup()
+		h, _ := domain.NewHabit("", "Run", "user-1")
+		habits.Create(ctx, h)
+
+		pause, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-1", HabitID: h.ID, StartDate: "2026-08-01", Location: rome})
+
+		require.NoError(t, err)
+		assert.Equal(t, h.ID, pause.HabitID)
+		assert.Equal(t, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), pause.StartDate)
+		assert.Nil(t, pause.EndDate)
+	})
+
+	t.Run("Fail: Cannot pause another user's habit (IDOR)", func(t *testing.T) {
+		svc, _, habits := setup()
+		h, _ := domain.NewHabit("", "Not Yours", "user-1")
+		habits.Create(ctx, h)
+
+		_, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-2", HabitID: h.ID})
+
+		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
+	})
+
+	t.Run("Success: End an open-ended pause, then clear its end", func(t *testing.T) {
+		svc, _, _ := setup()
+		pause, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-1", StartDate: "2026-08-01"})
+		require.NoError(t, err)
+
+		ended, err := svc.Update(ctx, services.UpdatePauseInput{ID: pause.ID, UserID: "user-1", EndDate: ptr("2026-08-10")})
+		require.NoError(t, err)
+		require.NotNil(t, ended.EndDate)
+		assert.Equal(t, "2026-08-10", ended.EndDate.Format("2006-01-02"))
+
+		_, err = svc.Update(ctx, services.UpdatePauseInput{ID: pause.ID, UserID: "user-1", EndDate: ptr("2026-07-01")})
+		assert.ErrorIs(t, err, domain.ErrInvalidDateRange)
+
+		reopened, err := svc.Update(ctx, services.UpdatePauseInput{ID: pause.ID, UserID: "user-1", EndDate: ptr(""), Reason: ptr("Sick")})
+		require.NoError(t, err)
+		assert.Nil(t, reopened.EndDate)
+		assert.Equal(t, "Sick", reopened.Reason)
+	})
+
+	t.Run("Fail: Other users' pauses are not found", func(t *testing.T) {
+		svc, _, _ := setup()
+		pause, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-1"})
+		require.NoError(t, err)
+
+		_, err = svc.Update(ctx, services.UpdatePauseInput{ID: pause.ID, UserID: "user-2", Reason: ptr("Mine now")})
+		assert.ErrorIs(t, err, domain.ErrPauseNotFound)
+		assert.ErrorIs(t, svc.Delete(ctx, pause.ID, "user-2"), domain.ErrPauseNotFound)
+	})
+
+	t.Run("Success: Delete a pause", func(t *testing.T) {
+		svc, pauses, _ := setup()
+		pause, err := svc.Create(ctx, services.CreatePauseInput{UserID: "user-1"})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Delete(ctx, pause.ID, "user-1"))
+		assert.Empty(t, pauses.store)
+	})
+}
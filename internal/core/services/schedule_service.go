@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type ScheduleService struct {
+	habitRepo domain.HabitRepository
+	entryRepo domain.HabitEntryRepository
+	pauses    domain.PauseRepository
+}
+
+// NewScheduleService leaves the habits paused in pauses on a day out of its
+// due list; pauses may be nil.
+func NewScheduleService(habitRepo domain.HabitRepository, entryRepo domain.HabitEntryRepository, pauses domain.PauseRepository) *ScheduleService {
+	return &ScheduleService{
+		habitRepo: habitRepo,
+		entryRepo: entryRepo,
+		pauses:    pauses,
+	}
+}
+
+// DueOn returns the user's habits due on the calendar date of day, read in
+// day's location, with their progress toward the target on that day.
+func (s *ScheduleService) DueOn(ctx context.Context, userID string, day time.Time) (*domain.DueHabits, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	loc := day.Location()
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	habits, err := s.habitRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pauses, err := userPauses(ctx, s.pauses, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*domain.Habit
+	fetchStart := dayStart
+	for _, h := range habits {
+		if !h.IsDueOn(dayStart) || pauses.Covers(h.ID, dayStart) {
+			continue
+		}
+		due = append(due, h)
+		if h.IsPeriodic() {
+			if start := h.PeriodStart(dayStart); start.Before(fetchStart) {
+				fetchStart = start
+			}
+		}
+	}
+
+	result := &domain.DueHabits{
+		Date:     dayStart.Format("2006-01-02"),
+		Timezone: loc.String(),
+		Habits:   make([]domain.DueHabit, 0, len(due)),
+	}
+	if len(due) == 0 {
+		return result, nil
+	}
+
+	entries, err := s.entryRepo.ListByUserIDAndDateRange(ctx, userID, fetchStart.UTC(), dayEnd.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]map[string]int)
+	for _, e := range entries {
+		if _, ok := values[e.HabitID]; !ok {
+			values[e.HabitID] = make(map[string]int)
+		}
+		values[e.HabitID][e.CompletionDate.In(loc).Format("2006-01-02")] += e.Value
+	}
+
+	for _, h := range due {
+		item := domain.DueHabit{
+			Habit:    h,
+			Progress: values[h.ID][result.Date],
+		}
+		item.Completed = item.Progress >= h.TargetValue
+
+		if h.IsPeriodic() {
+			periodKey := h.PeriodStart(dayStart).Format("2006-01-02")
+			item.PeriodProgress = periodCompletions(h, values[h.ID], loc)[periodKey]
+			item.PeriodTarget = h.PeriodTarget()
+		}
+
+		if item.Completed {
+			result.Completed++
+		}
+		result.Habits = append(result.Habits, item)
+	}
+
+	sort.SliceStable(result.Habits, func(i, j int) bool {
+		return result.Habits[i].Habit.SortOrder < result.Habits[j].Habit.SortOrder
+	})
+	result.Total = len(result.Habits)
+
+	return result, nil
+}
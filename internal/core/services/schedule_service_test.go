@@ -0,0 +1,142 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+func TestScheduleService_DueOn(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-schedule-1"
+
+	// Wednesday 14 October 2026.
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success: Lists due habits with today's progress", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewScheduleService(habitRepo, entryRepo, nil)
+
+		habits := []*domain.Habit{
+			{ID: "water", UserID: userID, Title: "Water", SortOrder: 2, TargetValue: 2000, FrequencyType: domain.HabitFreqDaily, StartDate: start},
+			{ID: "gym", UserID: userID, Title: "Gym", SortOrder: 1, TargetValue: 1, FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{1, 5}, StartDate: start},
+			{ID: "read", UserID: userID, Title: "Read", SortOrder: 0, TargetValue: 1, FrequencyType: domain.HabitFreqInterval, Interval: 13, StartDate: start},
+			{ID: "run", UserID: userID, Title: "Run", SortOrder: 3, TargetValue: 1, FrequencyType: domain.HabitFreqWeekly, TimesPerPeriod: 3, StartDate: start},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+		dayEnd := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "water", UserID: userID, Value: 1500, CompletionDate: day.Add(9 * time.Hour)},
+			{ID: "e2", HabitID: "water", UserID: userID, Value: 500, CompletionDate: day.Add(15 * time.Hour)},
+			{ID: "e3", HabitID: "run", UserID: userID, Value: 1, CompletionDate: monday.Add(7 * time.Hour)},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, monday, dayEnd).Return(entries, nil)
+
+		due, err := svc.DueOn(ctx, userID, day)
+		require.NoError(t, err)
+
+		assert.Equal(t, "2026-10-14", due.Date)
+		assert.Equal(t, "UTC", due.Timezone)
+		require.Len(t, due.Habits, 3)
+		assert.Equal(t, 3, due.Total)
+		assert.Equal(t, 1, due.Completed)
+
+		assert.Equal(t, "read", due.Habits[0].Habit.ID)
+		assert.False(t, due.Habits[0].Completed)
+
+		assert.Equal(t, "water", due.Habits[1].Habit.ID)
+		assert.Equal(t, 2000, due.Habits[1].Progress)
+		assert.True(t, due.Habits[1].Completed)
+
+		assert.Equal(t, "run", due.Habits[2].Habit.ID)
+		assert.Equal(t, 0, due.Habits[2].Progress)
+		assert.Equal(t, 1, due.Habits[2].PeriodProgress)
+		assert.Equal(t, 3, due.Habits[2].PeriodTarget)
+
+		entryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Timezone: Entries count on the user's local day", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Skip("timezone data not available")
+		}
+
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewScheduleService(habitRepo, entryRepo, nil)
+
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "Walk", TargetValue: 1, FrequencyType: domain.HabitFreqDaily, StartDate: start},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		// 14 October 01:00 in Tokyo is still 13 October in UTC.
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: time.Date(2026, 10, 13, 16, 0, 0, 0, time.UTC)},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return(entries, nil)
+
+		due, err := svc.DueOn(ctx, userID, time.Date(2026, 10, 14, 12, 0, 0, 0, tokyo))
+		require.NoError(t, err)
+
+		require.Len(t, due.Habits, 1)
+		assert.Equal(t, "Asia/Tokyo", due.Timezone)
+		assert.True(t, due.Habits[0].Completed)
+	})
+
+	t.Run("Pauses: Paused habits are not due", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+		pauseEnd := day
+		svc := services.NewScheduleService(habitRepo, entryRepo, NewMockPauseRepo(
+			&domain.Pause{ID: "p1", UserID: userID, HabitID: "run", StartDate: start, EndDate: &pauseEnd},
+		))
+
+		habits := []*domain.Habit{
+			{ID: "run", UserID: userID, Title: "Run", TargetValue: 1, FrequencyType: domain.HabitFreqDaily, StartDate: start},
+			{ID: "read", UserID: userID, Title: "Read", TargetValue: 1, FrequencyType: domain.HabitFreqDaily, StartDate: start},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return([]domain.HabitEntry{}, nil)
+
+		due, err := svc.DueOn(ctx, userID, day)
+		require.NoError(t, err)
+
+		require.Len(t, due.Habits, 1)
+		assert.Equal(t, "read", due.Habits[0].Habit.ID)
+
+		due, err = svc.DueOn(ctx, userID, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		assert.Len(t, due.Habits, 2, "The pause is over the day after its end date")
+	})
+
+	t.Run("Success: Nothing due skips the entries lookup", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+		svc := services.NewScheduleService(habitRepo, entryRepo, nil)
+
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "Gym", FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{0}, StartDate: start},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		due, err := svc.DueOn(ctx, userID, day)
+		require.NoError(t, err)
+
+		assert.Empty(t, due.Habits)
+		assert.Equal(t, 0, due.Total)
+		entryRepo.AssertNotCalled(t, "ListByUserIDAndDateRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
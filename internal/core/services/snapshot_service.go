@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type SnapshotService struct {
+	repo domain.SnapshotRepository
+}
+
+func NewSnapshotService(repo domain.SnapshotRepository) *SnapshotService {
+	return &SnapshotService{
+		repo: repo,
+	}
+}
+
+// Export writes the full current state of the user to w, for devices that
+// bootstrap from a snapshot and then switch to delta sync from its cursor.
+func (s *SnapshotService) Export(ctx context.Context, userID string, w domain.SnapshotWriter) error {
+	if userID == "" {
+		return domain.ErrUnauthorized
+	}
+	return s.repo.Snapshot(ctx, userID, w)
+}
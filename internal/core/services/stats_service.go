@@ -10,12 +10,16 @@ import (
 type StatsService struct {
 	habitRepo domain.HabitRepository
 	entryRepo domain.HabitEntryRepository
+	pauses    domain.PauseRepository
 }
 
-func NewStatsService(habitRepo domain.HabitRepository, entryRepo domain.HabitEntryRepository) *StatsService {
+// NewStatsService leaves the days paused in pauses out of the completion
+// rates; pauses may be nil.
+func NewStatsService(habitRepo domain.HabitRepository, entryRepo domain.HabitEntryRepository, pauses domain.PauseRepository) *StatsService {
 	return &StatsService{
 		habitRepo: habitRepo,
 		entryRepo: entryRepo,
+		pauses:    pauses,
 	}
 }
 
@@ -23,14 +27,34 @@ func (s *StatsService) GetWeeklyStats(ctx context.Context, input domain.StatsInp
 	localStart := time.Date(input.StartDate.Year(), input.StartDate.Month(), input.StartDate.Day(), 0, 0, 0, 0, input.Location)
 	localEnd := time.Date(input.EndDate.Year(), input.EndDate.Month(), input.EndDate.Day(), 23, 59, 59, 999999999, input.Location)
 
-	dbStart := localStart.UTC()
-	dbEnd := localEnd.UTC()
-
 	habits, err := s.habitRepo.ListByUserID(ctx, input.UserID)
 	if err != nil {
 		return nil, err
 	}
 
+	pauses, err := userPauses(ctx, s.pauses, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Weekly and monthly habits are judged on their whole week or month, so
+	// entries are loaded for the periods overlapping the range.
+	fetchStart, fetchEnd := localStart, localEnd
+	for _, h := range habits {
+		if !h.IsPeriodic() {
+			continue
+		}
+		if start := h.PeriodStart(localStart); start.Before(fetchStart) {
+			fetchStart = start
+		}
+		if end := h.NextPeriod(h.PeriodStart(localEnd)).Add(-time.Nanosecond); end.After(fetchEnd) {
+			fetchEnd = end
+		}
+	}
+
+	dbStart := fetchStart.UTC()
+	dbEnd := fetchEnd.UTC()
+
 	entries, err := s.entryRepo.ListByUserIDAndDateRange(ctx, input.UserID, dbStart, dbEnd)
 	if err != nil {
 		return nil, err
@@ -69,6 +93,8 @@ func (s *StatsService) GetWeeklyStats(ctx context.Context, input domain.StatsInp
 			DailyProgress: make([]int, 0),
 		}
 
+		periodDays := periodCompletions(h, entriesMap[h.ID], input.Location)
+
 		daysInPeriod := 0
 		daysAchieved := 0
 
@@ -81,14 +107,19 @@ func (s *StatsService) GetWeeklyStats(ctx context.Context, input domain.StatsInp
 			hStat.TotalValue += val
 			hStat.DailyProgress = append(hStat.DailyProgress, val)
 
-			if val >= h.TargetValue {
-				daysAchieved++
-				totalDaysCompleted++
+			// Only the days in the habit's schedule, and not paused, count
+			// toward its rate.
+			if h.IsScheduledOn(currentDate) && !pauses.Covers(h.ID, currentDate) {
+				periodMet := periodDays != nil && periodDays[h.PeriodStart(currentDate).Format("2006-01-02")] >= h.PeriodTarget()
+				if val >= h.TargetValue || periodMet {
+					daysAchieved++
+					totalDaysCompleted++
+				}
+
+				daysInPeriod++
+				totalDaysPossible++
 			}
 
-			daysInPeriod++
-			totalDaysPossible++
-
 			currentDate = currentDate.AddDate(0, 0, 1)
 		}
 
@@ -106,3 +137,25 @@ func (s *StatsService) GetWeeklyStats(ctx context.Context, input domain.StatsInp
 
 	return stats, nil
 }
+
+// periodCompletions counts, for a weekly or monthly habit, the days reaching
+// the target in each week or month, keyed by the period's first day. A day
+// without an entry still counts as achieved when its period is met.
+func periodCompletions(h *domain.Habit, days map[string]int, loc *time.Location) map[string]int {
+	if !h.IsPeriodic() {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for dateKey, val := range days {
+		if val < h.TargetValue {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", dateKey, loc)
+		if err != nil {
+			continue
+		}
+		counts[h.PeriodStart(day).Format("2006-01-02")]++
+	}
+	return counts
+}
@@ -27,7 +27,7 @@ func TestStatsService_GetWeeklyStats(t *testing.T) {
 		habitRepo := new(MockHabitRepo)
 		entryRepo := new(MockHabitEntryRepo)
 
-		svc := services.NewStatsService(habitRepo, entryRepo)
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
 
 		habits := []*domain.Habit{
 			{ID: "h1", UserID: userID, Title: "Drink Water", TargetValue: 2000, Unit: "ml"},
@@ -76,11 +76,146 @@ func TestStatsService_GetWeeklyStats(t *testing.T) {
 		assert.InDelta(t, 33.33, stats.OverallRate, 0.1)
 	})
 
+	t.Run("Weekly: Three runs meet the whole week", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
+
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "Run", TargetValue: 1, FrequencyType: domain.HabitFreqWeekly, TimesPerPeriod: 3},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		monday := time.Date(2024, 1, 8, 0, 0, 0, 0, utc)
+		sundayEnd := time.Date(2024, 1, 14, 23, 59, 59, 999999999, utc)
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: monday},
+			{ID: "e2", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: monday.AddDate(0, 0, 1)},
+			{ID: "e3", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: endDate.AddDate(0, 0, -1)},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, monday, sundayEnd).Return(entries, nil)
+
+		stats, err := svc.GetWeeklyStats(ctx, domain.StatsInput{
+			UserID:    userID,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Location:  utc,
+		})
+
+		require.NoError(t, err)
+
+		h1 := findHabitStat(stats.HabitStats, "h1")
+		require.NotNil(t, h1)
+		assert.Equal(t, []int{0, 1, 0}, h1.DailyProgress)
+		assert.Equal(t, 3, h1.DaysCompleted)
+		assert.InDelta(t, 100.0, h1.CompletionRate, 0.1)
+		entryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Schedule: Only scheduled days count toward the rate", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
+
+		// 10 and 12 January 2024 are a Wednesday and a Friday.
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "Gym", TargetValue: 1, FrequencyType: domain.HabitFreqRRule, RRule: "FREQ=WEEKLY;BYDAY=WE,FR"},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: startDate},
+			{ID: "e2", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: startDate.AddDate(0, 0, 1)},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return(entries, nil)
+
+		stats, err := svc.GetWeeklyStats(ctx, domain.StatsInput{
+			UserID:    userID,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Location:  utc,
+		})
+
+		require.NoError(t, err)
+
+		h1 := findHabitStat(stats.HabitStats, "h1")
+		require.NotNil(t, h1)
+		assert.Equal(t, []int{1, 1, 0}, h1.DailyProgress)
+		assert.Equal(t, 1, h1.DaysCompleted)
+		assert.InDelta(t, 50.0, h1.CompletionRate, 0.1)
+	})
+
+	t.Run("Dates: Days before the habit started do not count", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
+
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "New", TargetValue: 1, StartDate: endDate.Add(9 * time.Hour)},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: endDate.Add(10 * time.Hour)},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return(entries, nil)
+
+		stats, err := svc.GetWeeklyStats(ctx, domain.StatsInput{
+			UserID:    userID,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Location:  utc,
+		})
+
+		require.NoError(t, err)
+
+		h1 := findHabitStat(stats.HabitStats, "h1")
+		require.NotNil(t, h1)
+		assert.Equal(t, 1, h1.DaysCompleted)
+		assert.InDelta(t, 100.0, h1.CompletionRate, 0.1)
+	})
+
+	t.Run("Pauses: Paused days do not count toward the rate", func(t *testing.T) {
+		habitRepo := new(MockHabitRepo)
+		entryRepo := new(MockHabitEntryRepo)
+
+		svc := services.NewStatsService(habitRepo, entryRepo, NewMockPauseRepo(
+			&domain.Pause{ID: "p1", UserID: userID, StartDate: startDate.AddDate(0, 0, 1)},
+		))
+
+		habits := []*domain.Habit{
+			{ID: "h1", UserID: userID, Title: "Read", TargetValue: 1},
+		}
+		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
+
+		entries := []domain.HabitEntry{
+			{ID: "e1", HabitID: "h1", UserID: userID, Value: 1, CompletionDate: startDate},
+		}
+		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return(entries, nil)
+
+		stats, err := svc.GetWeeklyStats(ctx, domain.StatsInput{
+			UserID:    userID,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Location:  utc,
+		})
+
+		require.NoError(t, err)
+
+		h1 := findHabitStat(stats.HabitStats, "h1")
+		require.NotNil(t, h1)
+		assert.Equal(t, 1, h1.DaysCompleted)
+		assert.InDelta(t, 100.0, h1.CompletionRate, 0.1)
+	})
+
 	t.Run("Timezone: Shifts Late Night UTC entries to Previous Day Local", func(t *testing.T) {
 
 		habitRepo := new(MockHabitRepo)
 		entryRepo := new(MockHabitEntryRepo)
-		svc := services.NewStatsService(habitRepo, entryRepo)
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
 
 		nyLoc := time.FixedZone("America/New_York", -5*60*60)
 
@@ -120,7 +255,7 @@ func TestStatsService_GetWeeklyStats(t *testing.T) {
 	t.Run("Edge Case: No Habits returns zero stats", func(t *testing.T) {
 		habitRepo := new(MockHabitRepo)
 		entryRepo := new(MockHabitEntryRepo)
-		svc := services.NewStatsService(habitRepo, entryRepo)
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
 
 		habitRepo.On("ListByUserID", ctx, userID).Return([]*domain.Habit{}, nil)
 		entryRepo.On("ListByUserIDAndDateRange", ctx, userID, mock.Anything, mock.Anything).Return([]domain.HabitEntry{}, nil)
@@ -137,7 +272,7 @@ func TestStatsService_GetWeeklyStats(t *testing.T) {
 	t.Run("Fail: Habit Repo Error propagates", func(t *testing.T) {
 		habitRepo := new(MockHabitRepo)
 		entryRepo := new(MockHabitEntryRepo)
-		svc := services.NewStatsService(habitRepo, entryRepo)
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
 
 		dbErr := errors.New("db connection lost")
 		habitRepo.On("ListByUserID", ctx, userID).Return(nil, dbErr)
@@ -152,7 +287,7 @@ func TestStatsService_GetWeeklyStats(t *testing.T) {
 	t.Run("Fail: Entry Repo Error propagates", func(t *testing.T) {
 		habitRepo := new(MockHabitRepo)
 		entryRepo := new(MockHabitEntryRepo)
-		svc := services.NewStatsService(habitRepo, entryRepo)
+		svc := services.NewStatsService(habitRepo, entryRepo, nil)
 
 		habits := []*domain.Habit{{ID: "h1"}}
 		habitRepo.On("ListByUserID", ctx, userID).Return(habits, nil)
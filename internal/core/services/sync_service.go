@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type SyncService struct {
+	habitSvc  *HabitService
+	entrySvc  *EntryService
+	deviceSvc *DeviceService
+	tx        domain.Transactor
+}
+
+func NewSyncService(habitSvc *HabitService, entrySvc *EntryService, deviceSvc *DeviceService, tx domain.Transactor) *SyncService {
+	return &SyncService{
+		habitSvc:  habitSvc,
+		entrySvc:  entrySvc,
+		deviceSvc: deviceSvc,
+		tx:        tx,
+	}
+}
+
+type HabitChange struct {
+	Op    string
+	Habit UpdateHabitInput
+}
+
+type EntryChange struct {
+	Op             string
+	ID             string
+	HabitID        string
+	CompletionDate time.Time
+	Value          int
+	Notes          string
+	Version        int
+	HLC            string
+}
+
+type SyncInput struct {
+	UserID string
+	// DeviceID is the registered device syncing, if any. Its cursor is
+	// recorded as acknowledged.
+	DeviceID string
+	Since    int64
+	Limit    int
+	Habits   []HabitChange
+	Entries  []EntryChange
+}
+
+// Sync applies a batch of client changes and returns the server-side deltas
+// since the client cursor, all within one transaction. Each change is
+// isolated, so a conflicting or invalid item does not discard the others.
+// Habits are applied before entries, so entries may reference habits
+// created in the same batch.
+func (s *SyncService) Sync(ctx context.Context, input SyncInput) (*domain.SyncResult, error) {
+	result := &domain.SyncResult{
+		Habits:  make([]domain.HabitSyncResult, 0, len(input.Habits)),
+		Entries: make([]domain.EntrySyncResult, 0, len(input.Entries)),
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, change := range input.Habits {
+			res, err := s.applyHabitChange(ctx, input.UserID, change)
+			if err != nil {
+				return err
+			}
+			result.Habits = append(result.Habits, res)
+		}
+
+		for _, change := range input.Entries {
+			res, err := s.applyEntryChange(ctx, input.UserID, change)
+			if err != nil {
+				return err
+			}
+			result.Entries = append(result.Entries, res)
+		}
+
+		if err := s.acknowledge(ctx, input.DeviceID, input.Since); err != nil {
+			return err
+		}
+
+		return s.collectChanges(ctx, result, input.UserID, input.Since, input.Limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ApplyEntries applies a batch of entry changes in one transaction, each in
+// its own savepoint as in Sync, and reports a status per change. Each habit
+// is checked for ownership once, and each affected habit gets a single
+// streak job once the batch has committed.
+func (s *SyncService) ApplyEntries(ctx context.Context, userID string, changes []EntryChange) ([]domain.EntrySyncResult, error) {
+	results := make([]domain.EntrySyncResult, 0, len(changes))
+
+	ctx, batch := withEntryBatch(ctx)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, change := range changes {
+			res, err := s.applyEntryChange(ctx, userID, change)
+			if err != nil {
+				return err
+			}
+			results = append(results, res)
+		}
+
+		s.tx.AfterCommit(ctx, func() {
+			for _, habitID := range batch.streaks {
+				s.entrySvc.worker.Enqueue(habitID)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Pull returns one page of server-side changes after since without applying
+// anything. As in Sync, since is recorded as the acknowledged cursor of the
+// device, if any, so that clients which only pull still let the tombstone GC
+// make progress.
+func (s *SyncService) Pull(ctx context.Context, userID, deviceID string, since int64, limit int) (*domain.SyncResult, error) {
+	result := &domain.SyncResult{
+		Habits:  []domain.HabitSyncResult{},
+		Entries: []domain.EntrySyncResult{},
+	}
+
+	if err := s.acknowledge(ctx, deviceID, since); err != nil {
+		return nil, err
+	}
+	if err := s.collectChanges(ctx, result, userID, since, limit); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// acknowledge records that the device holds every change up to the cursor
+// it synced from.
+func (s *SyncService) acknowledge(ctx context.Context, deviceID string, cursor int64) error {
+	if deviceID == "" || s.deviceSvc == nil {
+		return nil
+	}
+
+	err := s.deviceSvc.Acknowledge(ctx, deviceID, cursor)
+	if errors.Is(err, domain.ErrDeviceNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *SyncService) collectChanges(ctx context.Context, result *domain.SyncResult, userID string, since int64, limit int) error {
+	habits, moreHabits, err := s.habitSvc.GetDelta(ctx, userID, since, limit)
+	if err != nil {
+		return err
+	}
+
+	entries, moreEntries, err := s.entrySvc.GetDelta(ctx, userID, since, limit)
+	if err != nil {
+		return err
+	}
+
+	if moreHabits || moreEntries {
+		habits, entries = trimSyncPage(habits, moreHabits, entries, moreEntries)
+		result.HasMore = true
+	}
+
+	result.Changes = domain.SyncChanges{Habits: habits, Entries: entries}
+	result.Cursor = nextSyncCursor(habits, entries, since)
+	return nil
+}
+
+func (s *SyncService) applyHabitChange(ctx context.Context, userID string, change HabitChange) (domain.HabitSyncResult, error) {
+	input := change.Habit
+	input.UserID = userID
+
+	var habit *domain.Habit
+	err := s.withinItem(ctx, func(ctx context.Context) error {
+		var err error
+		switch change.Op {
+		case domain.SyncOpCreate:
+			habit, err = s.habitSvc.Create(ctx, createInputFromUpdate(input))
+		case domain.SyncOpUpdate:
+			habit, err = s.habitSvc.Update(ctx, input)
+		case domain.SyncOpDelete:
+			err = s.deleteHabit(ctx, input)
+		default:
+			err = domain.ErrInvalidSyncOp
+		}
+		return err
+	})
+
+	res := domain.HabitSyncResult{ID: input.ID, Habit: habit}
+	if habit != nil {
+		res.ID = habit.ID
+	}
+
+	status, err := syncStatus(err, &res.Error)
+	if err != nil {
+		return res, err
+	}
+	res.Status = status
+
+	if status == domain.SyncStatusConflict {
+		if current, getErr := s.habitSvc.GetByID(ctx, input.ID, userID); getErr == nil {
+			res.Habit = current
+		}
+	}
+
+	return res, nil
+}
+
+func (s *SyncService) deleteHabit(ctx context.Context, input UpdateHabitInput) error {
+	current, err := s.habitSvc.GetByID(ctx, input.ID, input.UserID)
+	if errors.Is(err, domain.ErrHabitNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if input.Version > 0 && current.Version != input.Version {
+		return domain.ErrHabitConflict
+	}
+
+	return s.habitSvc.Delete(ctx, input.ID, input.UserID)
+}
+
+func (s *SyncService) applyEntryChange(ctx context.Context, userID string, change EntryChange) (domain.EntrySyncResult, error) {
+	var entry *domain.HabitEntry
+	err := s.withinItem(ctx, func(ctx context.Context) error {
+		var err error
+		switch change.Op {
+		case domain.SyncOpCreate:
+			entry, err = s.entrySvc.Create(ctx, CreateEntryInput{
+				ID:             change.ID,
+				HabitID:        change.HabitID,
+				UserID:         userID,
+				CompletionDate: change.CompletionDate,
+				Value:          change.Value,
+				Notes:          change.Notes,
+				HLC:            change.HLC,
+			})
+		case domain.SyncOpUpdate:
+			entry, err = s.entrySvc.Update(ctx, UpdateEntryInput{
+				ID:      change.ID,
+				UserID:  userID,
+				Value:   change.Value,
+				Notes:   change.Notes,
+				Version: change.Version,
+				HLC:     change.HLC,
+			})
+		case domain.SyncOpDelete:
+			err = s.deleteEntry(ctx, userID, change)
+		default:
+			err = domain.ErrInvalidSyncOp
+		}
+		return err
+	})
+
+	res := domain.EntrySyncResult{ID: change.ID, Entry: entry}
+	if entry != nil {
+		res.ID = entry.ID
+	}
+
+	status, err := syncStatus(err, &res.Error)
+	if err != nil {
+		return res, err
+	}
+	res.Status = status
+
+	if status == domain.SyncStatusConflict {
+		if current, getErr := s.entrySvc.GetByID(ctx, change.ID, userID); getErr == nil {
+			res.Entry = current
+		}
+	}
+
+	return res, nil
+}
+
+func (s *SyncService) deleteEntry(ctx context.Context, userID string, change EntryChange) error {
+	current, err := s.entrySvc.GetByID(ctx, change.ID, userID)
+	if errors.Is(err, domain.ErrEntryNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if change.Version > 0 && current.Version != change.Version {
+		return domain.ErrEntryConflict
+	}
+
+	return s.entrySvc.Delete(ctx, change.ID, userID)
+}
+
+// withinItem runs a single change in its own savepoint. Side effects queued
+// by a rolled back change are dropped together with its writes.
+func (s *SyncService) withinItem(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.tx.WithinTransaction(ctx, fn)
+}
+
+var syncRejections = []error{
+	domain.ErrInvalidSyncOp,
+	domain.ErrHabitNotFound,
+	domain.ErrEntryNotFound,
+	domain.ErrUnauthorized,
+	domain.ErrInvalidEntry,
+	domain.ErrHabitTitleEmpty,
+	domain.ErrHabitTitleTooLong,
+	domain.ErrHabitDescTooLong,
+	domain.ErrHabitInvalidUserID,
+	domain.ErrInvalidColor,
+	domain.ErrInvalidWeekdays,
+	domain.ErrInvalidTarget,
+	domain.ErrInvalidInterval,
+	domain.ErrHabitArchived,
+	domain.ErrInvalidHabitType,
+	domain.ErrInvalidReminder,
+	domain.ErrInvalidFrequency,
+	domain.ErrInvalidTimesPerPeriod,
+	domain.ErrInvalidRRule,
+	domain.ErrInvalidExDate,
+	domain.ErrInvalidHabitDate,
+	domain.ErrInvalidDateRange,
+	domain.ErrInvalidHLC,
+	domain.ErrHLCTooFarAhead,
+}
+
+// syncStatus classifies the outcome of a single change and stores the reason
+// for a conflict or rejection in msg. Errors that are not the client's fault
+// are returned, so that the whole sync is aborted.
+func syncStatus(err error, msg *string) (string, error) {
+	if err == nil {
+		return domain.SyncStatusApplied, nil
+	}
+
+	if errors.Is(err, domain.ErrHabitConflict) || errors.Is(err, domain.ErrEntryConflict) {
+		*msg = err.Error()
+		return domain.SyncStatusConflict, nil
+	}
+
+	for _, target := range syncRejections {
+		if errors.Is(err, target) {
+			*msg = err.Error()
+			return domain.SyncStatusRejected, nil
+		}
+	}
+
+	log.Printf("[SYNC] Aborting batch, unexpected error: %v", err)
+	return "", err
+}
+
+// syncPageSize falls back to the default page size for a missing limit and
+// caps oversized ones.
+func syncPageSize(limit int) int {
+	if limit <= 0 {
+		return domain.DefaultSyncPageSize
+	}
+	if limit > domain.MaxSyncPageSize {
+		return domain.MaxSyncPageSize
+	}
+	return limit
+}
+
+// trimSyncPage aligns the habit and entry pages on a single cursor. Both
+// tables share the user's change sequence, so the page ends at the lowest
+// sequence reached by a truncated list, and anything past it in the other
+// list is left for the next page.
+func trimSyncPage(habits []*domain.Habit, moreHabits bool, entries []*domain.HabitEntry, moreEntries bool) ([]*domain.Habit, []*domain.HabitEntry) {
+	var cut int64 = -1
+	if moreHabits && len(habits) > 0 {
+		cut = habits[len(habits)-1].ChangeSeq
+	}
+	if moreEntries && len(entries) > 0 {
+		if last := entries[len(entries)-1].ChangeSeq; cut < 0 || last < cut {
+			cut = last
+		}
+	}
+	if cut < 0 {
+		return habits, entries
+	}
+
+	for len(habits) > 0 && habits[len(habits)-1].ChangeSeq > cut {
+		habits = habits[:len(habits)-1]
+	}
+	for len(entries) > 0 && entries[len(entries)-1].ChangeSeq > cut {
+		entries = entries[:len(entries)-1]
+	}
+	return habits, entries
+}
+
+func nextSyncCursor(habits []*domain.Habit, entries []*domain.HabitEntry, fallback int64) int64 {
+	cursor := fallback
+	for _, h := range habits {
+		if h.ChangeSeq > cursor {
+			cursor = h.ChangeSeq
+		}
+	}
+	for _, e := range entries {
+		if e.ChangeSeq > cursor {
+			cursor = e.ChangeSeq
+		}
+	}
+	return cursor
+}
@@ -0,0 +1,383 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakeHooksKey struct{}
+
+type fakeHooks struct {
+	fns []func()
+}
+
+// fakeTransactor counts the transactions it opens and, like the Postgres
+// transactor, holds back after-commit hooks until the outermost transaction
+// in the context succeeds. Transactors share their hooks through the
+// context, so nested services may use separate instances.
+type fakeTransactor struct {
+	calls int
+}
+
+func (f *fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.calls++
+
+	parent, nested := ctx.Value(fakeHooksKey{}).(*fakeHooks)
+	hooks := &fakeHooks{}
+	if err := fn(context.WithValue(ctx, fakeHooksKey{}, hooks)); err != nil {
+		return err
+	}
+
+	if nested {
+		parent.fns = append(parent.fns, hooks.fns...)
+		return nil
+	}
+	for _, hook := range hooks.fns {
+		hook()
+	}
+	return nil
+}
+
+func (f *fakeTransactor) AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(fakeHooksKey{}).(*fakeHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn()
+}
+
+func newTestSyncService(habitRepo domain.HabitRepository, entryRepo *MockHabitEntryRepo) (*services.SyncService, *fakeTransactor) {
+	tx := &fakeTransactor{}
+	habitSvc := services.NewHabitService(habitRepo, nil, services.WriteOptions{Tx: &fakeTransactor{}})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{Tx: &fakeTransactor{}})
+	return services.NewSyncService(habitSvc, entrySvc, nil, tx), tx
+}
+
+func TestSyncService_Sync(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-sync"
+
+	t.Run("Success: Applies habit changes and reports per-item status", func(t *testing.T) {
+		habitRepo := NewMockRepo()
+		entryRepo := new(MockHabitEntryRepo)
+		svc, tx := newTestSyncService(habitRepo, entryRepo)
+
+		existing, _ := domain.NewHabit("habit-existing", "Read", uid)
+		existing.Version = 3
+		require.NoError(t, habitRepo.Create(ctx, existing))
+
+		entryRepo.On("GetChanges", mock.Anything, uid, mock.Anything, mock.Anything).Return([]*domain.HabitEntry{}, nil)
+
+		input := services.SyncInput{
+			UserID: uid,
+			Habits: []services.HabitChange{
+				{Op: domain.SyncOpCreate, Habit: services.UpdateHabitInput{ID: "habit-new", Title: ptr("Run")}},
+				{Op: domain.SyncOpUpdate, Habit: services.UpdateHabitInput{ID: "habit-existing", Title: ptr("Stale"), Version: 1}},
+				{Op: domain.SyncOpCreate, Habit: services.UpdateHabitInput{ID: "habit-invalid", Title: ptr("")}},
+				{Op: "rename", Habit: services.UpdateHabitInput{ID: "habit-existing"}},
+			},
+		}
+
+		result, err := svc.Sync(ctx, input)
+		require.NoError(t, err)
+		require.Len(t, result.Habits, 4)
+
+		assert.Equal(t, domain.SyncStatusApplied, result.Habits[0].Status)
+		assert.Equal(t, "habit-new", result.Habits[0].ID)
+		require.NotNil(t, result.Habits[0].Habit)
+		assert.Equal(t, "Run", result.Habits[0].Habit.Title)
+
+		assert.Equal(t, domain.SyncStatusConflict, result.Habits[1].Status)
+		require.NotNil(t, result.Habits[1].Habit, "Conflicts must carry the server copy")
+		assert.Equal(t, 3, result.Habits[1].Habit.Version)
+		assert.Equal(t, "Read", result.Habits[1].Habit.Title)
+
+		assert.Equal(t, domain.SyncStatusRejected, result.Habits[2].Status)
+		assert.Contains(t, result.Habits[2].Error, "title")
+
+		assert.Equal(t, domain.SyncStatusRejected, result.Habits[3].Status)
+		assert.Equal(t, domain.ErrInvalidSyncOp.Error(), result.Habits[3].Error)
+
+		stored, err := habitRepo.GetByID(ctx, "habit-existing")
+		require.NoError(t, err)
+		assert.Equal(t, "Read", stored.Title, "A conflicting change must not be applied")
+
+		assert.Equal(t, 5, tx.calls, "One outer transaction plus one savepoint per change")
+	})
+
+	t.Run("Success: Returns server deltas and advances the cursor", func(t *testing.T) {
+		habitRepo := NewMockRepo()
+		entryRepo := new(MockHabitEntryRepo)
+		svc, _ := newTestSyncService(habitRepo, entryRepo)
+
+		seen, _ := domain.NewHabit("habit-seen", "Walk", uid)
+		require.NoError(t, habitRepo.Create(ctx, seen))
+		since := seen.ChangeSeq
+
+		remote, _ := domain.NewHabit("habit-remote", "Meditate", uid)
+		require.NoError(t, habitRepo.Create(ctx, remote))
+
+		entryRepo.On("GetChanges", mock.Anything, uid, since, domain.DefaultSyncPageSize+1).Return([]*domain.HabitEntry{
+			{ID: "entry-remote", HabitID: "habit-remote", UserID: uid, ChangeSeq: 7},
+		}, nil)
+
+		result, err := svc.Sync(ctx, services.SyncInput{UserID: uid, Since: since})
+		require.NoError(t, err)
+
+		require.Len(t, result.Changes.Habits, 1)
+		assert.Equal(t, "habit-remote", result.Changes.Habits[0].ID)
+		require.Len(t, result.Changes.Entries, 1)
+		assert.Equal(t, int64(7), result.Cursor, "The cursor is the highest change sequence returned")
+	})
+
+	t.Run("Success: Pages stop at the lowest sequence of a truncated list", func(t *testing.T) {
+		habitRepo := NewMockRepo()
+		entryRepo := new(MockHabitEntryRepo)
+		svc, _ := newTestSyncService(habitRepo, entryRepo)
+
+		for _, id := range []string{"habit-a", "habit-b", "habit-c"} {
+			h, _ := domain.NewHabit(id, "Habit", uid)
+			require.NoError(t, habitRepo.Create(ctx, h))
+		}
+
+		entryRepo.On("GetChanges", mock.Anything, uid, int64(0), 3).Return([]*domain.HabitEntry{
+			{ID: "entry-1", UserID: uid, ChangeSeq: 10},
+		}, nil)
+
+		result, err := svc.Sync(ctx, services.SyncInput{UserID: uid, Limit: 2})
+		require.NoError(t, err)
+
+		assert.True(t, result.HasMore)
+		assert.Len(t, result.Changes.Habits, 2)
+		assert.Empty(t, result.Changes.Entries, "Entries past the habit page must wait for the next page")
+		assert.Equal(t, int64(2), result.Cursor)
+	})
+
+	t.Run("Success: Entry changes go through ownership checks", func(t *testing.T) {
+		habitRepo := NewMockRepo()
+		entryRepo := new(MockHabitEntryRepo)
+		svc, _ := newTestSyncService(habitRepo, entryRepo)
+
+		own, _ := domain.NewHabit("habit-own", "Gym", uid)
+		require.NoError(t, habitRepo.Create(ctx, own))
+		foreign, _ := domain.NewHabit("habit-foreign", "Gym", "someone-else")
+		require.NoError(t, habitRepo.Create(ctx, foreign))
+
+		entryRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+		entryRepo.On("GetByID", mock.Anything, "entry-gone").Return(nil, domain.ErrEntryNotFound)
+		entryRepo.On("GetChanges", mock.Anything, uid, mock.Anything, mock.Anything).Return([]*domain.HabitEntry{}, nil)
+
+		input := services.SyncInput{
+			UserID: uid,
+			Entries: []services.EntryChange{
+				{Op: domain.SyncOpCreate, HabitID: "habit-own", CompletionDate: time.Now(), Value: 1},
+				{Op: domain.SyncOpCreate, HabitID: "habit-foreign", CompletionDate: time.Now(), Value: 1},
+				{Op: domain.SyncOpDelete, ID: "entry-gone"},
+			},
+		}
+
+		result, err := svc.Sync(ctx, input)
+		require.NoError(t, err)
+		require.Len(t, result.Entries, 3)
+
+		assert.Equal(t, domain.SyncStatusApplied, result.Entries[0].Status)
+		assert.Equal(t, domain.SyncStatusRejected, result.Entries[1].Status)
+		assert.Equal(t, domain.SyncStatusApplied, result.Entries[2].Status, "Deleting an already deleted entry is idempotent")
+
+		entryRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+
+	t.Run("Fail: Unexpected errors abort the whole batch", func(t *testing.T) {
+		habitRepo := NewMockRepo()
+		habitRepo.simulateError = errors.New("connection reset")
+		entryRepo := new(MockHabitEntryRepo)
+		svc, _ := newTestSyncService(habitRepo, entryRepo)
+
+		input := services.SyncInput{
+			UserID: uid,
+			Habits: []services.HabitChange{
+				{Op: domain.SyncOpUpdate, Habit: services.UpdateHabitInput{ID: "habit-1", Title: ptr("Run"), Version: 1}},
+			},
+		}
+
+		result, err := svc.Sync(ctx, input)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		entryRepo.AssertNotCalled(t, "GetChanges")
+	})
+}
+
+func TestSyncService_ApplyEntries(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-batch"
+	now := time.Now().UTC()
+
+	habitRepo := new(MockHabitRepo)
+	entryRepo := new(MockHabitEntryRepo)
+	worker := getTestWorker()
+	tx := &fakeTransactor{}
+
+	habitSvc := services.NewHabitService(habitRepo, nil, services.WriteOptions{Tx: &fakeTransactor{}})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, worker, nil, services.WriteOptions{Tx: &fakeTransactor{}})
+	svc := services.NewSyncService(habitSvc, entrySvc, nil, tx)
+
+	habitRepo.On("GetByID", mock.Anything, "habit-a").Return(&domain.Habit{ID: "habit-a", UserID: uid}, nil)
+	habitRepo.On("GetByID", mock.Anything, "habit-b").Return(&domain.Habit{ID: "habit-b", UserID: uid}, nil)
+	habitRepo.On("GetByID", mock.Anything, "habit-foreign").Return(&domain.Habit{ID: "habit-foreign", UserID: "someone-else"}, nil)
+
+	entryRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	entryRepo.On("GetByID", mock.Anything, "entry-old").Return(&domain.HabitEntry{ID: "entry-old", HabitID: "habit-b", UserID: uid, Version: 1}, nil)
+	entryRepo.On("Delete", mock.Anything, "entry-old", uid).Return(nil)
+
+	results, err := svc.ApplyEntries(ctx, uid, []services.EntryChange{
+		{Op: domain.SyncOpCreate, HabitID: "habit-a", CompletionDate: now, Value: 1},
+		{Op: domain.SyncOpCreate, HabitID: "habit-a", CompletionDate: now.AddDate(0, 0, -1), Value: 1},
+		{Op: domain.SyncOpCreate, HabitID: "habit-a", CompletionDate: now.AddDate(0, 0, -2), Value: 1},
+		{Op: domain.SyncOpCreate, HabitID: "habit-foreign", CompletionDate: now, Value: 1},
+		{Op: domain.SyncOpDelete, ID: "entry-old", Version: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	for _, i := range []int{0, 1, 2, 4} {
+		assert.Equal(t, domain.SyncStatusApplied, results[i].Status, "change %d", i)
+	}
+	assert.Equal(t, domain.SyncStatusRejected, results[3].Status)
+
+	habitRepo.AssertNumberOfCalls(t, "GetByID", 2)
+	entryRepo.AssertNumberOfCalls(t, "Create", 3)
+	assert.Equal(t, 2, worker.Pending(), "One streak job per affected habit")
+	assert.Equal(t, 6, tx.calls, "One outer transaction plus one savepoint per change")
+}
+
+type recordingNotifier struct {
+	published []string
+}
+
+func (n *recordingNotifier) Publish(ctx context.Context, userID string) error {
+	n.published = append(n.published, userID)
+	return nil
+}
+
+func (n *recordingNotifier) Subscribe(ctx context.Context, userID string) (<-chan struct{}, error) {
+	return nil, errors.New("not supported")
+}
+
+// observingTransactor records how many notifications had been published when
+// the outermost transaction finished, before its after-commit hooks ran.
+type observingTransactor struct {
+	fakeTransactor
+	depth     int
+	notifier  *recordingNotifier
+	publishes int
+}
+
+func (o *observingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	o.depth++
+	defer func() { o.depth-- }()
+
+	return o.fakeTransactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := fn(ctx)
+		if o.depth == 1 {
+			o.publishes = len(o.notifier.published)
+		}
+		return err
+	})
+}
+
+func TestSyncService_Notifications(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-notify"
+
+	habitRepo := NewMockRepo()
+	entryRepo := new(MockHabitEntryRepo)
+	entryRepo.On("GetChanges", mock.Anything, uid, mock.Anything, mock.Anything).Return([]*domain.HabitEntry{}, nil)
+
+	notifier := &recordingNotifier{}
+	tx := &observingTransactor{notifier: notifier}
+	habitSvc := services.NewHabitService(habitRepo, notifier, services.WriteOptions{Tx: &fakeTransactor{}})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), notifier, services.WriteOptions{Tx: &fakeTransactor{}})
+	svc := services.NewSyncService(habitSvc, entrySvc, nil, tx)
+
+	result, err := svc.Sync(ctx, services.SyncInput{
+		UserID: uid,
+		Habits: []services.HabitChange{
+			{Op: domain.SyncOpCreate, Habit: services.UpdateHabitInput{ID: "habit-1", Title: ptr("Run")}},
+			{Op: domain.SyncOpCreate, Habit: services.UpdateHabitInput{ID: "habit-2", Title: ptr("")}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, domain.SyncStatusApplied, result.Habits[0].Status)
+
+	assert.Zero(t, tx.publishes, "Nothing is published before the transaction commits")
+	assert.Equal(t, []string{uid}, notifier.published, "Only applied changes are published")
+}
+
+func TestSyncService_Pull(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-pull"
+
+	habitRepo := NewMockRepo()
+	entryRepo := new(MockHabitEntryRepo)
+	svc, tx := newTestSyncService(habitRepo, entryRepo)
+
+	h, _ := domain.NewHabit("habit-1", "Read", uid)
+	require.NoError(t, habitRepo.Create(ctx, h))
+
+	entryRepo.On("GetChanges", mock.Anything, uid, int64(0), domain.DefaultSyncPageSize+1).Return([]*domain.HabitEntry{
+		{ID: "entry-1", HabitID: "habit-1", UserID: uid, ChangeSeq: 5},
+	}, nil)
+
+	result, err := svc.Pull(ctx, uid, "", 0, 0)
+	require.NoError(t, err)
+
+	assert.Len(t, result.Changes.Habits, 1)
+	assert.Len(t, result.Changes.Entries, 1)
+	assert.Equal(t, int64(5), result.Cursor)
+	assert.False(t, result.HasMore)
+	assert.Zero(t, tx.calls, "Pulling changes does not open a transaction")
+}
+
+func TestSyncService_AcknowledgesDeviceCursor(t *testing.T) {
+	ctx := context.Background()
+	uid := "user-ack"
+
+	habitRepo := NewMockRepo()
+	entryRepo := new(MockHabitEntryRepo)
+	entryRepo.On("GetChanges", mock.Anything, uid, mock.Anything, mock.Anything).Return([]*domain.HabitEntry{}, nil)
+
+	deviceRepo := services.NewMockDeviceRepo()
+	devices := services.NewDeviceService(deviceRepo)
+	device, err := devices.Register(ctx, services.RegisterDeviceInput{ID: "device-1", UserID: uid})
+	require.NoError(t, err)
+
+	habitSvc := services.NewHabitService(habitRepo, nil, services.WriteOptions{})
+	entrySvc := services.NewEntryService(entryRepo, habitRepo, getTestWorker(), nil, services.WriteOptions{})
+	svc := services.NewSyncService(habitSvc, entrySvc, devices, &fakeTransactor{})
+
+	_, err = svc.Sync(ctx, services.SyncInput{UserID: uid, DeviceID: device.ID, Since: 12})
+	require.NoError(t, err)
+
+	stored, err := devices.GetByID(ctx, device.ID, uid)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stored.LastCursor, "The cursor sent by the device is what it has already applied")
+
+	_, err = svc.Sync(ctx, services.SyncInput{UserID: uid, DeviceID: "unknown-device", Since: 3})
+	assert.NoError(t, err, "Tokens issued before device registration keep syncing")
+
+	_, err = svc.Pull(ctx, uid, device.ID, 20, 0)
+	require.NoError(t, err)
+
+	stored, err = devices.GetByID(ctx, device.ID, uid)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), stored.LastCursor, "Pulling, as the change stream does, acknowledges the cursor too")
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,24 +15,42 @@ type TokenService struct {
 	issuer        string
 	tokenDuration time.Duration
 	userRepo      domain.UserRepository
+	deviceRepo    domain.DeviceRepository
 }
 
-func NewTokenService(secretKey string, issuer string, tokenDuration time.Duration, userRepo domain.UserRepository) *TokenService {
+func NewTokenService(secretKey string, issuer string, tokenDuration time.Duration, userRepo domain.UserRepository, deviceRepo domain.DeviceRepository) *TokenService {
 	return &TokenService{
 		secretKey:     []byte(secretKey),
 		issuer:        issuer,
 		tokenDuration: tokenDuration,
 		userRepo:      userRepo,
+		deviceRepo:    deviceRepo,
 	}
 }
 
+// Session is the identity carried by a valid token. DeviceID is empty for
+// tokens not bound to a registered device.
+type Session struct {
+	UserID   string
+	DeviceID string
+}
+
 func (s *TokenService) GenerateToken(userID string) (string, error) {
+	return s.GenerateDeviceToken(userID, "")
+}
+
+// GenerateDeviceToken issues a token bound to a device, which stops being
+// accepted as soon as the device is revoked.
+func (s *TokenService) GenerateDeviceToken(userID string, deviceID string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub": userID,
 		"exp": time.Now().Add(s.tokenDuration).Unix(),
 		"iat": time.Now().Unix(),
 		"iss": s.issuer,
 	}
+	if deviceID != "" {
+		claims["did"] = deviceID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -44,6 +63,14 @@ func (s *TokenService) GenerateToken(userID string) (string, error) {
 }
 
 func (s *TokenService) ValidateToken(tokenString string) (string, error) {
+	session, err := s.ValidateSession(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return session.UserID, nil
+}
+
+func (s *TokenService) ValidateSession(tokenString string) (*Session, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -52,17 +79,17 @@ func (s *TokenService) ValidateToken(tokenString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("invalid token: %w", err)
+		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		if iss, ok := claims["iss"].(string); !ok || iss != s.issuer {
-			return "", fmt.Errorf("invalid token issuer")
+			return nil, fmt.Errorf("invalid token issuer")
 		}
 
 		userID, ok := claims["sub"].(string)
 		if !ok {
-			return "", fmt.Errorf("invalid token subject")
+			return nil, fmt.Errorf("invalid token subject")
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -70,11 +97,26 @@ func (s *TokenService) ValidateToken(tokenString string) (string, error) {
 
 		_, err := s.userRepo.GetByID(ctx, userID)
 		if err != nil {
-			return "", fmt.Errorf("user no longer exists or db error: %w", err)
+			return nil, fmt.Errorf("user no longer exists or db error: %w", err)
+		}
+
+		session := &Session{UserID: userID}
+		session.DeviceID, _ = claims["did"].(string)
+		if session.DeviceID != "" && s.deviceRepo != nil {
+			device, err := s.deviceRepo.GetByID(ctx, session.DeviceID)
+			if errors.Is(err, domain.ErrDeviceNotFound) {
+				return nil, domain.ErrDeviceRevoked
+			}
+			if err != nil {
+				return nil, fmt.Errorf("device lookup failed: %w", err)
+			}
+			if device.UserID != userID || device.IsRevoked() {
+				return nil, domain.ErrDeviceRevoked
+			}
 		}
 
-		return userID, nil
+		return session, nil
 	}
 
-	return "", fmt.Errorf("invalid token claims")
+	return nil, fmt.Errorf("invalid token claims")
 }
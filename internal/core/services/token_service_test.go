@@ -44,7 +44,7 @@ func TestTokenService_GenerateAndValidate(t *testing.T) {
 
 	setup := func() (*TokenService, *MockUserRepoForToken) {
 		mockRepo := new(MockUserRepoForToken)
-		return NewTokenService(secret, issuer, 1*time.Hour, mockRepo), mockRepo
+		return NewTokenService(secret, issuer, 1*time.Hour, mockRepo, nil), mockRepo
 	}
 
 	t.Run("Success: Should generate and validate a token", func(t *testing.T) {
@@ -81,7 +81,7 @@ func TestTokenService_GenerateAndValidate(t *testing.T) {
 
 	t.Run("Fail: Should reject expired token", func(t *testing.T) {
 		mockRepo := new(MockUserRepoForToken)
-		service := NewTokenService(secret, issuer, -1*time.Second, mockRepo)
+		service := NewTokenService(secret, issuer, -1*time.Second, mockRepo, nil)
 
 		tokenString, err := service.GenerateToken(userID)
 		assert.NoError(t, err)
@@ -97,7 +97,7 @@ func TestTokenService_GenerateAndValidate(t *testing.T) {
 		tokenString, _ := service.GenerateToken(userID)
 
 		mockRepoAttacker := new(MockUserRepoForToken)
-		attackerService := NewTokenService("wrong-key", issuer, 1*time.Hour, mockRepoAttacker)
+		attackerService := NewTokenService("wrong-key", issuer, 1*time.Hour, mockRepoAttacker, nil)
 
 		extractedID, err := attackerService.ValidateToken(tokenString)
 		assert.Error(t, err)
@@ -107,10 +107,10 @@ func TestTokenService_GenerateAndValidate(t *testing.T) {
 
 	t.Run("Fail: Should reject token with wrong issuer", func(t *testing.T) {
 		mockRepo := new(MockUserRepoForToken)
-		serviceA := NewTokenService(secret, "correct-issuer", 1*time.Hour, mockRepo)
+		serviceA := NewTokenService(secret, "correct-issuer", 1*time.Hour, mockRepo, nil)
 		tokenString, _ := serviceA.GenerateToken(userID)
 
-		serviceB := NewTokenService(secret, "wrong-issuer", 1*time.Hour, mockRepo)
+		serviceB := NewTokenService(secret, "wrong-issuer", 1*time.Hour, mockRepo, nil)
 
 		extractedID, err := serviceB.ValidateToken(tokenString)
 		assert.Error(t, err)
@@ -143,3 +143,42 @@ func TestTokenService_GenerateAndValidate(t *testing.T) {
 		assert.Empty(t, extractedID)
 	})
 }
+
+func TestTokenService_DeviceTokens(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-123-uuid"
+
+	setup := func() (*TokenService, *DeviceService) {
+		userRepo := new(MockUserRepoForToken)
+		userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID}, nil)
+
+		deviceRepo := NewMockDeviceRepo()
+		return NewTokenService("secret", "kanso-test", time.Hour, userRepo, deviceRepo), NewDeviceService(deviceRepo)
+	}
+
+	t.Run("Success: Device-bound tokens carry the device", func(t *testing.T) {
+		service, devices := setup()
+		device, err := devices.Register(ctx, RegisterDeviceInput{UserID: userID, Name: "Phone"})
+		assert.NoError(t, err)
+
+		token, err := service.GenerateDeviceToken(userID, device.ID)
+		assert.NoError(t, err)
+
+		session, err := service.ValidateSession(token)
+		assert.NoError(t, err)
+		assert.Equal(t, userID, session.UserID)
+		assert.Equal(t, device.ID, session.DeviceID)
+	})
+
+	t.Run("Fail: Tokens of a revoked device are rejected", func(t *testing.T) {
+		service, devices := setup()
+		device, err := devices.Register(ctx, RegisterDeviceInput{UserID: userID})
+		assert.NoError(t, err)
+
+		token, _ := service.GenerateDeviceToken(userID, device.ID)
+		assert.NoError(t, devices.Revoke(ctx, device.ID, userID))
+
+		_, err = service.ValidateSession(token)
+		assert.ErrorIs(t, err, domain.ErrDeviceRevoked)
+	})
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+// noTransaction runs writes as they are, for services built without a
+// Transactor: there is nothing to roll back, so side effects run at once.
+type noTransaction struct{}
+
+func (noTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noTransaction) AfterCommit(ctx context.Context, fn func()) {
+	fn()
+}
+
+func transactorOrNone(tx domain.Transactor) domain.Transactor {
+	if tx == nil {
+		return noTransaction{}
+	}
+	return tx
+}
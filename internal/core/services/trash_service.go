@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/workers"
+)
+
+type TrashService struct {
+	repo      domain.HabitTrashRepository
+	tx        domain.Transactor
+	worker    *workers.StreakWorker
+	notifier  domain.ChangeNotifier
+	retention time.Duration
+	ops       operationLog
+}
+
+// NewTrashService lets users get back habits deleted within retention, the
+// same window the tombstone GC keeps them for, whatever the devices of the
+// user have already acknowledged. Restores are recorded in ops when it is
+// not nil.
+func NewTrashService(repo domain.HabitTrashRepository, tx domain.Transactor, ops domain.OperationRepository, worker *workers.StreakWorker, notifier domain.ChangeNotifier, retention time.Duration) *TrashService {
+	return &TrashService{
+		repo:      repo,
+		worker:    worker,
+		notifier:  notifier,
+		retention: retention,
+		ops:       operationLog{repo: ops},
+		tx:        tx,
+	}
+}
+
+func (s *TrashService) deletedSince() time.Time {
+	return time.Now().UTC().Add(-s.retention)
+}
+
+// List returns the user's deleted habits that can still be restored.
+func (s *TrashService) List(ctx context.Context, userID string) ([]*domain.Habit, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+	return s.repo.ListDeleted(ctx, userID, s.deletedSince())
+}
+
+// Restore undeletes a habit and the entries deleted with it, then has its
+// streaks recomputed.
+func (s *TrashService) Restore(ctx context.Context, id, userID string) (*domain.Habit, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	var habit *domain.Habit
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		habit, err = s.repo.Restore(ctx, id, userID, s.deletedSince())
+		if err != nil {
+			return err
+		}
+		if err := s.ops.record(ctx, habitOperation(habit, domain.OperationRestore), nil, habit); err != nil {
+			return err
+		}
+
+		s.tx.AfterCommit(ctx, func() {
+			s.worker.Enqueue(habit.ID)
+		})
+		notifyChange(ctx, s.tx, s.notifier, userID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return habit, nil
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/services"
+)
+
+type fakeTrashRepo struct {
+	deleted      map[string]*domain.Habit
+	deletedSince time.Time
+}
+
+func (r *fakeTrashRepo) ListDeleted(ctx context.Context, userID string, deletedSince time.Time) ([]*domain.Habit, error) {
+	r.deletedSince = deletedSince
+	var result []*domain.Habit
+	for _, h := range r.deleted {
+		if h.UserID == userID && !h.DeletedAt.Before(deletedSince) {
+			result = append(result, h)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeTrashRepo) Restore(ctx context.Context, id, userID string, deletedSince time.Time) (*domain.Habit, error) {
+	h, ok := r.deleted[id]
+	if !ok || h.UserID != userID || h.DeletedAt.Before(deletedSince) {
+		return nil, domain.ErrHabitNotFound
+	}
+	delete(r.deleted, id)
+	h.DeletedAt = nil
+	h.Version++
+	return h, nil
+}
+
+func TestTrashService(t *testing.T) {
+	ctx := context.Background()
+	retention := 30 * 24 * time.Hour
+
+	setup := func() (*services.TrashService, *fakeTrashRepo, *memoryOperationRepo) {
+		recent := time.Now().UTC().Add(-time.Hour)
+		expired := time.Now().UTC().Add(-retention - time.Hour)
+		repo := &fakeTrashRepo{deleted: map[string]*domain.Habit{
+			"habit-recent":  {ID: "habit-recent", UserID: "user-1", Title: "Read", Version: 2, DeletedAt: &recent},
+			"habit-expired": {ID: "habit-expired", UserID: "user-1", Title: "Run", Version: 2, DeletedAt: &expired},
+		}}
+		ops := &memoryOperationRepo{}
+		svc := services.NewTrashService(repo, &fakeTransactor{}, ops, getTestWorker(), nil, retention)
+		return svc, repo, ops
+	}
+
+	t.Run("List: Only habits within the retention window", func(t *testing.T) {
+		svc, repo, _ := setup()
+
+		habits, err := svc.List(ctx, "user-1")
+
+		require.NoError(t, err)
+		require.Len(t, habits, 1)
+		assert.Equal(t, "habit-recent", habits[0].ID)
+		assert.WithinDuration(t, time.Now().Add(-retention), repo.deletedSince, time.Minute)
+	})
+
+	t.Run("Restore: Undeletes with a version bump and logs it", func(t *testing.T) {
+		svc, _, ops := setup()
+
+		habit, err := svc.Restore(ctx, "habit-recent", "user-1")
+
+		require.NoError(t, err)
+		assert.Nil(t, habit.DeletedAt)
+		assert.Equal(t, 3, habit.Version)
+		require.Len(t, ops.ops, 1)
+		assert.Equal(t, domain.OperationRestore, ops.ops[0].Op)
+	})
+
+	t.Run("Restore: Fails outside the retention window or for other users", func(t *testing.T) {
+		svc, _, ops := setup()
+
+		_, err := svc.Restore(ctx, "habit-expired", "user-1")
+		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
+
+		_, err = svc.Restore(ctx, "habit-recent", "user-2")
+		assert.ErrorIs(t, err, domain.ErrHabitNotFound)
+
+		assert.Empty(t, ops.ops)
+	})
+}
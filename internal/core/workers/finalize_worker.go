@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+const finalizeBatchSize = 500
+
+// finalizeGrace is how long after its end date a habit is archived: the
+// end date is a calendar day of the user, stored as midnight UTC, and that
+// day is over everywhere, down to UTC-12, a day and a half later.
+const finalizeGrace = 36 * time.Hour
+
+type HabitFinalizer interface {
+	Finalize(ctx context.Context, habit *domain.Habit) error
+}
+
+// FinalizeWorker periodically archives the habits that have run past their
+// end date, so they stop showing up as due and their streaks are frozen.
+type FinalizeWorker struct {
+	repo      domain.EndedHabitRepository
+	finalizer HabitFinalizer
+	interval  time.Duration
+	now       func() time.Time
+	wg        sync.WaitGroup
+}
+
+func NewFinalizeWorker(repo domain.EndedHabitRepository, finalizer HabitFinalizer, interval time.Duration) *FinalizeWorker {
+	return &FinalizeWorker{
+		repo:      repo,
+		finalizer: finalizer,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+func (w *FinalizeWorker) Start(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		log.Printf("Finalize Worker started (every %s)...", w.interval)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.finalize(ctx)
+			case <-ctx.Done():
+				log.Println("Finalize Worker stopping...")
+				return
+			}
+		}
+	}()
+}
+
+func (w *FinalizeWorker) Stop() {
+	w.wg.Wait()
+	log.Println("Finalize Worker stopped gracefully.")
+}
+
+// finalize archives ended habits in batches. Habits changed while a batch
+// was being processed are skipped and picked up again by the next run.
+func (w *FinalizeWorker) finalize(ctx context.Context) {
+	cutoff := w.now().UTC().Add(-finalizeGrace)
+
+	total := 0
+	for ctx.Err() == nil {
+		habits, err := w.repo.ListEnded(ctx, cutoff, finalizeBatchSize)
+		if err != nil {
+			log.Printf("Finalize Worker failed to list ended habits: %v", err)
+			return
+		}
+
+		archived := 0
+		for _, habit := range habits {
+			if err := w.finalizer.Finalize(ctx, habit); err != nil {
+				if !errors.Is(err, domain.ErrHabitConflict) {
+					log.Printf("Finalize Worker failed to archive habit %s: %v", habit.ID, err)
+				}
+				continue
+			}
+			archived++
+		}
+		total += archived
+
+		if archived == 0 || len(habits) < finalizeBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Finalize Worker archived %d habits that ended before %s", total, cutoff.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,107 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/comitanigiacomo/kanso-sync-engine/internal/core/domain"
+)
+
+type fakeEndedRepo struct {
+	batches [][]*domain.Habit
+	err     error
+	cutoffs []time.Time
+}
+
+func (f *fakeEndedRepo) ListEnded(ctx context.Context, endedBefore time.Time, limit int) ([]*domain.Habit, error) {
+	f.cutoffs = append(f.cutoffs, endedBefore)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+type fakeFinalizer struct {
+	finalized []string
+	conflicts map[string]bool
+}
+
+func (f *fakeFinalizer) Finalize(ctx context.Context, habit *domain.Habit) error {
+	if f.conflicts[habit.ID] {
+		return domain.ErrHabitConflict
+	}
+	f.finalized = append(f.finalized, habit.ID)
+	return nil
+}
+
+func endedHabits(prefix string, n int) []*domain.Habit {
+	habits := make([]*domain.Habit, n)
+	for i := range habits {
+		habits[i] = &domain.Habit{ID: fmt.Sprintf("%s%d", prefix, i)}
+	}
+	return habits
+}
+
+func TestFinalizeWorker_Finalize(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Archives habits a day past their end date, in batches", func(t *testing.T) {
+		repo := &fakeEndedRepo{batches: [][]*domain.Habit{endedHabits("full-", finalizeBatchSize), endedHabits("last-", 2)}}
+		finalizer := &fakeFinalizer{}
+		w := NewFinalizeWorker(repo, finalizer, time.Hour)
+		w.now = func() time.Time { return now }
+
+		w.finalize(context.Background())
+
+		assert.Len(t, repo.cutoffs, 2)
+		for _, cutoff := range repo.cutoffs {
+			assert.Equal(t, now.Add(-36*time.Hour), cutoff)
+		}
+		assert.Len(t, finalizer.finalized, finalizeBatchSize+2)
+	})
+
+	t.Run("Skips habits changed concurrently", func(t *testing.T) {
+		repo := &fakeEndedRepo{batches: [][]*domain.Habit{{{ID: "busy"}, {ID: "idle"}}}}
+		finalizer := &fakeFinalizer{conflicts: map[string]bool{"busy": true}}
+		w := NewFinalizeWorker(repo, finalizer, time.Hour)
+
+		w.finalize(context.Background())
+
+		assert.Equal(t, []string{"idle"}, finalizer.finalized)
+	})
+
+	t.Run("Stops when a full batch makes no progress", func(t *testing.T) {
+		stuck := endedHabits("stuck-", finalizeBatchSize)
+		conflicts := make(map[string]bool)
+		for _, h := range stuck {
+			conflicts[h.ID] = true
+		}
+		repo := &fakeEndedRepo{batches: [][]*domain.Habit{stuck, stuck}}
+		w := NewFinalizeWorker(repo, &fakeFinalizer{conflicts: conflicts}, time.Hour)
+
+		w.finalize(context.Background())
+
+		assert.Len(t, repo.cutoffs, 1)
+	})
+
+	t.Run("Stops on repository errors", func(t *testing.T) {
+		repo := &fakeEndedRepo{err: errors.New("db down")}
+		finalizer := &fakeFinalizer{}
+		w := NewFinalizeWorker(repo, finalizer, time.Hour)
+
+		w.finalize(context.Background())
+
+		assert.Len(t, repo.cutoffs, 1)
+		assert.Empty(t, finalizer.finalized)
+	})
+}
@@ -2,6 +2,7 @@ package workers
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"sort"
 	"sync"
@@ -20,6 +21,10 @@ type EntryRepository interface {
 	ListByHabitID(ctx context.Context, habitID string) ([]*domain.HabitEntry, error)
 }
 
+type PauseRepository interface {
+	ListByUserID(ctx context.Context, userID string) ([]*domain.Pause, error)
+}
+
 type StreakJob struct {
 	HabitID string
 }
@@ -27,14 +32,31 @@ type StreakJob struct {
 type StreakWorker struct {
 	habitRepo HabitRepository
 	entryRepo EntryRepository
+	opsRepo   domain.OperationRepository
+	tx        domain.Transactor
+	pauseRepo PauseRepository
 	jobs      chan StreakJob
 	wg        sync.WaitGroup
 }
 
-func NewStreakWorker(hRepo HabitRepository, eRepo EntryRepository) *StreakWorker {
+// StreakOptions holds the optional dependencies of a StreakWorker. The zero
+// value ignores pauses and records no operations.
+type StreakOptions struct {
+	// Pauses makes paused days neither break nor extend a streak.
+	Pauses PauseRepository
+	// Operations records every streak change within the same transaction
+	// as the change itself. It requires Tx.
+	Operations domain.OperationRepository
+	Tx         domain.Transactor
+}
+
+func NewStreakWorker(hRepo HabitRepository, eRepo EntryRepository, opts StreakOptions) *StreakWorker {
 	return &StreakWorker{
 		habitRepo: hRepo,
 		entryRepo: eRepo,
+		opsRepo:   opts.Operations,
+		tx:        opts.Tx,
+		pauseRepo: opts.Pauses,
 		jobs:      make(chan StreakJob, 100),
 	}
 }
@@ -61,6 +83,11 @@ func (w *StreakWorker) Stop() {
 	log.Println("Streak Worker stopped gracefully.")
 }
 
+// Pending reports how many jobs are queued and not yet picked up.
+func (w *StreakWorker) Pending() int {
+	return len(w.jobs)
+}
+
 func (w *StreakWorker) Enqueue(habitID string) {
 	select {
 	case w.jobs <- StreakJob{HabitID: habitID}:
@@ -82,10 +109,28 @@ func (w *StreakWorker) processJob(ctx context.Context, job StreakJob) {
 		return
 	}
 
-	current, longest := calculateStreaks(entries)
+	var pauses domain.Pauses
+	if w.pauseRepo != nil {
+		all, err := w.pauseRepo.ListByUserID(ctx, habit.UserID)
+		if err != nil {
+			log.Printf("Worker Error fetching pauses for %s: %v", job.HabitID, err)
+			return
+		}
+		pauses = domain.Pauses(all).ForHabit(habit.ID)
+	}
+
+	var current, longest int
+	switch {
+	case habit.IsPeriodic():
+		current, longest = calculatePeriodStreaks(habit, entries, time.Now().UTC(), pauses)
+	case hasSchedule(habit) || len(pauses) > 0:
+		current, longest = calculateScheduledStreaks(habit, entries, time.Now().UTC(), pauses)
+	default:
+		current, longest = calculateStreaks(entries)
+	}
 
 	if habit.CurrentStreak != current || habit.LongestStreak != longest {
-		if err := w.habitRepo.UpdateStreaks(ctx, job.HabitID, current, longest); err != nil {
+		if err := w.saveStreaks(ctx, habit, current, longest); err != nil {
 			log.Printf("Worker Failed to update streak for %s: %v", job.HabitID, err)
 		} else {
 			log.Printf("Streak updated for %s: Current=%d, Longest=%d", habit.Title, current, longest)
@@ -93,6 +138,35 @@ func (w *StreakWorker) processJob(ctx context.Context, job StreakJob) {
 	}
 }
 
+type streakValue struct {
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+}
+
+func (w *StreakWorker) saveStreaks(ctx context.Context, habit *domain.Habit, current, longest int) error {
+	if w.opsRepo == nil {
+		return w.habitRepo.UpdateStreaks(ctx, habit.ID, current, longest)
+	}
+
+	oldValue, _ := json.Marshal(streakValue{CurrentStreak: habit.CurrentStreak, LongestStreak: habit.LongestStreak})
+	newValue, _ := json.Marshal(streakValue{CurrentStreak: current, LongestStreak: longest})
+
+	return w.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := w.habitRepo.UpdateStreaks(ctx, habit.ID, current, longest); err != nil {
+			return err
+		}
+		return w.opsRepo.Append(ctx, &domain.Operation{
+			UserID:     habit.UserID,
+			EntityType: domain.OperationEntityHabit,
+			EntityID:   habit.ID,
+			HabitID:    habit.ID,
+			Op:         domain.OperationStreak,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+		})
+	})
+}
+
 func calculateStreaks(entries []*domain.HabitEntry) (int, int) {
 	if len(entries) == 0 {
 		return 0, 0
@@ -160,3 +234,136 @@ func calculateStreaks(entries []*domain.HabitEntry) (int, int) {
 
 	return currentStreak, longestStreak
 }
+
+// hasSchedule reports whether the habit skips some days, so that its streaks
+// run over scheduled days rather than consecutive ones.
+func hasSchedule(habit *domain.Habit) bool {
+	switch {
+	case habit.RRule != "", len(habit.ExDates) > 0:
+		return true
+	case habit.FrequencyType == domain.HabitFreqSpecificDays:
+		return len(habit.Weekdays) > 0
+	case habit.FrequencyType == domain.HabitFreqInterval:
+		return habit.Interval > 1
+	}
+	return false
+}
+
+// calculateScheduledStreaks counts the streaks of a habit in the days of its
+// schedule: days off and paused days neither break nor extend a streak, and
+// today does not break it while it is still open. Days are UTC days, which
+// habit and pause dates, stored as midnight UTC, name directly.
+func calculateScheduledStreaks(habit *domain.Habit, entries []*domain.HabitEntry, now time.Time, pauses domain.Pauses) (int, int) {
+	done := make(map[string]bool)
+	var first time.Time
+	for _, e := range entries {
+		completed := e.CompletionDate.UTC()
+		done[completed.Format("2006-01-02")] = true
+		if first.IsZero() || completed.Before(first) {
+			first = completed
+		}
+	}
+	if len(done) == 0 {
+		return 0, 0
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	longestStreak, run := 0, 0
+	for day := first.Truncate(24 * time.Hour); !day.After(today); day = day.AddDate(0, 0, 1) {
+		if !habit.IsScheduledOn(day) || pauses.Covers(habit.ID, day) {
+			continue
+		}
+		if done[day.Format("2006-01-02")] {
+			run++
+			if run > longestStreak {
+				longestStreak = run
+			}
+		} else if !day.Equal(today) {
+			run = 0
+		}
+	}
+
+	return run, longestStreak
+}
+
+// calculatePeriodStreaks counts the streaks of a weekly or monthly habit in
+// periods: a week or month is met once it has completions on as many days as
+// the habit asks for, whichever days they are. The current period extends the
+// streak once it is met, but does not break it while it is still open, and
+// periods that overlap a pause do not break it either.
+func calculatePeriodStreaks(habit *domain.Habit, entries []*domain.HabitEntry, now time.Time, pauses domain.Pauses) (int, int) {
+	days := make(map[string]bool)
+	counts := make(map[string]int)
+	starts := make(map[string]time.Time)
+
+	for _, e := range entries {
+		completed := e.CompletionDate.UTC()
+		dateKey := completed.Format("2006-01-02")
+		if days[dateKey] {
+			continue
+		}
+		days[dateKey] = true
+
+		start := habit.PeriodStart(completed)
+		periodKey := start.Format("2006-01-02")
+		counts[periodKey]++
+		starts[periodKey] = start
+	}
+
+	var met []time.Time
+	for periodKey, n := range counts {
+		if n >= habit.PeriodTarget() {
+			met = append(met, starts[periodKey])
+		}
+	}
+	if len(met) == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(met, func(i, j int) bool {
+		return met[i].Before(met[j])
+	})
+
+	longestStreak, run := 0, 0
+	for i, start := range met {
+		if i > 0 && nextCountedPeriod(habit, met[i-1], start, pauses).Equal(start) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longestStreak {
+			longestStreak = run
+		}
+	}
+
+	currentStreak := 0
+	last := met[len(met)-1]
+	thisPeriod := habit.PeriodStart(now)
+	if last.Equal(thisPeriod) || nextCountedPeriod(habit, last, thisPeriod, pauses).Equal(thisPeriod) {
+		currentStreak = run
+	}
+
+	return currentStreak, longestStreak
+}
+
+// nextCountedPeriod is the first period after start that does not overlap a
+// pause, looking no further than limit.
+func nextCountedPeriod(habit *domain.Habit, start, limit time.Time, pauses domain.Pauses) time.Time {
+	next := habit.NextPeriod(start)
+	for next.Before(limit) && periodPaused(habit, next, pauses) {
+		next = habit.NextPeriod(next)
+	}
+	return next
+}
+
+func periodPaused(habit *domain.Habit, start time.Time, pauses domain.Pauses) bool {
+	if len(pauses) == 0 {
+		return false
+	}
+	for day := start; day.Before(habit.NextPeriod(start)); day = day.AddDate(0, 0, 1) {
+		if pauses.Covers(habit.ID, day) {
+			return true
+		}
+	}
+	return false
+}
@@ -111,3 +111,261 @@ func TestCalculateStreaks(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculatePeriodStreaks(t *testing.T) {
+	// Wednesday: the current week started on Monday 12 October.
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	on := func(month time.Month, day int) *domain.HabitEntry {
+		return &domain.HabitEntry{CompletionDate: time.Date(2026, month, day, 18, 0, 0, 0, time.UTC)}
+	}
+
+	weekly := &domain.Habit{FrequencyType: domain.HabitFreqWeekly, TimesPerPeriod: 3}
+	monthly := &domain.Habit{FrequencyType: domain.HabitFreqMonthly, TimesPerPeriod: 2}
+
+	tests := []struct {
+		name        string
+		habit       *domain.Habit
+		entries     []*domain.HabitEntry
+		wantCurrent int
+		wantLongest int
+	}{
+		{
+			name:        "Empty entries",
+			habit:       weekly,
+			entries:     []*domain.HabitEntry{},
+			wantCurrent: 0,
+			wantLongest: 0,
+		},
+		{
+			name:  "Three runs last week keep the streak while this week is open",
+			habit: weekly,
+			entries: []*domain.HabitEntry{
+				on(time.October, 5), on(time.October, 7), on(time.October, 10),
+			},
+			wantCurrent: 1,
+			wantLongest: 1,
+		},
+		{
+			name:  "Met weeks in a row, including this one",
+			habit: weekly,
+			entries: []*domain.HabitEntry{
+				on(time.September, 29), on(time.September, 30), on(time.October, 2),
+				on(time.October, 5), on(time.October, 7), on(time.October, 10),
+				on(time.October, 12), on(time.October, 13), on(time.October, 14),
+			},
+			wantCurrent: 3,
+			wantLongest: 3,
+		},
+		{
+			name:  "Two entries on the same day count once",
+			habit: weekly,
+			entries: []*domain.HabitEntry{
+				on(time.October, 5), on(time.October, 5), on(time.October, 7),
+			},
+			wantCurrent: 0,
+			wantLongest: 0,
+		},
+		{
+			name:  "A missed week breaks the streak",
+			habit: weekly,
+			entries: []*domain.HabitEntry{
+				on(time.September, 15), on(time.September, 16), on(time.September, 17),
+				on(time.September, 22), on(time.September, 23), on(time.September, 24),
+				on(time.October, 5), on(time.October, 7),
+			},
+			wantCurrent: 0,
+			wantLongest: 2,
+		},
+		{
+			name:  "Monthly",
+			habit: monthly,
+			entries: []*domain.HabitEntry{
+				on(time.August, 1), on(time.August, 31),
+				on(time.September, 10), on(time.September, 11),
+				on(time.October, 1),
+			},
+			wantCurrent: 2,
+			wantLongest: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCurrent, gotLongest := calculatePeriodStreaks(tt.habit, tt.entries, now, nil)
+			assert.Equal(t, tt.wantCurrent, gotCurrent, "Current Streak mismatch")
+			assert.Equal(t, tt.wantLongest, gotLongest, "Longest Streak mismatch")
+		})
+	}
+}
+
+func TestCalculateScheduledStreaks(t *testing.T) {
+	// Wednesday 14 October 2026.
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	on := func(month time.Month, day int) *domain.HabitEntry {
+		return &domain.HabitEntry{CompletionDate: time.Date(2026, month, day, 18, 0, 0, 0, time.UTC)}
+	}
+
+	mondayWednesday := &domain.Habit{FrequencyType: domain.HabitFreqSpecificDays, Weekdays: []int{1, 3}, StartDate: start}
+	everyOtherTuesday := &domain.Habit{FrequencyType: domain.HabitFreqRRule, RRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", StartDate: time.Date(2026, 9, 22, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name        string
+		habit       *domain.Habit
+		entries     []*domain.HabitEntry
+		wantCurrent int
+		wantLongest int
+	}{
+		{
+			name:        "Empty entries",
+			habit:       mondayWednesday,
+			entries:     []*domain.HabitEntry{},
+			wantCurrent: 0,
+			wantLongest: 0,
+		},
+		{
+			name:  "Days off do not break the streak",
+			habit: mondayWednesday,
+			entries: []*domain.HabitEntry{
+				on(time.October, 5), on(time.October, 7), on(time.October, 12),
+			},
+			wantCurrent: 3,
+			wantLongest: 3,
+		},
+		{
+			name:  "Today extends the streak once done",
+			habit: mondayWednesday,
+			entries: []*domain.HabitEntry{
+				on(time.October, 7), on(time.October, 12), on(time.October, 14),
+			},
+			wantCurrent: 3,
+			wantLongest: 3,
+		},
+		{
+			name:  "Entries on days off do not count",
+			habit: mondayWednesday,
+			entries: []*domain.HabitEntry{
+				on(time.October, 10), on(time.October, 11), on(time.October, 12),
+			},
+			wantCurrent: 1,
+			wantLongest: 1,
+		},
+		{
+			name:  "A missed scheduled day breaks the streak",
+			habit: mondayWednesday,
+			entries: []*domain.HabitEntry{
+				on(time.September, 28), on(time.September, 30), on(time.October, 5),
+				on(time.October, 12),
+			},
+			wantCurrent: 1,
+			wantLongest: 3,
+		},
+		{
+			name:  "RRule: every other Tuesday",
+			habit: everyOtherTuesday,
+			entries: []*domain.HabitEntry{
+				on(time.September, 22), on(time.October, 6),
+			},
+			wantCurrent: 2,
+			wantLongest: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCurrent, gotLongest := calculateScheduledStreaks(tt.habit, tt.entries, now, nil)
+			assert.Equal(t, tt.wantCurrent, gotCurrent, "Current Streak mismatch")
+			assert.Equal(t, tt.wantLongest, gotLongest, "Longest Streak mismatch")
+		})
+	}
+}
+
+func TestCalculateStreaks_Pauses(t *testing.T) {
+	// Wednesday 14 October 2026.
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	on := func(month time.Month, day int) *domain.HabitEntry {
+		return &domain.HabitEntry{CompletionDate: date(month, day).Add(18 * time.Hour)}
+	}
+	pause := func(habitID string, from time.Time, to *time.Time) domain.Pauses {
+		return domain.Pauses{{HabitID: habitID, StartDate: from, EndDate: to}}
+	}
+
+	daily := &domain.Habit{ID: "daily", FrequencyType: domain.HabitFreqDaily, StartDate: start}
+	weekly := &domain.Habit{ID: "weekly", FrequencyType: domain.HabitFreqWeekly, TimesPerPeriod: 2, StartDate: start}
+
+	vacationEnd := date(time.October, 9)
+
+	t.Run("Daily: A vacation neither breaks nor extends the streak", func(t *testing.T) {
+		entries := []*domain.HabitEntry{
+			on(time.October, 1), on(time.October, 2), on(time.October, 6),
+			on(time.October, 10), on(time.October, 11), on(time.October, 12), on(time.October, 13),
+		}
+
+		current, longest := calculateScheduledStreaks(daily, entries, now, pause("", date(time.October, 3), &vacationEnd))
+
+		assert.Equal(t, 6, current, "The entry logged during the vacation does not count")
+		assert.Equal(t, 6, longest)
+	})
+
+	t.Run("Daily: Without the pause the same days break the streak", func(t *testing.T) {
+		entries := []*domain.HabitEntry{
+			on(time.October, 1), on(time.October, 2),
+			on(time.October, 10), on(time.October, 11), on(time.October, 12), on(time.October, 13),
+		}
+
+		current, longest := calculateScheduledStreaks(daily, entries, now, nil)
+
+		assert.Equal(t, 4, current)
+		assert.Equal(t, 4, longest)
+	})
+
+	t.Run("Daily: Dates chosen east of UTC cover the same days", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Skip("timezone data not available")
+		}
+		parse := func(value string) time.Time {
+			d, err := domain.ParseHabitDate(value, tokyo)
+			assert.NoError(t, err)
+			return d
+		}
+
+		habit := &domain.Habit{ID: "daily", FrequencyType: domain.HabitFreqDaily, StartDate: parse("2026-10-08"), StartDateSet: true}
+		pauseEnd := parse("2026-10-11")
+		// Completed at noon in Tokyo.
+		entries := []*domain.HabitEntry{}
+		for _, day := range []int{8, 9, 12, 13} {
+			entries = append(entries, &domain.HabitEntry{CompletionDate: time.Date(2026, time.October, day, 3, 0, 0, 0, time.UTC)})
+		}
+
+		current, longest := calculateScheduledStreaks(habit, entries, now, pause("", parse("2026-10-10"), &pauseEnd))
+
+		assert.Equal(t, 4, current, "The pause covers 10 and 11 October, not the UTC days its local midnights fall on")
+		assert.Equal(t, 4, longest)
+	})
+
+	t.Run("Daily: An open-ended pause keeps the streak frozen", func(t *testing.T) {
+		entries := []*domain.HabitEntry{on(time.October, 8), on(time.October, 9)}
+
+		current, _ := calculateScheduledStreaks(daily, entries, now, pause("daily", date(time.October, 10), nil))
+
+		assert.Equal(t, 2, current)
+	})
+
+	t.Run("Weekly: A paused week does not break the streak", func(t *testing.T) {
+		entries := []*domain.HabitEntry{
+			on(time.September, 22), on(time.September, 23),
+			on(time.October, 6), on(time.October, 7),
+		}
+		pausedWeek := date(time.October, 4)
+
+		current, longest := calculatePeriodStreaks(weekly, entries, now, pause("weekly", date(time.September, 30), &pausedWeek))
+
+		assert.Equal(t, 2, current)
+		assert.Equal(t, 2, longest)
+	})
+}
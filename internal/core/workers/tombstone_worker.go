@@ -0,0 +1,83 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const tombstoneBatchSize = 1000
+
+type TombstoneRepository interface {
+	PurgeTombstones(ctx context.Context, olderThan time.Time, limit int) (int64, error)
+}
+
+// TombstoneWorker periodically hard-deletes soft-deleted habits and entries
+// once they are older than the retention window. Clients that have not
+// synced within that window are asked for a full resync instead.
+type TombstoneWorker struct {
+	repo      TombstoneRepository
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+	wg        sync.WaitGroup
+}
+
+func NewTombstoneWorker(repo TombstoneRepository, retention, interval time.Duration) *TombstoneWorker {
+	return &TombstoneWorker{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+func (w *TombstoneWorker) Start(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		log.Printf("Tombstone Worker started (retention %s, every %s)...", w.retention, w.interval)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.purge(ctx)
+			case <-ctx.Done():
+				log.Println("Tombstone Worker stopping...")
+				return
+			}
+		}
+	}()
+}
+
+func (w *TombstoneWorker) Stop() {
+	w.wg.Wait()
+	log.Println("Tombstone Worker stopped gracefully.")
+}
+
+// purge deletes expired tombstones in batches, so a large backlog does not
+// hold locks in one long statement.
+func (w *TombstoneWorker) purge(ctx context.Context) {
+	cutoff := w.now().UTC().Add(-w.retention)
+
+	var total int64
+	for ctx.Err() == nil {
+		n, err := w.repo.PurgeTombstones(ctx, cutoff, tombstoneBatchSize)
+		if err != nil {
+			log.Printf("Tombstone Worker failed to purge: %v", err)
+			return
+		}
+		total += n
+		if n < tombstoneBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Tombstone Worker purged %d tombstones older than %s", total, cutoff.Format(time.RFC3339))
+	}
+}
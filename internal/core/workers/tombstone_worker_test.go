@@ -0,0 +1,67 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTombstoneRepo struct {
+	batches []int64
+	err     error
+	cutoffs []time.Time
+}
+
+func (f *fakeTombstoneRepo) PurgeTombstones(ctx context.Context, olderThan time.Time, limit int) (int64, error) {
+	f.cutoffs = append(f.cutoffs, olderThan)
+	if f.err != nil {
+		return 0, f.err
+	}
+	if len(f.batches) == 0 {
+		return 0, nil
+	}
+	n := f.batches[0]
+	f.batches = f.batches[1:]
+	return n, nil
+}
+
+func TestTombstoneWorker_Purge(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	retention := 30 * 24 * time.Hour
+
+	t.Run("Purges in batches until the backlog is drained", func(t *testing.T) {
+		repo := &fakeTombstoneRepo{batches: []int64{tombstoneBatchSize, tombstoneBatchSize, 10}}
+		w := NewTombstoneWorker(repo, retention, time.Hour)
+		w.now = func() time.Time { return now }
+
+		w.purge(context.Background())
+
+		assert.Len(t, repo.cutoffs, 3)
+		for _, cutoff := range repo.cutoffs {
+			assert.Equal(t, now.Add(-retention), cutoff, "Only tombstones older than the retention window are purged")
+		}
+	})
+
+	t.Run("Stops on repository errors", func(t *testing.T) {
+		repo := &fakeTombstoneRepo{err: errors.New("db down")}
+		w := NewTombstoneWorker(repo, retention, time.Hour)
+
+		w.purge(context.Background())
+
+		assert.Len(t, repo.cutoffs, 1)
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		repo := &fakeTombstoneRepo{batches: []int64{tombstoneBatchSize, tombstoneBatchSize}}
+		w := NewTombstoneWorker(repo, retention, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w.purge(ctx)
+
+		assert.Empty(t, repo.cutoffs)
+	})
+}